@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [RTM_FILE]",
+	Short: "Show what an RTM file would change in the database",
+	Long: `Compare an RTM file (YAML or JSON, or a CSV/XLSX backlog) with the data currently stored for a project.
+
+Added, removed and modified components, requirements (field by field),
+implementations and test links are listed. Run this before re-importing an
+LLM-regenerated RTM in update mode to spot titles, descriptions or statuses
+that were edited in the UI and would be overwritten.
+
+Nothing is written to the database.
+
+Example:
+  tracevibe diff rtm-data.json --project statsly
+  tracevibe diff rtm-data.yaml --project statsly --db-path /custom/path/tracevibe.db`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rtmFile := args[0]
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		mappingFile, _ := cmd.Flags().GetString("mapping")
+
+		if _, err := os.Stat(rtmFile); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: RTM file not found: %s\n", rtmFile)
+			os.Exit(1)
+		}
+
+		var mapping *importer.ColumnMapping
+		if mappingFile != "" {
+			var err error
+			if mapping, err = importer.LoadColumnMapping(mappingFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		diff, err := runDiff(rtmFile, projectKey, dbPath, mapping)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error comparing RTM data: %v\n", err)
+			os.Exit(1)
+		}
+		printDiff(rtmFile, diff)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	diffCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	diffCmd.Flags().String("mapping", "", "Column mapping file (YAML or JSON) for CSV/XLSX files")
+
+	diffCmd.MarkFlagRequired("project")
+}
+
+func runDiff(rtmFile, projectKey, dbPath string, mapping *importer.ColumnMapping) (*importer.Diff, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	return importer.New(db).DiffRTMFile(rtmFile, projectKey, mapping)
+}
+
+func printDiff(rtmFile string, diff *importer.Diff) {
+	fmt.Printf("Comparing %s with project '%s'\n", rtmFile, diff.ProjectKey)
+	if !diff.ProjectExists {
+		fmt.Println("(project does not exist yet; everything in the file is new)")
+	}
+
+	if len(diff.Issues) > 0 {
+		fmt.Printf("\n⚠ The file has %d validation error(s) and would be rejected by import:\n", len(diff.Issues))
+		for _, issue := range diff.Issues {
+			fmt.Printf("  - %s\n", issue)
+		}
+	}
+
+	if diff.Empty() {
+		fmt.Println("\nNo differences.")
+		return
+	}
+
+	printDiffSection("Components", &diff.Components)
+	printDiffSection("Requirements", &diff.Requirements)
+	printDiffSection("Implementations", &diff.Implementations)
+	printDiffSection("Test links", &diff.TestLinks)
+
+	fmt.Println("\nSummary:")
+	for _, s := range []struct {
+		name    string
+		section *importer.DiffSection
+	}{
+		{"components", &diff.Components},
+		{"requirements", &diff.Requirements},
+		{"implementations", &diff.Implementations},
+		{"test links", &diff.TestLinks},
+	} {
+		fmt.Printf("  %-16s %d added, %d removed, %d modified\n", s.name,
+			len(s.section.Added), len(s.section.Removed), len(s.section.Modified))
+	}
+}
+
+func printDiffSection(title string, section *importer.DiffSection) {
+	if len(section.Added) == 0 && len(section.Removed) == 0 && len(section.Modified) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s:\n", title)
+	for _, key := range section.Added {
+		fmt.Printf("  + %s\n", key)
+	}
+	for _, key := range section.Removed {
+		fmt.Printf("  - %s\n", key)
+	}
+	for _, entity := range section.Modified {
+		fmt.Printf("  ~ %s\n", entity.Key)
+		for _, change := range entity.Changes {
+			fmt.Printf("      %s: %q → %q\n", change.Field, change.Old, change.New)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a project as HTML, JSON, YAML, Markdown, ReqIF, CSV, XLSX, DOT or Mermaid",
+	Long: `Export a project in any of the formats the web UI offers, without starting the
+server:
+
+  html        standalone HTML report
+  compliance  print-ready audit report (see 'tracevibe report' for options)
+  json        RTM file that can be imported again (also yaml)
+  markdown    report for people and LLM prompts; --mermaid adds a diagram of
+              each component
+  reqif       ReqIF document for DOORS, Polarion and other RM tools
+  csv         flat traceability matrix: one row per requirement with its
+              parent chain, component, status, priority, implementation files
+              and functions, and the linked test cases of each test type
+              (also xlsx)
+  dot         Graphviz graph of components, requirements, implementation
+              files, API endpoints and tests with typed edges (also mermaid)
+
+Every format can be narrowed down to a slice of the project:
+
+  --component  requirements of these components
+  --tag        requirements of components with these tags
+  --status     requirements with these statuses
+  --type       requirements of these types (scope, user_story, tech_spec)
+  --root       one requirement and its descendants
+
+Each filter but --root takes one value or a comma-separated list, and filters
+combine. The csv and xlsx matrices list only the matching requirements; the
+other formats keep their parents too, so every requirement still hangs under
+its scope and json, yaml and reqif exports can be imported again.
+
+The web server offers the same exports at /export-json/PROJECT,
+/export-csv/PROJECT and so on, with the flags as query parameters
+(?component=COMP-001&tag=api&status=implemented, ?root=SCOPE-3&type=tech_spec,
+?mermaid=1).
+
+Output goes to stdout unless --output names a file; xlsx needs --output.
+
+Example:
+  tracevibe export --project statsly > matrix.csv
+  tracevibe export --project statsly --format json -o statsly-rtm.json
+  tracevibe export --project statsly --format html --component COMP-001 -o report.html
+  tracevibe export --project statsly --format json --root SCOPE-3 -o scope-3-rtm.json
+  tracevibe export --project statsly --format xlsx -o matrix.xlsx --status implemented,in_progress
+  tracevibe export --project statsly --format dot --root SCOPE-3 | dot -Tsvg > scope-3.svg
+  tracevibe export --project statsly --format markdown --mermaid -o RTM.md`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		formatName, _ := cmd.Flags().GetString("format")
+		outputFile, _ := cmd.Flags().GetString("output")
+		var opts export.Options
+		opts.Component, _ = cmd.Flags().GetString("component")
+		opts.Tag, _ = cmd.Flags().GetString("tag")
+		opts.Status, _ = cmd.Flags().GetString("status")
+		opts.Type, _ = cmd.Flags().GetString("type")
+		opts.Root, _ = cmd.Flags().GetString("root")
+		opts.Mermaid, _ = cmd.Flags().GetBool("mermaid")
+
+		format, err := export.LookupFormat(formatName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if format.Binary() && outputFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: --output is required for %s\n", format.Name)
+			os.Exit(1)
+		}
+
+		if err := runExport(projectKey, dbPath, format, outputFile, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error exporting project: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	exportCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	exportCmd.Flags().StringP("format", "f", "csv", "Output format: html, compliance, json, yaml, markdown, reqif, csv, xlsx, dot or mermaid")
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	exportCmd.Flags().String("component", "", "Only requirements of these components (comma-separated keys)")
+	exportCmd.Flags().String("tag", "", "Only requirements of components with these tags (comma-separated)")
+	exportCmd.Flags().String("status", "", "Only requirements with these statuses (comma-separated)")
+	exportCmd.Flags().String("type", "", "Only requirements of these types: scope, user_story, tech_spec (comma-separated)")
+	exportCmd.Flags().String("root", "", "Only this requirement key and its descendants")
+	exportCmd.Flags().Bool("mermaid", false, "markdown: embed a Mermaid diagram of each component")
+
+	exportCmd.MarkFlagRequired("project")
+}
+
+func runExport(projectKey, dbPath string, format *export.Format, outputFile string, opts export.Options) error {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	if outputFile == "" {
+		return format.Write(os.Stdout, db, projectKey, opts)
+	}
+
+	// Render in memory, so a failed export does not leave a partial file
+	var buf bytes.Buffer
+	if err := format.Write(&buf, db, projectKey, opts); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported project '%s' as %s to %s\n", projectKey, format.Name, outputFile)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/peshwar9/tracevibe/internal/schema"
 	"github.com/peshwar9/tracevibe/internal/templates"
 	"github.com/spf13/cobra"
 )
@@ -21,11 +22,15 @@ The guidelines include:
 - Requirement granularity principles
 - Hierarchical structure: Scope -> User Stories -> Tech Specs
 - Test mapping strategies
-- JSON/YAML format specifications`,
+- JSON/YAML format specifications
+
+Use --schema-file to also write the RTM JSON Schema, which the importer
+validates every file against, so the LLM (or your editor) can check its output.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		outputFile, _ := cmd.Flags().GetString("output")
 		promptFile, _ := cmd.Flags().GetString("prompt-file")
 		includePrompt, _ := cmd.Flags().GetBool("with-prompt")
+		schemaFile, _ := cmd.Flags().GetString("schema-file")
 
 		if err := generateGuidelines(outputFile); err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating guidelines: %v\n", err)
@@ -33,6 +38,14 @@ The guidelines include:
 		}
 		fmt.Printf("✅ RTM guidelines generated: %s\n", outputFile)
 
+		if schemaFile != "" {
+			if err := os.WriteFile(schemaFile, schema.RTM(), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing schema file: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ RTM JSON Schema (version %s) generated: %s\n", schema.Version, schemaFile)
+		}
+
 		// Generate LLM prompt if requested
 		if includePrompt || promptFile != "" {
 			prompt := generateLLMPrompt()
@@ -62,6 +75,7 @@ func init() {
 	guidelinesCmd.Flags().StringP("output", "o", "rtm-guidelines.md", "Output file for guidelines")
 	guidelinesCmd.Flags().BoolP("with-prompt", "p", false, "Display LLM prompt to console")
 	guidelinesCmd.Flags().String("prompt-file", "", "Save LLM prompt to specified file")
+	guidelinesCmd.Flags().String("schema-file", "", "Save the RTM JSON Schema to specified file")
 }
 
 func generateGuidelines(outputFile string) error {
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/peshwar9/tracevibe/internal/database"
 	"github.com/peshwar9/tracevibe/internal/importer"
@@ -12,7 +16,7 @@ import (
 
 var importCmd = &cobra.Command{
 	Use:   "import [RTM_FILE]",
-	Short: "Import RTM data from YAML/JSON file",
+	Short: "Import RTM data from a YAML/JSON file, a ReqIF document or a CSV/XLSX backlog",
 	Long: `Import Requirements Traceability Matrix data from YAML or JSON file into the local SQLite database.
 
 The RTM file should follow the hierarchical structure:
@@ -24,10 +28,69 @@ The RTM file should follow the hierarchical structure:
 Import Modes:
 - Default (update): Add new requirements and update existing ones by requirement key
 - --overwrite: Delete all existing project data and reimport everything fresh
+- --merge: Like update, but keep fields edited in the UI since the last import.
+  Fields changed both by a human and in the file are conflicts: the human edit
+  is kept unless resolved with --resolve KEY:field=file or --interactive.
+  Implementation and test links are added to, never removed.
+- --prune: In update or merge mode, delete components, requirements, API
+  endpoints and test links that came from an earlier import but are no longer
+  in the file. --prune=mark keeps them flagged as pruned instead (requirements
+  become deprecated). Records created in the UI or by 'tracevibe scan' are
+  never pruned. Requirements that still have children and components that
+  still have requirements are marked rather than deleted.
+
+The file is validated before anything is written: unknown component IDs,
+duplicate requirement keys, missing mandatory fields, invalid priority/status
+values and misplaced children are all reported at once. Use --dry-run to see
+what would be created, updated or deleted without touching the database.
+
+CSV and XLSX backlogs are imported one requirement per row. By default the
+header row names the columns Key, Type, Parent, Component, Title, Description,
+Priority, Status, Category and Acceptance Criteria (one criterion per line);
+--mapping points at a YAML or JSON file that names other columns:
+
+  sheet: Backlog            # XLSX only, default is the first sheet
+  columns:
+    key: Issue key
+    parent_key: Parent
+    title: Summary
+  types: {Epic: scope, Story: user_story, Task: tech_spec}
+  components:
+    - {id: COMP-001, name: API Server, type: backend_service}
+
+The parent column rebuilds the scope -> user story -> tech spec tree; rows
+without a type get one from their depth. Top-level rows need a component.
+
+ReqIF (.reqif) documents from DOORS, Polarion and similar tools are imported
+as exported by /export-reqif/: SPEC-OBJECTs become components, requirements
+and tests, matched by the LONG-NAME of their type and attributes. The
+hierarchy comes from the SPECIFICATIONs, or from "Refines" relations;
+ReqIF.ForeignID holds the requirement key, falling back to the identifier.
+
+After the import a report lists what was created, updated and pruned per
+entity, plus warnings about parts of the file that were ignored (such as
+unknown test types), located by JSON path. --format json prints the report
+(or the dry-run plan) as JSON; the exit status is non-zero when it failed.
+
+--source-root records where the project's source code is checked out. The
+server runs its tests and links its files there, and 'tracevibe scan' scans it
+by default; without one the server's --project-base-path is used. Components
+can narrow it down with a path (their directory) and a base_path (the
+directory their file paths are relative to) in the RTM file, both inside the
+source root. The server cannot change the source root.
 
 Example:
   tracevibe import my-project-rtm.yaml --project my-project
   tracevibe import rtm-data.json --project statsly --overwrite
+  tracevibe import rtm-data.json --project statsly --dry-run
+  tracevibe import rtm-data.json --project statsly --source-root ~/src/statsly
+  tracevibe import rtm-data.json --project statsly --format json
+  tracevibe import backlog.xlsx --project statsly --mapping backlog-mapping.yaml
+  tracevibe import statsly.reqif --project statsly --merge
+  tracevibe import rtm-data.json --project statsly --prune
+  tracevibe import rtm-data.json --project statsly --merge --prune=mark
+  tracevibe import rtm-data.json --project statsly --merge --interactive
+  tracevibe import rtm-data.json --project statsly --merge --resolve SCOPE-1-US-2:status=file
   tracevibe import rtm-data.json --project statsly --db-path /custom/path/tracevibe.db`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -35,6 +98,14 @@ Example:
 		projectKey, _ := cmd.Flags().GetString("project")
 		dbPath, _ := cmd.Flags().GetString("db-path")
 		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		merge, _ := cmd.Flags().GetBool("merge")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		resolveFlags, _ := cmd.Flags().GetStringSlice("resolve")
+		pruneFlag, _ := cmd.Flags().GetString("prune")
+		format, _ := cmd.Flags().GetString("format")
+		mappingFile, _ := cmd.Flags().GetString("mapping")
+		sourceRoot, _ := cmd.Flags().GetString("source-root")
 
 		if projectKey == "" {
 			fmt.Fprintf(os.Stderr, "Error: --project flag is required\n")
@@ -47,16 +118,117 @@ Example:
 			os.Exit(1)
 		}
 
-		if err := runImport(rtmFile, projectKey, dbPath, overwrite); err != nil {
-			fmt.Fprintf(os.Stderr, "Error importing RTM data: %v\n", err)
+		if format != "text" && format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --format %q (use text or json)\n", format)
+			os.Exit(1)
+		}
+		if interactive && format == "json" {
+			fmt.Fprintf(os.Stderr, "Error: --interactive cannot be combined with --format json\n")
+			os.Exit(1)
+		}
+
+		if merge && overwrite {
+			fmt.Fprintf(os.Stderr, "Error: --merge and --overwrite cannot be combined\n")
+			os.Exit(1)
+		}
+		if (interactive || len(resolveFlags) > 0) && !merge {
+			fmt.Fprintf(os.Stderr, "Error: --interactive and --resolve require --merge\n")
 			os.Exit(1)
 		}
 
-		mode := "updated"
+		if sourceRoot != "" {
+			var err error
+			if sourceRoot, err = checkSourceRoot(sourceRoot); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		prune, err := importer.ParsePrune(pruneFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if prune != "" && overwrite {
+			fmt.Fprintf(os.Stderr, "Error: --prune and --overwrite cannot be combined\n")
+			os.Exit(1)
+		}
+
+		opts := importer.Options{Mode: importer.ModeUpdate, Resolutions: map[string]string{}, Prune: prune}
+		if mappingFile != "" {
+			if opts.Mapping, err = importer.LoadColumnMapping(mappingFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		if overwrite {
-			mode = "overwritten and reimported"
+			opts.Mode = importer.ModeOverwrite
+		} else if merge {
+			opts.Mode = importer.ModeMerge
+		}
+		for _, resolve := range resolveFlags {
+			id, resolution, ok := strings.Cut(resolve, "=")
+			if !ok || (resolution != importer.ResolveFile && resolution != importer.ResolveDatabase) {
+				fmt.Fprintf(os.Stderr, "Error: invalid --resolve %q (expected KEY:field=file or KEY:field=database)\n", resolve)
+				os.Exit(1)
+			}
+			opts.Resolutions[id] = resolution
+		}
+
+		if dryRun {
+			plan, err := runImportPlan(rtmFile, projectKey, dbPath, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error planning import: %v\n", err)
+				os.Exit(1)
+			}
+			var conflicts []importer.Conflict
+			if merge && len(plan.Issues) == 0 {
+				conflicts, err = runMergeConflicts(rtmFile, projectKey, dbPath, opts.Mapping)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error checking merge conflicts: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if format == "json" {
+				printJSON(struct {
+					*importer.ImportPlan
+					Conflicts []importer.Conflict `json:"conflicts,omitempty"`
+				}{plan, conflicts})
+			} else {
+				printImportPlan(rtmFile, plan)
+				if merge && len(plan.Issues) == 0 {
+					printConflicts("\nMerge conflicts", conflicts)
+				}
+			}
+			if len(plan.Issues) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		report, err := runImport(rtmFile, projectKey, dbPath, opts, interactive, sourceRoot)
+		if format == "json" && report != nil {
+			printJSON(report)
+			if err != nil {
+				os.Exit(1)
+			}
+			return
 		}
-		fmt.Printf("Successfully %s RTM data for project '%s'\n", mode, projectKey)
+		if err != nil {
+			var validationErr *importer.ValidationError
+			if report != nil {
+				printReportWarnings(report)
+				if !errors.As(err, &validationErr) && len(report.Errors) == 1 && report.Errors[0].Path != "" {
+					fmt.Fprintf(os.Stderr, "Error importing RTM data at %s: %v\n", report.Errors[0].Path, err)
+					os.Exit(1)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Error importing RTM data: %v\n", err)
+			os.Exit(1)
+		}
+
+		printImportReport(report)
 		fmt.Printf("Database: %s\n", dbPath)
 		fmt.Printf("Use 'tracevibe serve' to view the data in the admin UI\n")
 	},
@@ -68,32 +240,265 @@ func init() {
 	importCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
 	importCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
 	importCmd.Flags().Bool("overwrite", false, "Delete existing project data before import (default: update mode)")
+	importCmd.Flags().Bool("dry-run", false, "Validate the file and print what would change without writing to the database")
+	importCmd.Flags().Bool("merge", false, "Keep fields edited in the UI since the last import (three-way merge)")
+	importCmd.Flags().Bool("interactive", false, "Ask how to resolve each merge conflict")
+	importCmd.Flags().StringSlice("resolve", nil, "Resolve a merge conflict: KEY:field=file or KEY:field=database (repeatable)")
+	importCmd.Flags().String("prune", "", "Remove (delete) or flag (mark) imported records missing from the file")
+	importCmd.Flags().Lookup("prune").NoOptDefVal = importer.PruneDelete
+	importCmd.Flags().String("format", "text", "Report format: text or json")
+	importCmd.Flags().String("mapping", "", "Column mapping file (YAML or JSON) for CSV/XLSX imports")
+	importCmd.Flags().String("source-root", "", "Directory the project's source code is checked out in")
 
 	importCmd.MarkFlagRequired("project")
 }
 
-func runImport(rtmFile, projectKey, dbPath string, overwrite bool) error {
+func runImport(rtmFile, projectKey, dbPath string, opts importer.Options, interactive bool, sourceRoot string) (*importer.ImportReport, error) {
 	// Initialize database
 	db, err := database.New(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer db.Close()
 
 	// Initialize schema if needed
 	if err := db.InitSchema(); err != nil {
-		return fmt.Errorf("failed to initialize database schema: %w", err)
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
 	}
 
 	// Create importer and run import
 	imp := importer.New(db)
-	if err := imp.ImportRTMFile(rtmFile, projectKey, overwrite); err != nil {
-		return fmt.Errorf("failed to import RTM data: %w", err)
+
+	if interactive {
+		conflicts, err := imp.MergeConflicts(rtmFile, projectKey, opts.Mapping)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check merge conflicts: %w", err)
+		}
+		if err := promptResolutions(conflicts, opts.Resolutions); err != nil {
+			return nil, err
+		}
+	}
+
+	// The report is returned on failure too: it locates the errors in the file
+	report, err := imp.Import(rtmFile, projectKey, opts)
+	if err != nil {
+		return report, fmt.Errorf("failed to import RTM data: %w", err)
+	}
+
+	if sourceRoot != "" {
+		project, err := db.GetProjectByKey(projectKey)
+		if err != nil || project == nil {
+			return report, fmt.Errorf("failed to find project %s: %v", projectKey, err)
+		}
+		if err := db.SaveSourceRoot(project.ID, sourceRoot); err != nil {
+			return report, fmt.Errorf("failed to save source root: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+func printImportReport(report *importer.ImportReport) {
+	mode := "updated"
+	switch report.Mode {
+	case importer.ModeOverwrite:
+		mode = "overwritten and reimported"
+	case importer.ModeMerge:
+		mode = "merged"
+	}
+	fmt.Printf("Successfully %s RTM data for project '%s' from %s (%d ms)\n",
+		mode, report.ProjectKey, report.Filename, report.DurationMs)
+
+	for _, entity := range report.Entities() {
+		c := report.Counts[entity]
+		fmt.Printf("  %-13s %d created, %d updated", entity, c.Created, c.Updated)
+		if c.Pruned > 0 {
+			fmt.Printf(", %d pruned", c.Pruned)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Recorded as import %s (see 'tracevibe imports list')\n", report.ImportID)
+
+	printReportWarnings(report)
+	if unresolved := report.Unresolved(); len(unresolved) > 0 {
+		printConflicts("⚠ Unresolved conflicts (kept the database value)", unresolved)
+		fmt.Printf("Re-run with --resolve KEY:field=file to take the file's value\n")
+	}
+	if len(report.Pruned) > 0 {
+		printPruned(report.Pruned)
+	}
+}
+
+func printReportWarnings(report *importer.ImportReport) {
+	if len(report.Warnings) == 0 {
+		return
+	}
+	fmt.Printf("⚠ Warnings (%d):\n", len(report.Warnings))
+	for _, warning := range report.Warnings {
+		fmt.Printf("  - %s\n", warning)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func runMergeConflicts(rtmFile, projectKey, dbPath string, mapping *importer.ColumnMapping) ([]importer.Conflict, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer db.Close()
 
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	return importer.New(db).MergeConflicts(rtmFile, projectKey, mapping)
+}
+
+// promptResolutions asks on the terminal how to resolve each conflict that has
+// no resolution yet
+func promptResolutions(conflicts []importer.Conflict, resolutions map[string]string) error {
+	reader := bufio.NewReader(os.Stdin)
+	for _, c := range conflicts {
+		if _, resolved := resolutions[c.ID()]; resolved {
+			continue
+		}
+		fmt.Printf("\nConflict in %s (%s)\n", c.RequirementKey, c.Field)
+		fmt.Printf("  last import: %q\n", c.Base)
+		fmt.Printf("  database:    %q\n", c.Database)
+		fmt.Printf("  file:        %q\n", c.File)
+
+		for {
+			fmt.Print("Keep [d]atabase or take [f]ile? [d] ")
+			answer, err := reader.ReadString('\n')
+			if err != nil && answer == "" {
+				return fmt.Errorf("failed to read answer: %w", err)
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "", "d", "database":
+				resolutions[c.ID()] = importer.ResolveDatabase
+			case "f", "file":
+				resolutions[c.ID()] = importer.ResolveFile
+			default:
+				continue
+			}
+			break
+		}
+	}
 	return nil
 }
 
+func printConflicts(title string, conflicts []importer.Conflict) {
+	if len(conflicts) == 0 {
+		fmt.Printf("%s: none\n", title)
+		return
+	}
+	fmt.Printf("%s (%d):\n", title, len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  %s\n      database: %q\n      file:     %q\n", c.ID(), c.Database, c.File)
+	}
+}
+
+func printPruned(pruned []importer.PrunedRecord) {
+	if len(pruned) == 0 {
+		fmt.Println("Pruned: nothing missing from the file")
+		return
+	}
+	fmt.Printf("Pruned (%d):\n", len(pruned))
+	for _, record := range pruned {
+		if record.Reason != "" {
+			fmt.Printf("  %-8s %-13s %s (%s)\n", record.Action, record.Entity, record.Key, record.Reason)
+		} else {
+			fmt.Printf("  %-8s %-13s %s\n", record.Action, record.Entity, record.Key)
+		}
+	}
+}
+
+func runImportPlan(rtmFile, projectKey, dbPath string, opts importer.Options) (*importer.ImportPlan, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	return importer.New(db).PlanRTMFile(rtmFile, projectKey, opts)
+}
+
+func printImportPlan(rtmFile string, plan *importer.ImportPlan) {
+	mode := string(plan.Mode)
+	if plan.Prune != "" {
+		mode += ", prune=" + plan.Prune
+	}
+	fmt.Printf("Dry run: importing %s into project '%s' (%s mode)\n\n", rtmFile, plan.ProjectKey, mode)
+
+	if len(plan.Issues) > 0 {
+		fmt.Printf("❌ Validation errors (%d):\n", len(plan.Issues))
+		for _, issue := range plan.Issues {
+			fmt.Printf("  - %s\n", issue)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Planned changes:")
+	changes := 0
+	for _, entry := range plan.Entries {
+		if entry.Action == importer.ActionUnchanged {
+			continue
+		}
+		changes++
+		fmt.Printf("  %-9s %-15s %s\n", entry.Action, entry.Entity, entry.Key)
+	}
+	if changes == 0 {
+		fmt.Println("  (none)")
+	}
+
+	fmt.Println("\nSummary:")
+	counts := plan.Counts()
+	for _, entity := range []string{"project", "component", "requirement", "implementation", "test_link", "api_endpoint"} {
+		c, ok := counts[entity]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  %-15s %d create, %d update, %d delete, %d unchanged", entity,
+			c[importer.ActionCreate], c[importer.ActionUpdate], c[importer.ActionDelete], c[importer.ActionUnchanged])
+		if c[importer.ActionMark] > 0 {
+			fmt.Printf(", %d mark", c[importer.ActionMark])
+		}
+		if c[importer.ActionConflict] > 0 {
+			fmt.Printf(", %d conflict", c[importer.ActionConflict])
+		}
+		fmt.Println()
+	}
+
+	if len(plan.Issues) > 0 {
+		fmt.Printf("\nThe import would be rejected until the validation errors are fixed.\n")
+	}
+}
+
+// checkSourceRoot makes a source root absolute and checks that it is a
+// directory; "" stays empty
+func checkSourceRoot(root string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid source root: %w", err)
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("source root is not a directory: %s", abs)
+	}
+	return abs, nil
+}
+
 func getDefaultDBPath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var importsCmd = &cobra.Command{
+	Use:   "imports",
+	Short: "Show the import history",
+	Long: `Show which RTM files were imported, when, by which generator and with what effect.
+
+Every import records the file name, a sha256 of its contents, the
+metadata.generated_by value (usually the LLM that wrote the file), the import
+mode, how many entities it created and updated, and how long it took. Each
+requirement also remembers the import that last touched it.`,
+}
+
+var importsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List past imports, newest first",
+	Long: `List past imports, newest first.
+
+Example:
+  tracevibe imports list
+  tracevibe imports list --project statsly --limit 5
+  tracevibe imports list --project statsly --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		limit, _ := cmd.Flags().GetInt("limit")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		imports, err := runImportsList(projectKey, dbPath, limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing imports: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON {
+			if imports == nil {
+				imports = []*database.Import{}
+			}
+			printJSON(imports)
+			return
+		}
+		printImports(imports)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importsCmd)
+	importsCmd.AddCommand(importsListCmd)
+
+	importsListCmd.Flags().StringP("project", "p", "", "Only show imports into this project")
+	importsListCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	importsListCmd.Flags().IntP("limit", "n", 20, "Maximum number of imports to show (0 for all)")
+	importsListCmd.Flags().Bool("json", false, "Print the history as JSON")
+}
+
+func runImportsList(projectKey, dbPath string, limit int) ([]*database.Import, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	if projectKey != "" {
+		project, err := db.GetProjectByKey(projectKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		if project == nil {
+			return nil, fmt.Errorf("project not found: %s", projectKey)
+		}
+	}
+
+	return db.ListImports(projectKey, limit)
+}
+
+func printImports(imports []*database.Import) {
+	if len(imports) == 0 {
+		fmt.Println("No imports recorded.")
+		return
+	}
+
+	for _, imp := range imports {
+		mode := imp.Mode
+		if imp.Prune != "" {
+			mode += ", prune=" + imp.Prune
+		}
+		generator := imp.GeneratedBy
+		if generator == "" {
+			generator = "unknown generator"
+		}
+
+		fmt.Printf("%s  %s  %s (%s)\n", imp.ImportedAt, imp.ProjectKey, imp.Filename, imp.ShortHash())
+		fmt.Printf("    %s, %s mode, %d ms\n", generator, mode, imp.DurationMs)
+		fmt.Printf("    requirements: %d created, %d updated; components: %d created; last to touch %d requirement(s)\n",
+			imp.Created("requirement"), imp.Updated("requirement"), imp.Created("component"), imp.Requirements)
+		if pruned := prunedTotal(imp); pruned > 0 {
+			fmt.Printf("    pruned: %d record(s)\n", pruned)
+		}
+		fmt.Printf("    id: %s\n", imp.ID)
+	}
+}
+
+func prunedTotal(imp *database.Import) int {
+	total := 0
+	for _, c := range imp.Counts {
+		total += c.Pruned
+	}
+	return total
+}
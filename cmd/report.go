@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a print-ready traceability report for audits",
+	Long: `Generate a traceability report from a template.
+
+The built-in "compliance" template is paginated HTML with print CSS: a cover
+page, a revision table from the requirement audit trail, a summary, a
+requirement-to-test matrix, an appendix of untested leaf requirements and a
+sign-off block. Open it in a browser and print to PDF.
+
+--template also accepts the path of a Go text/template file for custom
+layouts (HTML, Markdown, LaTeX, ...). The template is executed with the same
+data as the built-in one: .Title, .Project, .GeneratedAt, .Filter, .Stats,
+.Coverage (.Tested, .Total, .Percent), .TestTypes, .Rows, .Untested and
+.Revisions, where rows have .Key, .Type, .Title, .ParentChain, .ComponentKey,
+.Status, .Files, .Functions and .Tests (test type -> "file::test"). The
+functions lower, upper, join, testTypeLabel, derefString and add are
+available. Custom output is not HTML-escaped.
+
+The filters of 'tracevibe export' limit the report to a slice of the project.
+
+Example:
+  tracevibe report --project statsly -o statsly-compliance.html
+  tracevibe report --project statsly --component COMP-001 --title "Release 2.1 Verification" -o report.html
+  tracevibe report --project statsly --template ./audit.md.tmpl -o audit.md`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		templateName, _ := cmd.Flags().GetString("template")
+		outputFile, _ := cmd.Flags().GetString("output")
+		title, _ := cmd.Flags().GetString("title")
+		var filter export.Filter
+		filter.Component, _ = cmd.Flags().GetString("component")
+		filter.Tag, _ = cmd.Flags().GetString("tag")
+		filter.Status, _ = cmd.Flags().GetString("status")
+		filter.Type, _ = cmd.Flags().GetString("type")
+		filter.Root, _ = cmd.Flags().GetString("root")
+
+		if err := runReport(projectKey, dbPath, templateName, outputFile, title, filter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	reportCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	reportCmd.Flags().StringP("template", "t", "compliance", "Built-in template (compliance) or path of a text/template file")
+	reportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	reportCmd.Flags().String("title", "", "Report title (default \"Requirements Traceability Report\")")
+	reportCmd.Flags().String("component", "", "Only requirements of these components (comma-separated keys)")
+	reportCmd.Flags().String("tag", "", "Only requirements of components with these tags (comma-separated)")
+	reportCmd.Flags().String("status", "", "Only requirements with these statuses (comma-separated)")
+	reportCmd.Flags().String("type", "", "Only requirements of these types: scope, user_story, tech_spec (comma-separated)")
+	reportCmd.Flags().String("root", "", "Only this requirement key and its descendants")
+
+	reportCmd.MarkFlagRequired("project")
+}
+
+func runReport(projectKey, dbPath, templateName, outputFile, title string, filter export.Filter) error {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	report, err := export.BuildReport(db, projectKey, filter)
+	if err != nil {
+		return err
+	}
+	if title != "" {
+		report.Title = title
+	}
+
+	if outputFile == "" {
+		return export.WriteReport(os.Stdout, report, templateName)
+	}
+
+	// Render in memory, so a failed report does not leave a partial file
+	var buf bytes.Buffer
+	if err := export.WriteReport(&buf, report, templateName); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote report for project '%s' to %s\n", projectKey, outputFile)
+	return nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "Record test results against a project's test cases",
+	Long: `Record the outcome of test runs made outside TraceVibe, e.g. in CI, against the
+test cases linked to requirements.`,
+}
+
+var resultsIngestCmd = &cobra.Command{
+	Use:   "ingest REPORT.xml...",
+	Short: "Ingest JUnit XML test reports",
+	Long: `Ingest JUnit XML test reports and record the outcome, duration and failure
+message of every test case.
+
+Reports from go-junit-report, jest-junit, pytest --junitxml and other
+xUnit-style reporters are understood. Each reported test is matched to a
+test case of the project by name, and by file, class name or suite when
+several test cases share a name: a file path (Jest, pytest), a Go package
+import path (go-junit-report) or a dotted Python module. Tests that match no
+test case are listed but not recorded. Go subtests are covered by their
+parent test.
+
+Each report is stored as a test run with the git commit and environment it
+came from; the project page shows the latest result of every requirement and
+the history of each test case. The commit defaults to the one checked out in
+the current directory and the environment to this machine, prefixed with
+"ci" when CI=true.
+
+The web server accepts the same reports at POST /api/test-results, either as
+a multipart upload ("file", "project_key", "commit" and "environment" fields)
+or as the raw body with ?project=KEY&commit=SHA&environment=NAME.
+
+Example:
+  go test -v ./... 2>&1 | go-junit-report > report.xml
+  tracevibe results ingest report.xml --project statsly
+  tracevibe results ingest junit.xml pytest.xml --project statsly --json
+  tracevibe results ingest report.xml --project statsly --commit "$GITHUB_SHA" --environment staging`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		commit, _ := cmd.Flags().GetString("commit")
+		environment, _ := cmd.Flags().GetString("environment")
+
+		if commit == "" {
+			commit = results.GitCommit(".")
+		}
+		if environment == "" {
+			environment = results.Environment()
+		}
+		run := database.TestRun{GitCommit: commit, Environment: environment}
+
+		summaries, err := runResultsIngest(args, projectKey, dbPath, run)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error ingesting test results: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON {
+			printJSON(summaries)
+			return
+		}
+		for _, summary := range summaries {
+			printResultsSummary(summary)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resultsCmd)
+	resultsCmd.AddCommand(resultsIngestCmd)
+
+	resultsIngestCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	resultsIngestCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	resultsIngestCmd.Flags().Bool("json", false, "Print the summaries as JSON")
+	resultsIngestCmd.Flags().String("commit", "", "Git commit the tests ran against (default: HEAD of the current directory)")
+	resultsIngestCmd.Flags().String("environment", "", "Where the tests ran, e.g. ci or staging (default: this machine)")
+
+	resultsIngestCmd.MarkFlagRequired("project")
+}
+
+func runResultsIngest(reports []string, projectKey, dbPath string, run database.TestRun) ([]*results.Summary, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	var summaries []*results.Summary
+	for _, report := range reports {
+		file, err := os.Open(report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open report: %w", err)
+		}
+		outcomes, err := results.ParseJUnit(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", report, err)
+		}
+
+		run.Source = "junit:" + filepath.Base(report)
+		summary, err := results.Ingest(db, projectKey, run, outcomes)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func printResultsSummary(summary *results.Summary) {
+	fmt.Printf("%s: %d tests, %d recorded (%d passed, %d failed, %d errors, %d skipped)\n",
+		summary.Source, summary.Total, summary.Matched, summary.Passed, summary.Failed, summary.Errors, summary.Skipped)
+	if len(summary.Unmatched) > 0 {
+		fmt.Printf("  %d unmatched (no such test case in the project):\n", len(summary.Unmatched))
+		for _, outcome := range summary.Unmatched {
+			where := outcome.File
+			if where == "" {
+				where = outcome.ClassName
+			}
+			if where != "" {
+				fmt.Printf("    %s (%s)\n", outcome.Name, where)
+			} else {
+				fmt.Printf("    %s\n", outcome.Name)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/peshwar9/tracevibe/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +22,10 @@ Features:
 - Execute and monitor test cases
 - Git branch integration for change tracking`,
 	Version: "1.0.0",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		logging.Setup(verbose)
+	},
 }
 
 func Execute() {
@@ -31,5 +36,5 @@ func Execute() {
 }
 
 func init() {
-	// Global flags can be added here
+	rootCmd.PersistentFlags().Bool("verbose", false, "Log debug details to stderr")
 }
\ No newline at end of file
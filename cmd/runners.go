@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var runnersCmd = &cobra.Command{
+	Use:   "runners",
+	Short: "Configure how the tests of a project are run",
+	Long: `Configure the test runners of a project.
+
+Runners are configured in a .tracevibe.yaml at the root of the project's
+checkout, or saved in the database with 'tracevibe runners set', which takes
+precedence over the file. Runner commands run on the host of the server, so
+the server only shows the configuration (GET /api/project/KEY/test-runners)
+and never changes it.`,
+}
+
+var runnersShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the runner configuration saved for a project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+
+		var config string
+		err := withProject(dbPath, projectKey, func(db *database.DB, project *database.Project) error {
+			var err error
+			config, err = db.GetTestRunners(project.ID)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading test runners: %v\n", err)
+			os.Exit(1)
+		}
+		if config == "" {
+			fmt.Printf("No runners saved for '%s'; %s in its checkout is used if present\n", projectKey, runner.ConfigFile)
+			return
+		}
+		fmt.Print(config)
+	},
+}
+
+var runnersSetCmd = &cobra.Command{
+	Use:   "set CONFIG_FILE",
+	Short: "Save a runner configuration (YAML or JSON) for a project",
+	Long: `Save a runner configuration for a project. The file has the layout of
+.tracevibe.yaml and is validated before it is saved.
+
+Example:
+  tracevibe runners set runners.yaml --project statsly`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		config, err := runner.ParseConfig(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		err = withProject(dbPath, projectKey, func(db *database.DB, project *database.Project) error {
+			return db.SaveTestRunners(project.ID, string(data))
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving test runners: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %d runners for '%s'\n", len(config.Runners), projectKey)
+	},
+}
+
+var runnersClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the saved runner configuration of a project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+
+		err := withProject(dbPath, projectKey, func(db *database.DB, project *database.Project) error {
+			return db.SaveTestRunners(project.ID, "")
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing test runners: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed the saved runners of '%s'\n", projectKey)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runnersCmd)
+	for _, cmd := range []*cobra.Command{runnersShowCmd, runnersSetCmd, runnersClearCmd} {
+		runnersCmd.AddCommand(cmd)
+		cmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+		cmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+		cmd.MarkFlagRequired("project")
+	}
+}
+
+// withProject opens the database and calls fn with the project
+func withProject(dbPath, projectKey string, fn func(db *database.DB, project *database.Project) error) error {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return fmt.Errorf("project not found: %s", projectKey)
+	}
+	return fn(db, project)
+}
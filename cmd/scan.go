@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [REPO_PATH]",
+	Short: "Discover RTM annotations in source code",
+	Long: `Walk a repository and link source code to requirements using RTM annotations.
+
+Add a comment referencing one or more requirement keys next to the code that
+implements them:
+
+  Go, JS/TS:  // RTM: SCOPE-1-US-1-TS-1      or  /* RTM: [SCOPE-1-US-1-TS-1] */
+  Python:     # RTM: SCOPE-1-US-1-TS-1
+  SQL:        -- RTM: SCOPE-1-US-1-TS-1, SCOPE-1-US-1-TS-2
+
+Keys are dash-separated segments starting with a letter, at least one of them
+numeric (SCOPE-1-US-2, TS-001, PROJ-42). Comment markers inside string
+literals, such as the // of "http://", do not start a comment.
+
+An annotation placed directly above a function (or inside its body) links that
+function and its line range to the requirement. Matching rows in the
+implementations table are created or updated; the rows a scan creates are
+kept when the RTM file is imported again.
+
+Go _test.go files are parsed as well: a TestXxx function carrying an RTM
+annotation, or a t.Run subtest whose name contains a requirement key, is
+linked to that requirement as a test case. Linked Go tests that no longer
+exist in the source are reported.
+
+Without REPO_PATH the project's source root is scanned (see 'tracevibe import
+--source-root'), or else the current directory.
+
+Example:
+  tracevibe scan . --project my-project
+  tracevibe scan --project my-project
+  tracevibe scan ../statsly --project statsly --db-path /custom/path/tracevibe.db`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// "" scans the project's source root
+		repoPath := ""
+		if len(args) > 0 {
+			repoPath = args[0]
+		}
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+
+		if repoPath != "" {
+			if info, err := os.Stat(repoPath); err != nil || !info.IsDir() {
+				fmt.Fprintf(os.Stderr, "Error: repository path is not a directory: %s\n", repoPath)
+				os.Exit(1)
+			}
+		}
+
+		result, err := runScan(repoPath, projectKey, dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning repository: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Scanned %d files, found %d RTM annotations\n", result.FilesScanned, len(result.Annotations))
+		fmt.Printf("Implementations: %d created, %d updated\n", result.ImplementationsCreated, result.ImplementationsUpdated)
+		fmt.Printf("Parsed %d Go test files, linked %d tests to requirements (%d new links)\n",
+			result.TestFilesScanned, len(result.TestLinks), result.TestLinksCreated)
+		for _, key := range result.UnknownKeys {
+			fmt.Printf("⚠ Unknown requirement key: %s\n", key)
+		}
+		for _, missing := range result.MissingTests {
+			fmt.Printf("⚠ Linked test not found in source: %s\n", missing)
+		}
+		for _, parseErr := range result.ParseErrors {
+			fmt.Printf("⚠ Could not parse %s\n", parseErr)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	scanCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+
+	scanCmd.MarkFlagRequired("project")
+}
+
+func runScan(repoPath, projectKey, dbPath string) (*scanner.Result, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	return scanner.New(db).ScanProject(repoPath, projectKey)
+}
@@ -1,25 +1,31 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/export"
 	"github.com/peshwar9/tracevibe/internal/importer"
-	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/results"
+	"github.com/peshwar9/tracevibe/internal/runner"
+	"github.com/peshwar9/tracevibe/internal/schema"
+	"github.com/peshwar9/tracevibe/internal/workspace"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 //go:embed web/templates/*.html
@@ -42,8 +48,12 @@ Access the UI at http://localhost:8080 (default port).`,
 		port, _ := cmd.Flags().GetInt("port")
 		dbPath, _ := cmd.Flags().GetString("db-path")
 		projectBasePath, _ := cmd.Flags().GetString("project-base-path")
+		testWorkers, _ := cmd.Flags().GetInt("test-workers")
+		noTestRuns, _ := cmd.Flags().GetBool("no-test-runs")
+		editorURL, _ := cmd.Flags().GetString("editor-url")
+		sandbox := sandboxFromFlags(cmd, runner.DefaultEnv)
 
-		if err := startServer(port, dbPath, projectBasePath); err != nil {
+		if err := startServer(port, dbPath, projectBasePath, testWorkers, sandbox, noTestRuns, editorURL); err != nil {
 			fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
 			os.Exit(1)
 		}
@@ -55,10 +65,14 @@ func init() {
 
 	serveCmd.Flags().IntP("port", "p", 8080, "Port to run the server on")
 	serveCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
-	serveCmd.Flags().String("project-base-path", "", "Base path for resolving test file paths (e.g., /path/to/project/)")
+	serveCmd.Flags().String("project-base-path", "", "Source root of projects that have none of their own (e.g., /path/to/project/)")
+	serveCmd.Flags().Int("test-workers", 2, "Number of test runs executed at the same time")
+	serveCmd.Flags().Bool("no-test-runs", false, "Disable running tests, e.g. for read-only deployments")
+	serveCmd.Flags().String("editor-url", "vscode://file{path}", "Link to source files found in the project's source root; {path} is the absolute path, \"\" disables links")
+	addSandboxFlags(serveCmd, "default: PATH, HOME, locale and toolchain variables")
 }
 
-func startServer(port int, dbPath string, projectBasePath string) error {
+func startServer(port int, dbPath string, projectBasePath string, testWorkers int, sandbox *runner.Sandbox, noTestRuns bool, editorURL string) error {
 	// Initialize database
 	db, err := database.New(dbPath)
 	if err != nil {
@@ -90,21 +104,39 @@ func startServer(port int, dbPath string, projectBasePath string) error {
 		db:              db,
 		templates:       tmpl,
 		projectBasePath: projectBasePath,
+		sandbox:         sandbox,
+		noTestRuns:      noTestRuns,
+		editorURL:       editorURL,
 	}
+	server.jobs = newTestJobs(testWorkers, func(ctx context.Context, job *testJob) (*TestResult, error) {
+		return server.runTests(ctx, job.ID, job.Project, job.Component, job.Requirement, job)
+	})
 
 	// Routes
 	http.HandleFunc("/", server.dashboardHandler)
 	http.HandleFunc("/projects/", server.projectHandler)
 	http.HandleFunc("/export/", server.exportHandler)
+	http.HandleFunc("/export-compliance/", server.exportComplianceHandler)
 	http.HandleFunc("/export-json/", server.exportJSONHandler)
 	http.HandleFunc("/export-yaml/", server.exportYAMLHandler)
 	http.HandleFunc("/export-markdown/", server.exportMarkdownHandler)
+	http.HandleFunc("/export-reqif/", server.exportReqIFHandler)
+	http.HandleFunc("/export-csv/", server.exportCSVHandler)
+	http.HandleFunc("/export-xlsx/", server.exportXLSXHandler)
+	http.HandleFunc("/export-dot/", server.exportDOTHandler)
+	http.HandleFunc("/export-mermaid/", server.exportMermaidHandler)
 	http.HandleFunc("/api/test/run", server.testRunHandler)
+	http.HandleFunc("/api/test/runs", server.testRunsHandler)
+	http.HandleFunc("/api/test/runs/", server.testRunsHandler)
+	http.HandleFunc("/api/test-results", server.testResultsHandler)
+	http.HandleFunc("/api/test-cases/", server.testCaseHistoryHandler)
 	http.HandleFunc("/api/project/", server.projectAPIHandler)
 	http.HandleFunc("/api/projects/create", server.createProjectHandler)
 	http.HandleFunc("/api/components", server.componentsAPIHandler)
 	http.HandleFunc("/api/components/update", server.updateComponentHandler)
 	http.HandleFunc("/api/import", server.importHandler)
+	http.HandleFunc("/api/import/preview", server.importPreviewHandler)
+	http.HandleFunc("/api/schema/rtm", server.rtmSchemaHandler)
 	http.HandleFunc("/api/requirements/", server.requirementsAPIHandler)
 	http.HandleFunc("/api/methodology", server.methodologyHandler)
 	http.HandleFunc("/api/project-context/", server.projectContextHandler)
@@ -122,6 +154,10 @@ type Server struct {
 	db              *database.DB
 	templates       *template.Template
 	projectBasePath string
+	jobs            *testJobs
+	sandbox         *runner.Sandbox // limits of test commands
+	noTestRuns      bool            // test execution is disabled
+	editorURL       string          // link to source files, with {path} for the file
 }
 
 // Dashboard handler
@@ -183,6 +219,14 @@ func (s *Server) projectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// The project and component pages show the same trees as the exports
+type (
+	ComponentSummary   = export.ComponentSummary
+	RequirementTree    = export.RequirementTree
+	ImplementationInfo = export.ImplementationInfo
+	TestCaseInfo       = export.TestCaseInfo
+)
+
 type ComponentWithRequirements struct {
 	ComponentSummary
 	Requirements []RequirementTree `json:"requirements"`
@@ -190,18 +234,23 @@ type ComponentWithRequirements struct {
 
 func (s *Server) projectOverviewHandler(w http.ResponseWriter, r *http.Request, projectKey string) {
 	data := struct {
-		Title                string
-		Project              *database.Project
-		Components           []ComponentSummary
-		ComponentsWithReqs   []ComponentWithRequirements
-		Requirements         []RequirementTree
-		ScopeCount           int
-		UserStoryCount       int
-		TechSpecCount        int
-		TotalTestCount       int
-		Error                string
+		Title              string
+		Project            *database.Project
+		Components         []ComponentSummary
+		ComponentsWithReqs []ComponentWithRequirements
+		Requirements       []RequirementTree
+		ScopeCount         int
+		UserStoryCount     int
+		TechSpecCount      int
+		TotalTestCount     int
+		Imports            []*database.Import
+		TestRuns           []*database.TestRun
+		TestStatus         map[string]*RequirementTestStatus
+		NoTestRuns         bool
+		Error              string
 	}{
-		Title: "Project Overview",
+		Title:      "Project Overview",
+		NoTestRuns: s.noTestRuns,
 	}
 
 	// Get project
@@ -218,7 +267,7 @@ func (s *Server) projectOverviewHandler(w http.ResponseWriter, r *http.Request,
 	data.Project = project
 
 	// Get components summary
-	components, err := s.getComponentsSummary(projectKey)
+	components, err := export.Components(s.db, projectKey)
 	if err != nil {
 		data.Error = fmt.Sprintf("Error loading components: %v", err)
 	} else {
@@ -231,7 +280,7 @@ func (s *Server) projectOverviewHandler(w http.ResponseWriter, r *http.Request,
 		// Get requirements for each component
 		var componentsWithReqs []ComponentWithRequirements
 		for _, comp := range components {
-			requirements, err := s.getRequirementsTree(projectKey, comp.ComponentKey)
+			requirements, err := export.RequirementsTree(s.db, projectKey, comp.ComponentKey)
 			if err != nil {
 				// Log error but continue with other components
 				continue
@@ -245,23 +294,112 @@ func (s *Server) projectOverviewHandler(w http.ResponseWriter, r *http.Request,
 
 			// Count by type for totals
 			for _, req := range requirements {
-				countRequirementsByType(req, &data.ScopeCount, &data.UserStoryCount, &data.TechSpecCount)
+				export.CountRequirementsByType(req, &data.ScopeCount, &data.UserStoryCount, &data.TechSpecCount)
 			}
 		}
 		data.ComponentsWithReqs = componentsWithReqs
 	}
 
 	// Get requirements tree for backward compatibility
-	requirements, err := s.getRequirementsTree(projectKey, "")
+	requirements, err := export.RequirementsTree(s.db, projectKey, "")
 	if err != nil {
 		data.Error = fmt.Sprintf("Error loading requirements: %v", err)
 	} else {
 		data.Requirements = requirements
 	}
 
+	// Import history panel; a failure here should not hide the project
+	data.Imports, _ = s.db.ListImports(projectKey, 20)
+
+	// Last result badges and test run history, likewise optional
+	data.TestRuns, _ = s.db.ListTestRuns(project.ID, 20)
+	if latest, err := s.db.LatestTestResults(project.ID); err == nil {
+		data.TestStatus = make(map[string]*RequirementTestStatus)
+		for _, comp := range data.ComponentsWithReqs {
+			for _, req := range comp.Requirements {
+				collectTestStatus(req, latest, data.TestStatus)
+			}
+		}
+	}
+
 	s.renderTemplate(w, "project-page.html", data)
 }
 
+// RequirementTestStatus sums up the latest results of the test cases linked
+// to a requirement and its descendants
+type RequirementTestStatus struct {
+	RequirementID  string                    `json:"requirement_id"`
+	RequirementKey string                    `json:"requirement_key"`
+	Passed         int                       `json:"passed"`
+	Failed         int                       `json:"failed"`
+	Errors         int                       `json:"errors"`
+	Skipped        int                       `json:"skipped"`
+	NotRun         int                       `json:"not_run"`
+	Tests          []database.TestCaseStatus `json:"tests"`
+}
+
+// Label is the text of the requirement's last result badge
+func (t *RequirementTestStatus) Label() string {
+	switch {
+	case t.Failed+t.Errors > 0:
+		return fmt.Sprintf("✗ %d of %d failing", t.Failed+t.Errors, len(t.Tests))
+	case t.Passed == len(t.Tests):
+		return "✓ passing"
+	case t.Passed > 0:
+		return fmt.Sprintf("✓ %d of %d passing", t.Passed, len(t.Tests))
+	}
+	return "not run"
+}
+
+// Class is the CSS class of the requirement's last result badge
+func (t *RequirementTestStatus) Class() string {
+	switch {
+	case t.Failed+t.Errors > 0:
+		return "badge-test-failed"
+	case t.Passed > 0:
+		return "badge-test-passed"
+	}
+	return "badge-test-none"
+}
+
+// collectTestStatus sums up the latest test results of a requirement tree into
+// statuses, keyed by requirement ID, and returns the requirement's own
+func collectTestStatus(req RequirementTree, latest map[string][]database.TestCaseStatus, statuses map[string]*RequirementTestStatus) *RequirementTestStatus {
+	status := &RequirementTestStatus{RequirementID: req.ID, RequirementKey: req.RequirementKey}
+	seen := make(map[string]bool)
+	add := func(tests []database.TestCaseStatus) {
+		for _, test := range tests {
+			if seen[test.TestCaseID] {
+				continue
+			}
+			seen[test.TestCaseID] = true
+			status.Tests = append(status.Tests, test)
+			if test.Last == nil {
+				status.NotRun++
+				continue
+			}
+			switch test.Last.Status {
+			case database.TestPassed:
+				status.Passed++
+			case database.TestFailed:
+				status.Failed++
+			case database.TestError:
+				status.Errors++
+			case database.TestSkipped:
+				status.Skipped++
+			}
+		}
+	}
+	add(latest[req.ID])
+	for _, child := range req.Children {
+		add(collectTestStatus(child, latest, statuses).Tests)
+	}
+	if len(status.Tests) > 0 {
+		statuses[req.ID] = status
+	}
+	return status
+}
+
 func (s *Server) componentDetailsHandler(w http.ResponseWriter, r *http.Request, projectKey, componentKey string) {
 	data := struct {
 		Title          string
@@ -272,6 +410,7 @@ func (s *Server) componentDetailsHandler(w http.ResponseWriter, r *http.Request,
 		UserStoryCount int
 		TechSpecCount  int
 		TestCaseCount  int
+		Source         *sourceLinks
 		Error          string
 	}{
 		Title: "Component Details",
@@ -303,22 +442,56 @@ func (s *Server) componentDetailsHandler(w http.ResponseWriter, r *http.Request,
 	}
 	data.Component = component
 
+	if s.editorURL != "" {
+		if ws, err := workspace.Load(s.db, project, s.projectBasePath); err == nil {
+			data.Source = &sourceLinks{ws: ws, componentKey: componentKey, editorURL: s.editorURL}
+		}
+	}
+
 	// Get requirements tree for this component
-	requirements, err := s.getRequirementsTree(projectKey, componentKey)
+	requirements, err := export.RequirementsTree(s.db, projectKey, componentKey)
 	if err != nil {
 		data.Error = fmt.Sprintf("Error loading requirements: %v", err)
 	} else {
 		data.Requirements = requirements
 		// Count by type and test cases
 		for _, req := range requirements {
-			countRequirementsByType(req, &data.ScopeCount, &data.UserStoryCount, &data.TechSpecCount)
-			data.TestCaseCount += countTestCases(req)
+			export.CountRequirementsByType(req, &data.ScopeCount, &data.UserStoryCount, &data.TechSpecCount)
+			data.TestCaseCount += export.CountTestCases(req)
 		}
 	}
 
 	s.renderTemplate(w, "component-page.html", data)
 }
 
+// sourceLinks links the files of a component to an editor
+type sourceLinks struct {
+	ws           *workspace.Workspace
+	componentKey string
+	editorURL    string
+}
+
+// Link returns the editor URL of a file, or "" when it is not in the source
+// root or links are disabled
+func (l *sourceLinks) Link(file string) template.URL {
+	if l == nil {
+		return ""
+	}
+	path := l.ws.Path(l.componentKey, file)
+	if path == "" {
+		return ""
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	escaped := (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath()
+	return template.URL(strings.ReplaceAll(l.editorURL, "{path}", escaped))
+}
+
 // API handler for AJAX requests
 func (s *Server) apiHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -335,674 +508,344 @@ func (s *Server) apiHandler(w http.ResponseWriter, r *http.Request) {
 
 // Export handler for generating HTML reports
 func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract project key from URL path
-	path := r.URL.Path[len("/export/"):]
-	if path == "" {
-		http.Error(w, "Project key required", http.StatusBadRequest)
-		return
-	}
+	s.exportAs(w, r, "/export/", "html")
+}
+
+// Compliance report handler: print-ready HTML for audit deliverables
+func (s *Server) exportComplianceHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-compliance/", "compliance")
+}
+
+// JSON export handler for LLM consumption
+func (s *Server) exportJSONHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-json/", "json")
+}
+
+// YAML export handler for LLM consumption
+func (s *Server) exportYAMLHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-yaml/", "yaml")
+}
+
+// ReqIF export handler for requirements management tools
+func (s *Server) exportReqIFHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-reqif/", "reqif")
+}
+
+// CSV traceability matrix export handler for auditors
+func (s *Server) exportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-csv/", "csv")
+}
+
+// XLSX traceability matrix export handler for auditors
+func (s *Server) exportXLSXHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-xlsx/", "xlsx")
+}
+
+// Graphviz DOT export handler for the requirement -> file -> test graph
+func (s *Server) exportDOTHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-dot/", "dot")
+}
 
+// Mermaid export handler for the requirement -> file -> test graph
+func (s *Server) exportMermaidHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-mermaid/", "mermaid")
+}
+
+// Markdown export handler for human/dev consumption
+func (s *Server) exportMarkdownHandler(w http.ResponseWriter, r *http.Request) {
+	s.exportAs(w, r, "/export-markdown/", "markdown")
+}
+
+// exportAs downloads the project named after the route prefix in one of the
+// export formats. The component, tag, status, type and root query parameters
+// filter every format alike; markdown also takes mermaid=1.
+func (s *Server) exportAs(w http.ResponseWriter, r *http.Request, prefix, formatName string) {
 	// Remove trailing slash and any extra path components
-	projectKey := strings.Split(path, "/")[0]
+	projectKey := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")[0]
 	if projectKey == "" {
 		http.Error(w, "Project key required", http.StatusBadRequest)
 		return
 	}
-
-	// Get project data
 	project, err := s.db.GetProjectByKey(projectKey)
 	if err != nil || project == nil {
 		http.Error(w, "Project not found", http.StatusNotFound)
 		return
 	}
 
-	// Get components
-	components, err := s.getComponentsSummary(projectKey)
+	format, err := export.LookupFormat(formatName)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error loading components: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	// Get requirements tree
-	requirements, err := s.getRequirementsTree(projectKey, "")
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error loading requirements: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	// Calculate statistics
-	stats := struct {
-		TotalComponents   int
-		TotalRequirements int
-		TotalScopes      int
-		TotalUserStories int
-		TotalTechSpecs   int
-		TotalTestCases   int
-	}{}
-
-	stats.TotalComponents = len(components)
-	for _, req := range requirements {
-		countRequirementsByType(req, &stats.TotalScopes, &stats.TotalUserStories, &stats.TotalTechSpecs)
-		stats.TotalTestCases += countTestCases(req)
+	query := r.URL.Query()
+	opts := export.Options{
+		Filter: export.Filter{
+			Component: query.Get("component"),
+			Tag:       query.Get("tag"),
+			Status:    query.Get("status"),
+			Type:      query.Get("type"),
+			Root:      query.Get("root"),
+		},
 	}
-	stats.TotalRequirements = stats.TotalScopes + stats.TotalUserStories + stats.TotalTechSpecs
+	opts.Mermaid, _ = strconv.ParseBool(query.Get("mermaid"))
 
-	// Prepare template data
-	data := struct {
-		Project     *database.Project
-		Components  []ComponentSummary
-		Requirements []RequirementTree
-		Stats       interface{}
-		ExportDate  string
-	}{
-		Project:     project,
-		Components:  components,
-		Requirements: requirements,
-		Stats:       stats,
-		ExportDate:  time.Now().Format("2006-01-02 15:04:05"),
+	// Render before writing headers so errors can still be reported
+	var buf bytes.Buffer
+	if err := format.Write(&buf, s.db, projectKey, opts); err != nil {
+		http.Error(w, fmt.Sprintf("Error generating export: %v", err), http.StatusBadRequest)
+		return
 	}
 
-	// Set content type for HTML download
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-rtm-export.html"`, projectKey))
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s"`, projectKey, format.FileSuffix))
+	w.Write(buf.Bytes())
+}
 
-	// Render export template
-	err = s.templates.ExecuteTemplate(w, "export.html", data)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error generating export: %v", err), http.StatusInternalServerError)
+// testRunHandler queues a test run of a component, or of a requirement and
+// its descendants, and answers with its ID; progress and the result are
+// available under /api/test/runs/{id}
+func (s *Server) testRunHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-}
 
-// JSON export handler for LLM consumption
-func (s *Server) exportJSONHandler(w http.ResponseWriter, r *http.Request) {
-	projectKey, rtmData, err := s.getExportDataAsRTM(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if s.noTestRuns {
+		http.Error(w, "Running tests is disabled on this server", http.StatusForbidden)
 		return
 	}
 
-	// Set content type for JSON download
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-rtm-export.json"`, projectKey))
+	w.Header().Set("Content-Type", "application/json")
 
-	// Convert to JSON
-	jsonData, err := json.MarshalIndent(rtmData, "", "  ")
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error generating JSON: %v", err), http.StatusInternalServerError)
-		return
+	var req struct {
+		Project       string `json:"project"`
+		Component     string `json:"component"`
+		RequirementID string `json:"requirement_id"` // requirement key or ID
 	}
 
-	w.Write(jsonData)
-}
-
-// YAML export handler for LLM consumption
-func (s *Server) exportYAMLHandler(w http.ResponseWriter, r *http.Request) {
-	projectKey, rtmData, err := s.getExportDataAsRTM(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Set content type for YAML download
-	w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-rtm-export.yaml"`, projectKey))
-
-	// Convert to YAML
-	yamlData, err := yaml.Marshal(rtmData)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error generating YAML: %v", err), http.StatusInternalServerError)
+	if req.Project == "" || (req.Component == "" && req.RequirementID == "") {
+		http.Error(w, "Project and component or requirement_id are required", http.StatusBadRequest)
 		return
 	}
 
-	w.Write(yamlData)
-}
-
-// Markdown export handler for human/dev consumption
-func (s *Server) exportMarkdownHandler(w http.ResponseWriter, r *http.Request) {
-	projectKey, exportData, err := s.getExportData(r)
+	job, err := s.jobs.submit(req.Project, req.Component, req.RequirementID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
 
-	// Set content type for markdown download
-	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-rtm-export.md"`, projectKey))
-
-	// Generate markdown content
-	markdown := s.generateMarkdown(exportData)
-	w.Write([]byte(markdown))
+	state, _, _ := job.snapshot(0)
+	w.Header().Set("Location", "/api/test/runs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(state)
 }
 
-// Helper function to get export data
-func (s *Server) getExportData(r *http.Request) (string, map[string]interface{}, error) {
-	// Extract project key from URL path
-	var path string
-	if strings.HasPrefix(r.URL.Path, "/export-json/") {
-		path = r.URL.Path[len("/export-json/"):]
-	} else if strings.HasPrefix(r.URL.Path, "/export-yaml/") {
-		path = r.URL.Path[len("/export-yaml/"):]
-	} else if strings.HasPrefix(r.URL.Path, "/export-markdown/") {
-		path = r.URL.Path[len("/export-markdown/"):]
-	}
+// testResultsHandler ingests a JUnit XML report, either as the "file" field of
+// a multipart upload with "project_key", "commit" and "environment" fields, or
+// as the raw request body with ?project=KEY&commit=SHA&environment=NAME
+func (s *Server) testResultsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	if path == "" {
-		return "", nil, fmt.Errorf("project key required")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Remove trailing slash and any extra path components
-	projectKey := strings.Split(path, "/")[0]
+	var report io.Reader = r.Body
+	filename := "upload"
+	projectKey := r.URL.Query().Get("project")
+	run := database.TestRun{
+		GitCommit:   r.URL.Query().Get("commit"),
+		Environment: r.URL.Query().Get("environment"),
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB max
+			http.Error(w, fmt.Sprintf("Error parsing form: %v", err), http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error getting file: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		report, filename = file, header.Filename
+		if key := r.FormValue("project_key"); key != "" {
+			projectKey = key
+		}
+		if commit := r.FormValue("commit"); commit != "" {
+			run.GitCommit = commit
+		}
+		if environment := r.FormValue("environment"); environment != "" {
+			run.Environment = environment
+		}
+	}
 	if projectKey == "" {
-		return "", nil, fmt.Errorf("project key required")
+		http.Error(w, "Project key is required", http.StatusBadRequest)
+		return
 	}
-
-	// Get project data
-	project, err := s.db.GetProjectByKey(projectKey)
-	if err != nil || project == nil {
-		return "", nil, fmt.Errorf("project not found")
+	if project, err := s.db.GetProjectByKey(projectKey); err != nil || project == nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
 	}
 
-	// Get components
-	components, err := s.getComponentsSummary(projectKey)
+	outcomes, err := results.ParseJUnit(report)
 	if err != nil {
-		return "", nil, fmt.Errorf("error loading components: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	// Get requirements tree
-	requirements, err := s.getRequirementsTree(projectKey, "")
+	run.Source = "junit:" + filename
+	summary, err := results.Ingest(s.db, projectKey, run, outcomes)
 	if err != nil {
-		return "", nil, fmt.Errorf("error loading requirements: %v", err)
-	}
-
-	// Calculate statistics
-	stats := map[string]interface{}{
-		"total_components":    len(components),
-		"total_requirements":  0,
-		"total_scopes":       0,
-		"total_user_stories": 0,
-		"total_tech_specs":   0,
-		"total_test_cases":   0,
-	}
-
-	scopeCount, userStoryCount, techSpecCount := 0, 0, 0
-	testCaseCount := 0
-	for _, req := range requirements {
-		countRequirementsByType(req, &scopeCount, &userStoryCount, &techSpecCount)
-		testCaseCount += countTestCases(req)
-	}
-
-	stats["total_scopes"] = scopeCount
-	stats["total_user_stories"] = userStoryCount
-	stats["total_tech_specs"] = techSpecCount
-	stats["total_requirements"] = scopeCount + userStoryCount + techSpecCount
-	stats["total_test_cases"] = testCaseCount
-
-	// Prepare export data
-	exportData := map[string]interface{}{
-		"project":      project,
-		"components":   components,
-		"requirements": requirements,
-		"statistics":   stats,
-		"export_date":  time.Now().Format("2006-01-02 15:04:05"),
-		"export_timestamp": time.Now().Unix(),
+		http.Error(w, fmt.Sprintf("Error recording test results: %v", err), http.StatusInternalServerError)
+		return
 	}
-
-	return projectKey, exportData, nil
+	json.NewEncoder(w).Encode(summary)
 }
 
-// Helper function to get export data in RTMData format (compatible with import)
-func (s *Server) getExportDataAsRTM(r *http.Request) (string, *models.RTMData, error) {
-	// Extract project key from URL path
-	var path string
-	if strings.HasPrefix(r.URL.Path, "/export-json/") {
-		path = r.URL.Path[len("/export-json/"):]
-	} else if strings.HasPrefix(r.URL.Path, "/export-yaml/") {
-		path = r.URL.Path[len("/export-yaml/"):]
-	} else if strings.HasPrefix(r.URL.Path, "/export-markdown/") {
-		path = r.URL.Path[len("/export-markdown/"):]
-	}
+// testCaseHistoryHandler serves GET /api/test-cases/{id}/history, the
+// results of one test case, newest first
+func (s *Server) testCaseHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	if path == "" {
-		return "", nil, fmt.Errorf("project key required")
+	parts := splitPath(r.URL.Path[len("/api/test-cases/"):])
+	if len(parts) != 2 || parts[1] != "history" {
+		http.NotFound(w, r)
+		return
 	}
-
-	// Remove trailing slash and any extra path components
-	projectKey := strings.Split(path, "/")[0]
-	if projectKey == "" {
-		return "", nil, fmt.Errorf("project key required")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Get project data
-	project, err := s.db.GetProjectByKey(projectKey)
-	if err != nil || project == nil {
-		return "", nil, fmt.Errorf("project not found")
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		limit = l
 	}
-
-	// Get all requirements for the project
-	requirements, err := s.db.GetRequirementsByProject(project.ID)
+	history, err := s.db.TestCaseHistory(parts[0], limit)
 	if err != nil {
-		return "", nil, fmt.Errorf("error loading requirements: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-
-	// Get all components using existing method
-	componentSummaries, err := s.getComponentsSummary(projectKey)
-	if err != nil {
-		return "", nil, fmt.Errorf("error loading components: %v", err)
+	if history == nil {
+		history = []*database.TestCaseRun{}
 	}
+	json.NewEncoder(w).Encode(history)
+}
 
-	// Create RTMData structure
-	rtmData := &models.RTMData{
-		Metadata: models.RTMMetadata{
-			GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
-			GeneratedBy: "TraceVibe Export",
-			Project: models.Project{
-				ID:          project.ProjectKey,
-				Name:        project.Name,
-				Description: s.derefString(project.Description),
-				Repository:  s.derefString(project.RepositoryURL),
-				Version:     s.derefString(project.Version),
-				LastUpdated: project.UpdatedAt,
-			},
-		},
-		Project: models.Project{
-			ID:          project.ProjectKey,
-			Name:        project.Name,
-			Description: s.derefString(project.Description),
-			Repository:  s.derefString(project.RepositoryURL),
-			Version:     s.derefString(project.Version),
-			LastUpdated: project.UpdatedAt,
-		},
-		SystemComponents: []models.SystemComponent{},
-		Scopes:          []models.Scope{},
-	}
-
-	// Convert components
-	for _, comp := range componentSummaries {
-		rtmData.SystemComponents = append(rtmData.SystemComponents, models.SystemComponent{
-			ID:            comp.ComponentKey,
-			Name:          comp.Name,
-			ComponentType: comp.ComponentType,
-			Technology:    comp.Technology,
-			Description:   comp.Description,
-			Tags:          comp.Tags,
-		})
-	}
+// Project API handler for delete operations
+func (s *Server) projectAPIHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/api/project/"):]
+	parts := splitPath(path)
 
-	// Build hierarchical requirements structure (Scopes -> UserStories -> TechSpecs)
-	// Use pointers throughout to avoid copy issues
-	scopeMap := make(map[string]*models.Scope)
-	userStoryMap := make(map[string]*models.UserStory)
-	scopeIndexMap := make(map[string]int)
-
-	// First pass: create all scopes (store pointers, not copies)
-	for _, req := range requirements {
-		if strings.ToLower(req.RequirementType) == "scope" {
-			scope := models.Scope{
-				ID:          req.RequirementKey,
-				ComponentID: req.ComponentID,
-				Name:        req.Title,
-				Description: s.derefString(req.Description),
-				Priority:    req.Priority,
-				Status:      req.Status,
-				UserStories: []models.UserStory{},
-			}
-			scopeIndexMap[req.ID] = len(rtmData.Scopes)
-			rtmData.Scopes = append(rtmData.Scopes, scope)
-			scopeMap[req.ID] = &rtmData.Scopes[len(rtmData.Scopes)-1] // Store pointer to actual element in slice
-		}
+	if len(parts) == 2 && parts[1] == "delete" && r.Method == http.MethodDelete {
+		projectKey := parts[0]
+		s.deleteProjectHandler(w, r, projectKey)
+		return
 	}
-
-	// Second pass: create user stories and attach to scopes
-	for _, req := range requirements {
-		if strings.ToLower(req.RequirementType) == "user_story" {
-			if req.ParentRequirementID != nil {
-				if scopeIndex, exists := scopeIndexMap[*req.ParentRequirementID]; exists {
-					userStory := models.UserStory{
-						ID:          req.RequirementKey,
-						Name:        req.Title,
-						Description: s.derefString(req.Description),
-						Priority:    req.Priority,
-						Status:      req.Status,
-						TechSpecs:   []models.TechSpec{},
-					}
-					// Add to scope's user stories
-					rtmData.Scopes[scopeIndex].UserStories = append(rtmData.Scopes[scopeIndex].UserStories, userStory)
-					// Store pointer to the actual user story in the scope
-					userStoryIndex := len(rtmData.Scopes[scopeIndex].UserStories) - 1
-					userStoryMap[req.ID] = &rtmData.Scopes[scopeIndex].UserStories[userStoryIndex]
-				}
-			}
-		}
+	if len(parts) == 2 && parts[1] == "test-runners" {
+		s.testRunnersHandler(w, r, parts[0])
+		return
 	}
-
-	// Third pass: create tech specs and attach to user stories
-	for _, req := range requirements {
-		if strings.ToLower(req.RequirementType) == "tech_spec" {
-			if req.ParentRequirementID != nil {
-				if parentStory, exists := userStoryMap[*req.ParentRequirementID]; exists {
-					// Get implementation details
-					impl, _ := s.getImplementationForRequirement(req.ID)
-					// Get test coverage
-					testCov, _ := s.getTestCoverageForRequirement(project.ID, req.ID)
-
-					techSpec := models.TechSpec{
-						ID:                 req.RequirementKey,
-						Name:               req.Title,
-						Description:        s.derefString(req.Description),
-						Priority:           req.Priority,
-						Status:             req.Status,
-						AcceptanceCriteria: req.AcceptanceCriteria,
-						Implementation:     impl,
-						TestCoverage:       testCov,
-					}
-					// Add directly to the user story that's already in the scope
-					parentStory.TechSpecs = append(parentStory.TechSpecs, techSpec)
-				}
-			}
-		}
+	if len(parts) == 2 && parts[1] == "source-root" {
+		s.sourceRootHandler(w, r, parts[0])
+		return
 	}
 
-	return projectKey, rtmData, nil
+	http.Error(w, "Not found", http.StatusNotFound)
 }
 
-// Helper function to dereference string pointers
-func (s *Server) derefString(str *string) string {
-	if str == nil {
-		return ""
+// sourceRootHandler shows where the source code of a project is checked out:
+// the saved source root and the directory in use. It is read-only: the server
+// runs tests there, so the source root is only set with 'tracevibe import
+// --source-root'. Projects without one use the server's --project-base-path.
+func (s *Server) sourceRootHandler(w http.ResponseWriter, r *http.Request, projectKey string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-	return *str
-}
-
-// Helper function to get implementation for a requirement
-func (s *Server) getImplementationForRequirement(requirementID string) (*models.Implementation, error) {
-	query := `
-		SELECT layer, file_path, functions
-		FROM implementations
-		WHERE requirement_id = ?`
-
-	rows, err := s.db.Query(query, requirementID)
+	project, err := s.db.GetProjectByKey(projectKey)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	impl := &models.Implementation{}
-	hasData := false
-
-	for rows.Next() {
-		var layer, filePath, functionsJSON string
-		if err := rows.Scan(&layer, &filePath, &functionsJSON); err != nil {
-			continue
-		}
-
-		hasData = true
-		var functions []string
-		if functionsJSON != "" && functionsJSON != "[]" {
-			if err := json.Unmarshal([]byte(functionsJSON), &functions); err == nil {
-				fileImpl := models.FileImpl{
-					Path:      filePath,
-					Functions: functions,
-				}
-
-				switch layer {
-				case "backend":
-					if impl.Backend == nil {
-						impl.Backend = &models.BackendImpl{Files: []models.FileImpl{}}
-					}
-					impl.Backend.Files = append(impl.Backend.Files, fileImpl)
-				case "frontend":
-					if impl.Frontend == nil {
-						impl.Frontend = &models.FrontendImpl{Files: []models.FileImpl{}}
-					}
-					impl.Frontend.Files = append(impl.Frontend.Files, fileImpl)
-				case "database":
-					if impl.Database == nil {
-						impl.Database = &models.DatabaseImpl{Files: []models.FileImpl{}}
-					}
-					impl.Database.Files = append(impl.Database.Files, fileImpl)
-				}
-			}
-		}
+		http.Error(w, fmt.Sprintf("Error finding project: %v", err), http.StatusInternalServerError)
+		return
 	}
-
-	if !hasData {
-		return nil, nil
+	if project == nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
 	}
-	return impl, nil
-}
-
-// Helper function to get test coverage for a requirement
-func (s *Server) getTestCoverageForRequirement(projectID, requirementID string) (*models.TestCoverage, error) {
-	// Query to get test cases for this requirement
-	query := `
-		SELECT tf.file_path, tc.test_name, tf.test_type
-		FROM requirement_test_coverage rtc
-		JOIN test_cases tc ON rtc.test_case_id = tc.id
-		JOIN test_files tf ON tc.test_file_id = tf.id
-		WHERE rtc.requirement_id = ?`
 
-	rows, err := s.db.Query(query, requirementID)
+	ws, err := workspace.Load(s.db, project, s.projectBasePath)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	testCov := &models.TestCoverage{
-		UnitTests:        []models.TestFile{},
-		IntegrationTests: []models.TestFile{},
-		E2ETests:         []models.TestFile{},
-	}
-
-	// Group test cases by file and type
-	testFileMap := make(map[string]map[string][]string) // file -> type -> functions
-	hasData := false
-
-	for rows.Next() {
-		var filePath, testName, testType string
-		if err := rows.Scan(&filePath, &testName, &testType); err != nil {
-			continue
-		}
-
-		hasData = true
-		if _, exists := testFileMap[filePath]; !exists {
-			testFileMap[filePath] = make(map[string][]string)
-		}
-
-		if testType == "" {
-			testType = "unit"
-		}
-
-		testFileMap[filePath][testType] = append(testFileMap[filePath][testType], testName)
-	}
-
-	// Convert to TestFile structures
-	for filePath, typeMap := range testFileMap {
-		for testType, functions := range typeMap {
-			tf := models.TestFile{
-				File:      filePath,
-				Functions: functions,
-			}
-
-			switch testType {
-			case "unit":
-				testCov.UnitTests = append(testCov.UnitTests, tf)
-			case "integration":
-				testCov.IntegrationTests = append(testCov.IntegrationTests, tf)
-			case "e2e":
-				testCov.E2ETests = append(testCov.E2ETests, tf)
-			}
-		}
-	}
-
-	if !hasData {
-		return nil, nil
+		http.Error(w, fmt.Sprintf("Error loading source root: %v", err), http.StatusInternalServerError)
+		return
 	}
-	return testCov, nil
-}
-
-// Helper function to generate markdown content
-func (s *Server) generateMarkdown(exportData map[string]interface{}) string {
-	project := exportData["project"].(*database.Project)
-	components := exportData["components"].([]ComponentSummary)
-	requirements := exportData["requirements"].([]RequirementTree)
-	stats := exportData["statistics"].(map[string]interface{})
-	exportDate := exportData["export_date"].(string)
-
-	var md strings.Builder
-
-	// Header
-	md.WriteString(fmt.Sprintf("# %s - Requirements Traceability Matrix\n\n", project.Name))
-	md.WriteString(fmt.Sprintf("**Project Key:** %s  \n", project.ProjectKey))
-	if project.Description != nil && *project.Description != "" {
-		md.WriteString(fmt.Sprintf("**Description:** %s  \n", *project.Description))
-	}
-	if project.RepositoryURL != nil && *project.RepositoryURL != "" {
-		md.WriteString(fmt.Sprintf("**Repository:** %s  \n", *project.RepositoryURL))
-	}
-	if project.Version != nil && *project.Version != "" {
-		md.WriteString(fmt.Sprintf("**Version:** %s  \n", *project.Version))
-	}
-	md.WriteString(fmt.Sprintf("**Export Date:** %s  \n\n", exportDate))
-
-	// Statistics
-	md.WriteString("## 📊 Project Statistics\n\n")
-	md.WriteString("| Metric | Count |\n")
-	md.WriteString("|--------|-------|\n")
-	md.WriteString(fmt.Sprintf("| Components | %v |\n", stats["total_components"]))
-	md.WriteString(fmt.Sprintf("| Total Requirements | %v |\n", stats["total_requirements"]))
-	md.WriteString(fmt.Sprintf("| Scopes | %v |\n", stats["total_scopes"]))
-	md.WriteString(fmt.Sprintf("| User Stories | %v |\n", stats["total_user_stories"]))
-	md.WriteString(fmt.Sprintf("| Technical Specifications | %v |\n", stats["total_tech_specs"]))
-	md.WriteString(fmt.Sprintf("| Test Cases | %v |\n\n", stats["total_test_cases"]))
-
-	// Components
-	md.WriteString("## 🏗️ System Components\n\n")
-	for _, comp := range components {
-		md.WriteString(fmt.Sprintf("### %s\n", comp.Name))
-		md.WriteString(fmt.Sprintf("- **Type:** %s\n", comp.ComponentType))
-		if comp.Technology != "" {
-			md.WriteString(fmt.Sprintf("- **Technology:** %s\n", comp.Technology))
-		}
-		if comp.Description != "" {
-			md.WriteString(fmt.Sprintf("- **Description:** %s\n", comp.Description))
-		}
-		md.WriteString(fmt.Sprintf("- **Requirements:** %d Scopes, %d User Stories, %d Tech Specs\n",
-			comp.ScopeCount, comp.UserStoryCount, comp.TechSpecCount))
-		md.WriteString(fmt.Sprintf("- **Test Cases:** %d\n\n", comp.TestCaseCount))
+	sourceRoot := ""
+	if project.SourceRoot != nil {
+		sourceRoot = *project.SourceRoot
 	}
-
-	// Requirements
-	md.WriteString("## 📋 Requirements Hierarchy\n\n")
-	for _, req := range requirements {
-		s.writeRequirementToMarkdown(&md, req, 3)
-	}
-
-	// Footer
-	md.WriteString("\n---\n")
-	md.WriteString("*Generated by TraceVibe RTM Export*\n")
-
-	return md.String()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"source_root": sourceRoot,
+		"directory":   ws.Dir(),
+	})
 }
 
-// Helper function to write requirements recursively to markdown
-func (s *Server) writeRequirementToMarkdown(md *strings.Builder, req RequirementTree, level int) {
-	// Header with appropriate level
-	headerPrefix := strings.Repeat("#", level)
-
-	// Badge for requirement type
-	var badge string
-	switch strings.ToUpper(req.RequirementType) {
-	case "SCOPE":
-		badge = "🎯 SCOPE"
-	case "USER_STORY":
-		badge = "👤 USER STORY"
-	case "TECH_SPEC":
-		badge = "⚙️ TECH SPEC"
-	default:
-		badge = req.RequirementType
-	}
-
-	md.WriteString(fmt.Sprintf("%s %s: %s\n\n", headerPrefix, badge, req.Title))
-
-	// Metadata
-	md.WriteString(fmt.Sprintf("- **ID:** %s\n", req.RequirementKey))
-	md.WriteString(fmt.Sprintf("- **Status:** %s\n", req.Status))
-	md.WriteString(fmt.Sprintf("- **Priority:** %s\n", req.Priority))
-	md.WriteString(fmt.Sprintf("- **Category:** %s\n", req.Category))
-
-	if req.Description != "" {
-		md.WriteString(fmt.Sprintf("- **Description:** %s\n", req.Description))
-	}
-
-	// Test cases
-	if len(req.TestCases) > 0 {
-		md.WriteString("- **Test Cases:**\n")
-		for _, tc := range req.TestCases {
-			md.WriteString(fmt.Sprintf("  - %s: %s\n", tc.TestType, tc.FilePath))
-		}
-	}
-
-	// Implementation
-	if len(req.Implementation) > 0 {
-		md.WriteString("- **Implementation:**\n")
-		for _, impl := range req.Implementation {
-			md.WriteString(fmt.Sprintf("  - **%s:** %s", impl.Layer, impl.FilePath))
-			if len(impl.Functions) > 0 {
-				md.WriteString(fmt.Sprintf(" (Functions: %s)", strings.Join(impl.Functions, ", ")))
-			}
-			md.WriteString("\n")
-		}
+// testRunnersHandler shows the test runner configuration of a project and the
+// runners it gives. It is read-only: runner commands run on this host, so they
+// are only configured through 'tracevibe runners' or the checkout's
+// .tracevibe.yaml.
+func (s *Server) testRunnersHandler(w http.ResponseWriter, r *http.Request, projectKey string) {
+	project, err := s.db.GetProjectByKey(projectKey)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error finding project: %v", err), http.StatusInternalServerError)
+		return
 	}
-
-	md.WriteString("\n")
-
-	// Process children recursively
-	for _, child := range req.Children {
-		s.writeRequirementToMarkdown(md, child, level+1)
+	if project == nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
 	}
-}
 
-// Test runner handler
-func (s *Server) testRunHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	var req struct {
-		Project   string `json:"project"`
-		Component string `json:"component"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	response := struct {
+		Source  string   `json:"source"` // database, file or builtin
+		Config  string   `json:"config,omitempty"`
+		Runners []string `json:"runners"`
+		Error   string   `json:"error,omitempty"`
+	}{Source: "builtin", Runners: []string{}}
+	stored, err := s.db.GetTestRunners(project.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading test runners: %v", err), http.StatusInternalServerError)
 		return
 	}
-
-	result, err := s.runTestsForComponent(req.Project, req.Component)
+	ws, err := workspace.Load(s.db, project, s.projectBasePath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error loading test runners: %v", err), http.StatusInternalServerError)
 		return
 	}
-
-	json.NewEncoder(w).Encode(result)
-}
-
-// Project API handler for delete operations
-func (s *Server) projectAPIHandler(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path[len("/api/project/"):]
-	parts := splitPath(path)
-
-	if len(parts) == 2 && parts[1] == "delete" && r.Method == http.MethodDelete {
-		projectKey := parts[0]
-		s.deleteProjectHandler(w, r, projectKey)
-		return
+	if stored != "" {
+		response.Source, response.Config = "database", stored
+	} else if data, err := os.ReadFile(filepath.Join(ws.Dir(), runner.ConfigFile)); err == nil {
+		response.Source, response.Config = "file", string(data)
+	}
+	if registry, err := s.testRunners(project.ID, ws); err != nil {
+		response.Error = err.Error()
+	} else {
+		for _, testRunner := range registry.Runners() {
+			response.Runners = append(response.Runners, testRunner.Name())
+		}
 	}
 
-	http.Error(w, "Not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
 func (s *Server) deleteProjectHandler(w http.ResponseWriter, r *http.Request, projectKey string) {
@@ -1026,7 +869,7 @@ func (s *Server) deleteProjectHandler(w http.ResponseWriter, r *http.Request, pr
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
-		"status": "success",
+		"status":  "success",
 		"message": fmt.Sprintf("Project %s deleted successfully", projectKey),
 	})
 }
@@ -1047,7 +890,18 @@ func (s *Server) deleteProject(projectID string) error {
 		return err
 	}
 
-	// 2. Delete test_cases
+	// 2. Delete test run history and test_cases
+	_, err = tx.Exec(`DELETE FROM test_case_results WHERE run_id IN
+		(SELECT id FROM test_runs WHERE project_id = ?)`, projectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM test_runs WHERE project_id = ?`, projectID)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.Exec(`DELETE FROM test_cases WHERE test_file_id IN
 		(SELECT id FROM test_files WHERE project_id = ?)`, projectID)
 	if err != nil {
@@ -1079,7 +933,13 @@ func (s *Server) deleteProject(projectID string) error {
 		return err
 	}
 
-	// 7. Delete project
+	// 7. Delete import history
+	_, err = tx.Exec(`DELETE FROM imports WHERE project_id = ?`, projectID)
+	if err != nil {
+		return err
+	}
+
+	// 8. Delete project
 	_, err = tx.Exec(`DELETE FROM projects WHERE id = ?`, projectID)
 	if err != nil {
 		return err
@@ -1089,42 +949,162 @@ func (s *Server) deleteProject(projectID string) error {
 	return tx.Commit()
 }
 
-func (s *Server) runTestsForComponent(projectKey, componentKey string) (*TestResult, error) {
-	// First, check if the project has a Makefile with test targets - use that if available
-	if s.projectBasePath != "" {
-		makefilePath := filepath.Join(s.projectBasePath, "Makefile")
+// runTests runs the tests linked to the requirements of a component, or only
+// those linked to one requirement and its descendants when requirementKey
+// (a requirement key or ID) is set. It writes their output to out as it is
+// produced and records the run under runID, with the result of every test
+// case the output reports, in the test history. A cancelled run is not
+// recorded.
+func (s *Server) runTests(ctx context.Context, runID, projectKey, componentKey, requirementKey string, out io.Writer) (*TestResult, error) {
+	project, err := s.db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+
+	var testCases []database.TestCaseRef
+	scope := fmt.Sprintf("component '%s'", componentKey)
+	if requirementKey != "" {
+		req, err := s.db.GetRequirementByKey(project.ID, requirementKey)
+		if err != nil {
+			// Not a key; it may be the requirement's ID
+			if req, err = s.db.GetRequirementByID(requirementKey); err != nil || req.ProjectID != project.ID {
+				return nil, fmt.Errorf("requirement '%s' not found in project '%s'", requirementKey, projectKey)
+			}
+		}
+		var reqComponentKey string
+		if err := s.db.QueryRow("SELECT component_key FROM system_components WHERE id = ?", req.ComponentID).Scan(&reqComponentKey); err != nil {
+			return nil, fmt.Errorf("failed to get component of requirement: %w", err)
+		}
+		if componentKey != "" && componentKey != reqComponentKey {
+			return nil, fmt.Errorf("requirement '%s' belongs to component '%s', not '%s'", req.RequirementKey, reqComponentKey, componentKey)
+		}
+		componentKey, requirementKey = reqComponentKey, req.RequirementKey
+		scope = fmt.Sprintf("requirement '%s'", requirementKey)
+		testCases, err = s.db.ListRequirementTestCases(req.ID)
+	} else {
+		testCases, err = s.db.ListTestCases(project.ID, componentKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := workspace.Load(s.db, project, s.projectBasePath)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := s.testRunners(project.ID, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	// The Makefile runs everything, so it only stands in for a whole component
+	useMake := requirementKey == ""
+	result, records, err := s.executeTests(ctx, ws, registry, projectKey, componentKey, scope, testCases, useMake, out)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if result.Passed+result.Failed == 0 && len(records) == 0 {
+		// Nothing ran
+		return result, nil
+	}
+
+	run := database.TestRun{
+		ID:             runID,
+		ProjectID:      project.ID,
+		ComponentKey:   componentKey,
+		RequirementKey: requirementKey,
+		Source:         "runner",
+		GitCommit:      results.GitCommit(ws.Dir()),
+		Environment:    results.Environment(),
+		DurationMs:     time.Since(startedAt).Milliseconds(),
+		StartedAt:      startedAt.UTC().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.db.RecordTestRun(&run, records); err != nil {
+		return nil, err
+	}
+	result.RunID = run.ID
+	return result, nil
+}
+
+// testRunners returns the test runners of a project: those configured in the
+// database or else in the checkout's .tracevibe.yaml, then the built-in ones
+func (s *Server) testRunners(projectID string, ws *workspace.Workspace) (*runner.Registry, error) {
+	stored, err := s.db.GetTestRunners(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test runner configuration: %w", err)
+	}
+	var config *runner.Config
+	if stored != "" {
+		config, err = runner.ParseConfig([]byte(stored))
+	} else {
+		config, err = runner.LoadConfig(ws.Dir())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.Registry()
+}
+
+// executeTests runs the given test cases of a component, through the
+// Makefile of the component or project if useMake is set and it has a test
+// target, or else file by file with the runners of the registry, and
+// attributes the per-test results in the output to the test cases; scope
+// describes what is being tested in messages
+func (s *Server) executeTests(ctx context.Context, ws *workspace.Workspace, registry *runner.Registry, projectKey, componentKey, scope string, testCases []database.TestCaseRef, useMake bool, out io.Writer) (*TestResult, []database.TestCaseResult, error) {
+	// First, check if the component or project has a Makefile with test targets - use that if available
+	makeDirs := []string{ws.ComponentDir(componentKey)}
+	if makeDirs[0] != ws.Dir() {
+		makeDirs = append(makeDirs, ws.Dir())
+	}
+	for _, makeDir := range makeDirs {
+		if !useMake || ws.Root == "" {
+			break
+		}
+		makefilePath := filepath.Join(makeDir, "Makefile")
 		if _, err := os.Stat(makefilePath); err == nil {
+			target := ""
 			// Check if Makefile has full-test target
 			if s.hasMakeTarget(makefilePath, "full-test") {
-				return s.runMakeTest(projectKey, componentKey, "full-test")
+				target = "full-test"
+			} else if s.hasMakeTarget(makefilePath, "test") {
+				// Fallback to 'test' target if available
+				target = "test"
 			}
-			// Fallback to 'test' target if available
-			if s.hasMakeTarget(makefilePath, "test") {
-				return s.runMakeTest(projectKey, componentKey, "test")
+			if target != "" {
+				result, err := s.runMakeTest(ctx, makeDir, target, out)
+				if err != nil {
+					return nil, nil, err
+				}
+				return result, results.Attribute(testCases, results.ParseOutput(result.Output), ""), nil
 			}
 		}
 	}
 
 	// Fallback to individual test file execution
-	// Get test files for this component
-	testFiles, err := s.getTestFilesForComponent(projectKey, componentKey)
-	if err != nil {
-		// Return a valid result with error message instead of error
-		return &TestResult{
-			Passed:   0,
-			Failed:   0,
-			Duration: "0s",
-			Output:   fmt.Sprintf("Error accessing test files: %v", err),
-		}, nil
+	// Get the test files of the test cases
+	var testFiles []string
+	for _, tc := range testCases {
+		if len(testFiles) == 0 || testFiles[len(testFiles)-1] != tc.FilePath {
+			testFiles = append(testFiles, tc.FilePath)
+		}
 	}
 
 	if len(testFiles) == 0 {
+		message := fmt.Sprintf("No test files found for %s in project '%s'.\n\nTo add test files, include them in your RTM JSON with test_cases entries.", scope, projectKey)
+		fmt.Fprintln(out, message)
 		return &TestResult{
 			Passed:   0,
 			Failed:   0,
 			Duration: "0s",
-			Output:   fmt.Sprintf("No test files found for component '%s' in project '%s'.\n\nTo add test files, include them in your RTM JSON with test_cases entries.", componentKey, projectKey),
-		}, nil
+			Output:   message,
+		}, nil, nil
 	}
 
 	// Run tests and collect results
@@ -1133,36 +1113,61 @@ func (s *Server) runTestsForComponent(projectKey, componentKey string) (*TestRes
 	passed := 0
 	failed := 0
 	skipped := 0
+	var records []database.TestCaseResult
 
 	for _, testFile := range testFiles {
-		// Resolve test file path using project base path
-		fullTestPath := testFile
-		if s.projectBasePath != "" {
-			fullTestPath = filepath.Join(s.projectBasePath, testFile)
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Resolve the test file in the project's or component's checkout
+		fullTestPath := ws.Path(componentKey, testFile)
+		if fullTestPath == "" {
+			skipped++
+			outputs = append(outputs, fmt.Sprintf("Skipping %s: File is outside the project's source root %s", testFile, ws.Dir()))
+			fmt.Fprintf(out, "%s\n\n", outputs[len(outputs)-1])
+			continue
 		}
 
 		// Check if test file actually exists
 		if _, err := os.Stat(fullTestPath); os.IsNotExist(err) {
 			skipped++
-			if s.projectBasePath != "" {
+			if ws.Root != "" {
 				outputs = append(outputs, fmt.Sprintf("Skipping %s: File does not exist at %s", testFile, fullTestPath))
 			} else {
-				outputs = append(outputs, fmt.Sprintf("Skipping %s: File does not exist (set TRACEVIBE_PROJECT_BASE_PATH or use --project-base-path)", testFile))
+				outputs = append(outputs, fmt.Sprintf("Skipping %s: File does not exist (set the project's source root, TRACEVIBE_PROJECT_BASE_PATH or use --project-base-path)", testFile))
 			}
+			fmt.Fprintf(out, "%s\n\n", outputs[len(outputs)-1])
 			continue
 		}
 
-		success, output, err := s.runTestFile(fullTestPath, s.projectBasePath)
+		var fileTestCases []database.TestCaseRef
+		var testNames []string
+		for _, tc := range testCases {
+			if tc.FilePath == testFile {
+				fileTestCases = append(fileTestCases, tc)
+				testNames = append(testNames, tc.TestName)
+			}
+		}
+
+		fmt.Fprintf(out, "Running tests in %s:\n", testFile)
+		success, output, outcomes, err := s.runTestFile(ctx, registry, ws.Root, ws.Resolve(componentKey, testFile), testNames, out)
 		outputs = append(outputs, fmt.Sprintf("Running tests in %s:\n%s", testFile, output))
+		fmt.Fprint(out, "\n")
 
+		failure := ""
 		if err != nil {
 			failed++
 			outputs = append(outputs, fmt.Sprintf("ERROR: %v", err))
+			fmt.Fprintf(out, "ERROR: %v\n\n", err)
+			failure = err.Error()
 		} else if success {
 			passed++
 		} else {
 			failed++
+			failure = "the test run failed without reporting any test"
 		}
+		records = append(records, results.Attribute(fileTestCases, outcomes, failure)...)
 	}
 
 	duration := time.Since(startTime)
@@ -1182,158 +1187,41 @@ func (s *Server) runTestsForComponent(projectKey, componentKey string) (*TestRes
 	summary := strings.Join(summaryParts, ", ")
 	if summary != "" {
 		outputs = append([]string{summary + "\n"}, outputs...)
+		fmt.Fprintf(out, "%s\n", summary)
 	}
 
 	return &TestResult{
 		Passed:   passed,
 		Failed:   failed,
 		Duration: duration.Round(time.Millisecond).String(),
-		Output:   strings.Join(outputs, "\n\n"),
-	}, nil
-}
-
-func (s *Server) getTestFilesForComponent(projectKey, componentKey string) ([]string, error) {
-	query := `
-		SELECT DISTINCT tf.file_path
-		FROM test_files tf
-		JOIN test_cases tc ON tf.id = tc.test_file_id
-		JOIN requirement_test_coverage rtc ON tc.id = rtc.test_case_id
-		JOIN requirements r ON rtc.requirement_id = r.id
-		JOIN system_components c ON r.component_id = c.id
-		JOIN projects p ON tf.project_id = p.id
-		WHERE p.project_key = ? AND c.component_key = ?
-		ORDER BY tf.file_path`
-
-	rows, err := s.db.Query(query, projectKey, componentKey)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var testFiles []string
-	for rows.Next() {
-		var filePath string
-		if err := rows.Scan(&filePath); err != nil {
-			continue
-		}
-		testFiles = append(testFiles, filePath)
-	}
-
-	return testFiles, nil
-}
-
-func (s *Server) runTestFile(testFile, projectBasePath string) (bool, string, error) {
-	// Determine test runner based on file extension and run appropriate commands
-	var cmd *exec.Cmd
-	var workingDir string
-
-	switch {
-	case strings.HasSuffix(testFile, "_test.go"):
-		// Go tests: run the package directory, not the individual file
-		packageDir := filepath.Dir(testFile)
-
-		// Set working directory to project base path if available, otherwise current dir
-		if projectBasePath != "" {
-			workingDir = projectBasePath
-			// Make packageDir relative to project base path
-			if relPath, err := filepath.Rel(projectBasePath, packageDir); err == nil {
-				packageDir = relPath
-			}
-		} else {
-			workingDir = "."
-		}
-		cmd = exec.Command("go", "test", "-v", "./"+packageDir)
-
-	case strings.HasSuffix(testFile, ".test.js") || strings.HasSuffix(testFile, ".spec.js") ||
-		 strings.HasSuffix(testFile, ".test.ts") || strings.HasSuffix(testFile, ".spec.ts"):
-		// JavaScript/TypeScript tests: use npm test or jest directly
-		if projectBasePath != "" {
-			// Start from project base path and look for package.json
-			workingDir = projectBasePath
-			packageDir := filepath.Dir(testFile)
-			for packageDir != "." && packageDir != "/" && packageDir != projectBasePath {
-				if _, err := os.Stat(filepath.Join(packageDir, "package.json")); err == nil {
-					workingDir = packageDir
-					break
-				}
-				packageDir = filepath.Dir(packageDir)
-			}
-		} else {
-			// Try to detect if it's a frontend project by checking for package.json
-			packageDir := filepath.Dir(testFile)
-			for packageDir != "." && packageDir != "/" {
-				if _, err := os.Stat(filepath.Join(packageDir, "package.json")); err == nil {
-					workingDir = packageDir
-					break
-				}
-				packageDir = filepath.Dir(packageDir)
-			}
-
-			if workingDir == "" {
-				workingDir = "."
-			}
-		}
-
-		// Use jest to run specific test file
-		relativeTestFile := testFile
-		if workingDir != "." {
-			if rel, err := filepath.Rel(workingDir, testFile); err == nil {
-				relativeTestFile = rel
-			}
-		}
-		cmd = exec.Command("npx", "jest", relativeTestFile, "--verbose")
-
-	case strings.HasSuffix(testFile, ".test.py") || strings.HasSuffix(testFile, "_test.py") || strings.HasSuffix(testFile, "test_*.py"):
-		// Python tests: use pytest
-		if projectBasePath != "" {
-			workingDir = projectBasePath
-			// Make test file relative to project base path
-			if relPath, err := filepath.Rel(projectBasePath, testFile); err == nil {
-				cmd = exec.Command("python", "-m", "pytest", "-v", relPath)
-			} else {
-				cmd = exec.Command("python", "-m", "pytest", "-v", testFile)
-			}
-		} else {
-			workingDir = "."
-			cmd = exec.Command("python", "-m", "pytest", "-v", testFile)
-		}
-
-	default:
-		return false, "", fmt.Errorf("unsupported test file format: %s (supported: Go _test.go, JS/TS .test/.spec files, Python .test.py/_test.py/test_*.py)", testFile)
-	}
+		Output:   strings.Join(outputs, "\n\n"),
+	}, records, nil
+}
 
-	// Set working directory if specified
-	if workingDir != "" {
-		cmd.Dir = workingDir
+// runTestFile runs the tests in one file, given relative to the checkout in
+// root, with the runner the registry picks for it and returns whether they
+// passed, their output and the outcome of each test it reports; the output is
+// also written to out as it is produced. When testNames are given only those
+// tests run.
+func (s *Server) runTestFile(ctx context.Context, registry *runner.Registry, root, testFile string, testNames []string, out io.Writer) (bool, string, []results.Outcome, error) {
+	testRunner := registry.Find(testFile)
+	if testRunner == nil {
+		return false, "", nil, fmt.Errorf("no test runner for %s (built in: Go _test.go, JS/TS .test/.spec files, Python .test.py/_test.py/test_*.py; configure others in %s)", testFile, runner.ConfigFile)
+	}
+	cmd, err := testRunner.Command(runner.Spec{BaseDir: root, File: testFile, Tests: testNames})
+	if err != nil {
+		return false, "", nil, err
 	}
 
-	// Inherit environment and ensure GOTOOLCHAIN is set to avoid version mismatches
-	cmd.Env = os.Environ()
-	// Set GOTOOLCHAIN to use the current Go version and handle auto-downloads
-	cmd.Env = append(cmd.Env, "GOTOOLCHAIN=go1.25.1+auto")
-
-	output, err := cmd.CombinedOutput()
-	outputStr := string(output)
-
 	// Add command info to output for debugging
-	cmdInfo := fmt.Sprintf("Command: %s\nWorking Dir: %s\n\n", strings.Join(cmd.Args, " "), workingDir)
-	outputStr = cmdInfo + outputStr
-
-	if err != nil {
-		// Check if it's a test failure vs execution error
-		exitCode := -1
-		if exitError, ok := err.(*exec.ExitError); ok {
-			exitCode = exitError.ExitCode()
-		}
+	cmdInfo := fmt.Sprintf("Runner: %s\n%s\n", testRunner.Name(), cmd)
+	fmt.Fprint(out, cmdInfo)
 
-		// Exit code 1 usually means test failures, not execution errors
-		if exitCode == 1 && (strings.Contains(outputStr, "FAIL") || strings.Contains(outputStr, "failed")) {
-			return false, outputStr, nil // Test ran but failed
-		}
-		return false, outputStr, fmt.Errorf("execution failed (exit code %d): %v", exitCode, err)
+	result, err := cmd.Run(ctx, s.sandbox, out)
+	if result == nil {
+		return false, cmdInfo, nil, err
 	}
-
-	return true, outputStr, nil
+	return result.Passed, cmdInfo + result.Output, result.Outcomes, err
 }
 
 // Helper methods
@@ -1371,66 +1259,20 @@ func splitPath(path string) []string {
 // Data structures for templates
 
 type ProjectSummary struct {
-	ID                    string `json:"id"`
-	ProjectKey            string `json:"project_key"`
-	Name                  string `json:"name"`
-	Description           string `json:"description"`
-	Status                string `json:"status"`
-	ComponentCount        int    `json:"component_count"`
-	RequirementCount      int    `json:"requirement_count"`
-	ScopeCount            int    `json:"scope_count"`
-	UserStoryCount        int    `json:"user_story_count"`
-	TechSpecCount         int    `json:"tech_spec_count"`
-	TestCaseCount         int    `json:"test_case_count"`
-	UnitTestCount         int    `json:"unit_test_count"`
-	IntegrationTestCount  int    `json:"integration_test_count"`
-	E2ETestCount          int    `json:"e2e_test_count"`
-}
-
-type ComponentSummary struct {
-	ID               string   `json:"id"`
-	ComponentKey     string   `json:"component_key"`
-	Name             string   `json:"name"`
-	ComponentType    string   `json:"component_type"`
-	Technology       string   `json:"technology"`
-	Description      string   `json:"description"`
-	Tags             []string `json:"tags"`
-	TotalRequirements int     `json:"total_requirements"`
-	ScopeCount       int      `json:"scope_count"`
-	UserStoryCount   int      `json:"user_story_count"`
-	TechSpecCount    int      `json:"tech_spec_count"`
-	ImplementationCount int   `json:"implementation_count"`
-	TestCaseCount    int      `json:"test_case_count"`
-	ScopeIDs         []string `json:"scope_ids"`
-}
-
-type RequirementTree struct {
-	ID               string            `json:"id"`
-	RequirementKey   string            `json:"requirement_key"`
-	RequirementType  string            `json:"requirement_type"`
-	Title            string            `json:"title"`
-	Description      string            `json:"description"`
-	Category         string            `json:"category"`
-	Status           string            `json:"status"`
-	Priority         string            `json:"priority"`
-	Children         []RequirementTree `json:"children"`
-	Implementation   []ImplementationInfo `json:"implementation"`
-	TestCases        []TestCaseInfo    `json:"test_cases"`
-	UserStoryCount   int               `json:"user_story_count"`
-	TechSpecCount    int               `json:"tech_spec_count"`
-	TestCaseCount    int               `json:"test_case_count"`
-}
-
-type ImplementationInfo struct {
-	Layer     string   `json:"layer"`
-	FilePath  string   `json:"file_path"`
-	Functions []string `json:"functions"`
-}
-
-type TestCaseInfo struct {
-	FilePath string `json:"file_path"`
-	TestName string `json:"test_name"`
-	TestType string `json:"test_type"`
+	ID                   string `json:"id"`
+	ProjectKey           string `json:"project_key"`
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	Status               string `json:"status"`
+	ComponentCount       int    `json:"component_count"`
+	RequirementCount     int    `json:"requirement_count"`
+	ScopeCount           int    `json:"scope_count"`
+	UserStoryCount       int    `json:"user_story_count"`
+	TechSpecCount        int    `json:"tech_spec_count"`
+	TestCaseCount        int    `json:"test_case_count"`
+	UnitTestCount        int    `json:"unit_test_count"`
+	IntegrationTestCount int    `json:"integration_test_count"`
+	E2ETestCount         int    `json:"e2e_test_count"`
 }
 
 type TestResult struct {
@@ -1438,6 +1280,7 @@ type TestResult struct {
 	Failed   int    `json:"failed"`
 	Duration string `json:"duration"`
 	Output   string `json:"output"`
+	RunID    string `json:"run_id,omitempty"` // test run recorded in the history
 }
 
 // Database query methods (these would need to be implemented in the database package)
@@ -1536,103 +1379,6 @@ func (s *Server) getProjectsSummary() ([]ProjectSummary, error) {
 	return projects, nil
 }
 
-func (s *Server) getComponentsSummary(projectKey string) ([]ComponentSummary, error) {
-	// First check if tags column exists
-	var tagCount int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('system_components') WHERE name='tags'").Scan(&tagCount)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check table structure: %w", err)
-	}
-
-	var query string
-	if tagCount > 0 {
-		// Tags column exists, include it in the query
-		query = `
-			SELECT c.id, c.component_key, c.name, c.component_type,
-				   COALESCE(c.technology, '') as technology, COALESCE(c.description, '') as description,
-				   COALESCE(c.tags, '[]') as tags,
-				   COALESCE(cs.total_requirements, 0), COALESCE(cs.scope_count, 0),
-				   COALESCE(cs.user_story_count, 0), COALESCE(cs.tech_spec_count, 0),
-				   COALESCE(cs.implementation_count, 0), COALESCE(cs.test_case_count, 0)
-			FROM system_components c
-			JOIN projects p ON c.project_id = p.id
-			LEFT JOIN component_summary cs ON c.id = cs.id
-			WHERE p.project_key = ?
-			ORDER BY c.name`
-	} else {
-		// Tags column doesn't exist, exclude it from query
-		query = `
-			SELECT c.id, c.component_key, c.name, c.component_type,
-				   COALESCE(c.technology, '') as technology, COALESCE(c.description, '') as description,
-				   COALESCE(cs.total_requirements, 0), COALESCE(cs.scope_count, 0),
-				   COALESCE(cs.user_story_count, 0), COALESCE(cs.tech_spec_count, 0),
-				   COALESCE(cs.implementation_count, 0), COALESCE(cs.test_case_count, 0)
-			FROM system_components c
-			JOIN projects p ON c.project_id = p.id
-			LEFT JOIN component_summary cs ON c.id = cs.id
-			WHERE p.project_key = ?
-			ORDER BY c.name`
-	}
-
-	rows, err := s.db.Query(query, projectKey)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var components []ComponentSummary
-	for rows.Next() {
-		var c ComponentSummary
-		var tagsJSON string
-
-		if tagCount > 0 {
-			// Scan with tags column
-			err := rows.Scan(&c.ID, &c.ComponentKey, &c.Name, &c.ComponentType,
-				&c.Technology, &c.Description, &tagsJSON, &c.TotalRequirements, &c.ScopeCount,
-				&c.UserStoryCount, &c.TechSpecCount, &c.ImplementationCount, &c.TestCaseCount)
-			if err != nil {
-				return nil, err
-			}
-
-			// Parse tags JSON
-			if tagsJSON != "" && tagsJSON != "[]" {
-				json.Unmarshal([]byte(tagsJSON), &c.Tags)
-			}
-		} else {
-			// Scan without tags column
-			err := rows.Scan(&c.ID, &c.ComponentKey, &c.Name, &c.ComponentType,
-				&c.Technology, &c.Description, &c.TotalRequirements, &c.ScopeCount,
-				&c.UserStoryCount, &c.TechSpecCount, &c.ImplementationCount, &c.TestCaseCount)
-			if err != nil {
-				return nil, err
-			}
-			// Tags will remain empty slice (initialized by struct)
-		}
-
-		// Get scope IDs for this component
-		scopeQuery := `
-			SELECT r.requirement_key
-			FROM requirements r
-			WHERE r.component_id = ? AND UPPER(r.requirement_type) = 'SCOPE'
-			ORDER BY r.requirement_key`
-
-		scopeRows, err := s.db.Query(scopeQuery, c.ID)
-		if err == nil {
-			defer scopeRows.Close()
-			for scopeRows.Next() {
-				var scopeID string
-				if err := scopeRows.Scan(&scopeID); err == nil {
-					c.ScopeIDs = append(c.ScopeIDs, scopeID)
-				}
-			}
-		}
-
-		components = append(components, c)
-	}
-
-	return components, nil
-}
-
 func (s *Server) getComponentByKey(projectKey, componentKey string) (*ComponentSummary, error) {
 	query := `
 		SELECT c.id, c.component_key, c.name, c.component_type,
@@ -1658,205 +1404,6 @@ func (s *Server) getComponentByKey(projectKey, componentKey string) (*ComponentS
 	return &c, nil
 }
 
-func (s *Server) getRequirementsTree(projectKey, componentKey string) ([]RequirementTree, error) {
-	// This is a simplified version - in practice you'd need recursive queries or multiple queries
-	// to build the complete hierarchical tree with implementations and test cases
-
-	whereClause := "WHERE p.project_key = ? AND r.parent_requirement_id IS NULL"
-	args := []interface{}{projectKey}
-
-	if componentKey != "" {
-		whereClause += " AND c.component_key = ?"
-		args = append(args, componentKey)
-	}
-
-	query := fmt.Sprintf(`
-		SELECT r.id, r.requirement_key, r.requirement_type, r.title,
-			   COALESCE(r.description, '') as description, r.category, r.status,
-			   COALESCE(r.priority, 'medium') as priority
-		FROM requirements r
-		JOIN projects p ON r.project_id = p.id
-		JOIN system_components c ON r.component_id = c.id
-		%s
-		ORDER BY r.requirement_key`, whereClause)
-
-
-	rows, err := s.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var requirements []RequirementTree
-	rowCount := 0
-	for rows.Next() {
-		rowCount++
-		var req RequirementTree
-		err := rows.Scan(&req.ID, &req.RequirementKey, &req.RequirementType,
-			&req.Title, &req.Description, &req.Category, &req.Status, &req.Priority)
-		if err != nil {
-			return nil, err
-		}
-
-		// Get children recursively (simplified for now)
-		children, err := s.getChildRequirements(req.ID)
-		if err == nil {
-			req.Children = children
-			// Calculate counts from children
-			for _, child := range children {
-				switch strings.ToUpper(child.RequirementType) {
-				case "USER_STORY":
-					req.UserStoryCount++
-				case "TECH_SPEC":
-					req.TechSpecCount++
-				}
-				req.TestCaseCount += len(child.TestCases) + child.TestCaseCount
-			}
-		}
-
-		// Get implementation and test info
-		req.Implementation, _ = s.getImplementationInfo(req.ID)
-		req.TestCases, _ = s.getTestCaseInfo(req.ID)
-		req.TestCaseCount += len(req.TestCases)
-
-		requirements = append(requirements, req)
-	}
-
-	return requirements, nil
-}
-
-func (s *Server) getChildRequirements(parentID string) ([]RequirementTree, error) {
-	query := `
-		SELECT id, requirement_key, requirement_type, title,
-			   COALESCE(description, '') as description, category, status,
-			   COALESCE(priority, 'medium') as priority
-		FROM requirements
-		WHERE parent_requirement_id = ?
-		ORDER BY requirement_key`
-
-	rows, err := s.db.Query(query, parentID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var children []RequirementTree
-	for rows.Next() {
-		var child RequirementTree
-		err := rows.Scan(&child.ID, &child.RequirementKey, &child.RequirementType,
-			&child.Title, &child.Description, &child.Category, &child.Status, &child.Priority)
-		if err != nil {
-			continue
-		}
-
-		// Recursively get children
-		grandchildren, err := s.getChildRequirements(child.ID)
-		if err == nil {
-			child.Children = grandchildren
-			// Calculate counts from grandchildren
-			for _, grandchild := range grandchildren {
-				switch strings.ToUpper(grandchild.RequirementType) {
-				case "USER_STORY":
-					child.UserStoryCount++
-				case "TECH_SPEC":
-					child.TechSpecCount++
-				}
-				child.TestCaseCount += len(grandchild.TestCases) + grandchild.TestCaseCount
-			}
-		}
-
-		// Get implementation and test info
-		child.Implementation, _ = s.getImplementationInfo(child.ID)
-		child.TestCases, _ = s.getTestCaseInfo(child.ID)
-		child.TestCaseCount += len(child.TestCases)
-
-		children = append(children, child)
-	}
-
-	return children, nil
-}
-
-func (s *Server) getImplementationInfo(requirementID string) ([]ImplementationInfo, error) {
-	query := `SELECT layer, file_path, COALESCE(functions, '[]') as functions
-			  FROM implementations WHERE requirement_id = ?`
-
-	rows, err := s.db.Query(query, requirementID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var implementations []ImplementationInfo
-	for rows.Next() {
-		var impl ImplementationInfo
-		var functionsJSON string
-
-		err := rows.Scan(&impl.Layer, &impl.FilePath, &functionsJSON)
-		if err != nil {
-			continue
-		}
-
-		// Parse functions JSON
-		if functionsJSON != "" && functionsJSON != "[]" {
-			json.Unmarshal([]byte(functionsJSON), &impl.Functions)
-		}
-
-		implementations = append(implementations, impl)
-	}
-
-	return implementations, nil
-}
-
-func (s *Server) getTestCaseInfo(requirementID string) ([]TestCaseInfo, error) {
-	query := `
-		SELECT tf.file_path, tc.test_name, tc.test_type
-		FROM requirement_test_coverage rtc
-		JOIN test_cases tc ON rtc.test_case_id = tc.id
-		JOIN test_files tf ON tc.test_file_id = tf.id
-		WHERE rtc.requirement_id = ?`
-
-	rows, err := s.db.Query(query, requirementID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var testCases []TestCaseInfo
-	for rows.Next() {
-		var tc TestCaseInfo
-		err := rows.Scan(&tc.FilePath, &tc.TestName, &tc.TestType)
-		if err != nil {
-			continue
-		}
-		testCases = append(testCases, tc)
-	}
-
-	return testCases, nil
-}
-
-func countRequirementsByType(req RequirementTree, scopeCount, userStoryCount, techSpecCount *int) {
-	switch strings.ToUpper(req.RequirementType) {
-	case "SCOPE":
-		*scopeCount++
-	case "USER_STORY":
-		*userStoryCount++
-	case "TECH_SPEC":
-		*techSpecCount++
-	}
-
-	for _, child := range req.Children {
-		countRequirementsByType(child, scopeCount, userStoryCount, techSpecCount)
-	}
-}
-
-func countTestCases(req RequirementTree) int {
-	count := len(req.TestCases)
-	for _, child := range req.Children {
-		count += countTestCases(child)
-	}
-	return count
-}
-
 // hasMakeTarget checks if a Makefile contains a specific target
 func (s *Server) hasMakeTarget(makefilePath, target string) bool {
 	content, err := os.ReadFile(makefilePath)
@@ -1875,25 +1422,25 @@ func (s *Server) hasMakeTarget(makefilePath, target string) bool {
 	return false
 }
 
-// runMakeTest executes a make target for testing
-func (s *Server) runMakeTest(projectKey, componentKey, target string) (*TestResult, error) {
+// runMakeTest executes a make target in dir for testing, writing its output to out
+// as it is produced
+func (s *Server) runMakeTest(ctx context.Context, dir, target string, out io.Writer) (*TestResult, error) {
 	startTime := time.Now()
 
 	// Execute make command in the project directory
-	cmd := exec.Command("make", target)
-	cmd.Dir = s.projectBasePath
-
-	// Inherit environment and ensure GOTOOLCHAIN is set to avoid version mismatches
-	cmd.Env = os.Environ()
-	// Set GOTOOLCHAIN to use the current Go version and handle auto-downloads
-	cmd.Env = append(cmd.Env, "GOTOOLCHAIN=go1.25.1+auto")
-
-	output, err := cmd.CombinedOutput()
-	outputStr := string(output)
+	cmd := &runner.Command{Args: []string{"make", target}, Dir: dir}
 
 	// Add command info to output
-	cmdInfo := fmt.Sprintf("Command: make %s\nWorking Dir: %s\n\n", target, s.projectBasePath)
-	outputStr = cmdInfo + outputStr
+	cmdInfo := cmd.String() + "\n"
+	fmt.Fprint(out, cmdInfo)
+
+	var output bytes.Buffer
+	err := s.sandbox.Exec(ctx, cmd, io.MultiWriter(&output, out))
+	if errors.Is(err, runner.ErrTimeout) {
+		fmt.Fprintf(&output, "\nERROR: %v\n", err)
+		fmt.Fprintf(out, "\nERROR: %v\n", err)
+	}
+	outputStr := cmdInfo + output.String()
 
 	duration := time.Since(startTime)
 
@@ -1981,13 +1528,15 @@ func (s *Server) componentsAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) createComponentHandler(w http.ResponseWriter, r *http.Request) {
 	var data struct {
-		ProjectID      string   `json:"project_id"`
-		ComponentKey   string   `json:"component_key"`
-		Name           string   `json:"name"`
-		ComponentType  string   `json:"component_type"`
-		Technology     *string  `json:"technology"`
-		Description    *string  `json:"description"`
-		Tags           []string `json:"tags"`
+		ProjectID     string   `json:"project_id"`
+		ComponentKey  string   `json:"component_key"`
+		Name          string   `json:"name"`
+		ComponentType string   `json:"component_type"`
+		Technology    *string  `json:"technology"`
+		Description   *string  `json:"description"`
+		Tags          []string `json:"tags"`
+		Path          *string  `json:"path"`      // directory in the checkout
+		BasePath      *string  `json:"base_path"` // directory file paths are relative to
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
@@ -2015,11 +1564,11 @@ func (s *Server) createComponentHandler(w http.ResponseWriter, r *http.Request)
 
 	// Create the component in the database
 	query := `
-		INSERT INTO system_components (project_id, component_key, name, component_type, technology, description, tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO system_components (project_id, component_key, name, component_type, technology, description, tags, path, base_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.Exec(query, data.ProjectID, data.ComponentKey, data.Name, data.ComponentType, data.Technology, data.Description, tagsJSON)
+	result, err := s.db.Exec(query, data.ProjectID, data.ComponentKey, data.Name, data.ComponentType, data.Technology, data.Description, tagsJSON, data.Path, data.BasePath)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error creating component: %v", err), http.StatusInternalServerError)
 		return
@@ -2059,6 +1608,8 @@ func (s *Server) updateComponentHandler(w http.ResponseWriter, r *http.Request)
 		Technology    *string  `json:"technology"`
 		Description   *string  `json:"description"`
 		Tags          []string `json:"tags"`
+		Path          *string  `json:"path"`      // left as it is when absent
+		BasePath      *string  `json:"base_path"` // likewise
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
@@ -2066,7 +1617,6 @@ func (s *Server) updateComponentHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-
 	// Validate required fields
 	if data.ID == "" || data.Name == "" || data.ComponentType == "" {
 		http.Error(w, "Missing required fields: id, name, component_type", http.StatusBadRequest)
@@ -2101,10 +1651,11 @@ func (s *Server) updateComponentHandler(w http.ResponseWriter, r *http.Request)
 		// Tags column exists, include it in the update
 		query = `
 			UPDATE system_components
-			SET name = ?, component_type = ?, technology = ?, description = ?, tags = ?
+			SET name = ?, component_type = ?, technology = ?, description = ?, tags = ?,
+				path = COALESCE(?, path), base_path = COALESCE(?, base_path)
 			WHERE id = ?
 		`
-		args = []interface{}{data.Name, data.ComponentType, data.Technology, data.Description, tagsJSON, data.ID}
+		args = []interface{}{data.Name, data.ComponentType, data.Technology, data.Description, tagsJSON, data.Path, data.BasePath, data.ID}
 	} else {
 		// Tags column doesn't exist, exclude it from update
 		query = `
@@ -2152,22 +1703,16 @@ func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse multipart form
-	err := r.ParseMultipartForm(10 << 20) // 10MB max
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error parsing form: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// Get file from form
-	file, header, err := r.FormFile("file")
+	tempPath, filename, err := saveUploadedRTMFile(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error getting file: %v", err), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
+	defer os.Remove(tempPath)
 
-	// Get project key and overwrite flag
+	// Get project key and import mode. "overwrite=true" is kept for older clients;
+	// merge conflicts are resolved with a JSON object of conflict ID -> "file"/"database";
+	// prune is "true"/"delete" or "mark"
 	projectKey := r.FormValue("project_key")
 	overwrite := r.FormValue("overwrite") == "true"
 
@@ -2176,27 +1721,53 @@ func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create temporary file
-	tempFile, err := os.CreateTemp("", "rtm_import_*"+filepath.Ext(header.Filename))
+	mode, err := importer.ParseMode(r.FormValue("mode"))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error creating temp file: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	// Copy uploaded file to temp file
-	_, err = io.Copy(tempFile, file)
+	if overwrite {
+		mode = importer.ModeOverwrite
+	}
+	prune, err := importer.ParsePrune(r.FormValue("prune"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mapping, err := uploadedColumnMapping(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error saving file: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	opts := importer.Options{Mode: mode, Prune: prune, Filename: filename, Mapping: mapping}
+	if resolutions := r.FormValue("resolutions"); resolutions != "" {
+		if err := json.Unmarshal([]byte(resolutions), &opts.Resolutions); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid resolutions: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Import using the existing importer
-	importer := importer.New(s.db)
-	err = importer.ImportRTMFile(tempFile.Name(), projectKey, overwrite)
+	imp := importer.New(s.db)
+	report, err := imp.Import(tempPath, projectKey, opts)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Import failed: %v", err), http.StatusInternalServerError)
+		var validationErr *importer.ValidationError
+		if errors.As(err, &validationErr) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "RTM file failed validation",
+				"issues":  validationErr.Issues,
+				"report":  report,
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Import failed: %v", err),
+			"report":  report,
+		})
 		return
 	}
 
@@ -2204,13 +1775,138 @@ func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"success":     true,
 		"project_key": projectKey,
-		"filename":    header.Filename,
-		"overwrite":   overwrite,
+		"filename":    filename,
+		"overwrite":   mode == importer.ModeOverwrite,
+		"mode":        mode,
+		"conflicts":   report.Conflicts,
+		"unresolved":  len(report.Unresolved()),
+		"pruned":      report.Pruned,
+		"import_id":   report.ImportID,
+		"counts":      report.Counts,
+		"warnings":    report.Warnings,
+		"report":      report,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// importPreviewHandler compares an uploaded RTM file with the stored project
+// without importing it
+func (s *Server) importPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tempPath, filename, err := saveUploadedRTMFile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer os.Remove(tempPath)
+
+	projectKey := r.FormValue("project_key")
+	if projectKey == "" {
+		http.Error(w, "Project key is required", http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := uploadedColumnMapping(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp := importer.New(s.db)
+	diff, err := imp.DiffRTMFile(tempPath, projectKey, mapping)
+	if err != nil {
+		var validationErr *importer.ValidationError
+		if errors.As(err, &validationErr) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "RTM file failed validation",
+				"issues":  validationErr.Issues,
+			})
+			return
+		}
+		http.Error(w, fmt.Sprintf("Preview failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"project_key": projectKey,
+		"filename":    filename,
+		"diff":        diff,
+	}
+
+	// For a merge import, also list the conflicts that would need resolving
+	if r.FormValue("mode") == string(importer.ModeMerge) && len(diff.Issues) == 0 {
+		conflicts, err := imp.MergeConflicts(tempPath, projectKey, mapping)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Preview failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response["conflicts"] = conflicts
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
+// saveUploadedRTMFile copies the "file" field of a multipart upload to a temp
+// file, keeping its extension so the importer can tell JSON from YAML. The
+// caller removes the returned file.
+func saveUploadedRTMFile(r *http.Request) (string, string, error) {
+	// Parse multipart form
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB max
+		return "", "", fmt.Errorf("Error parsing form: %v", err)
+	}
+
+	// Get file from form
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", "", fmt.Errorf("Error getting file: %v", err)
+	}
+	defer file.Close()
+
+	// Create temporary file
+	tempFile, err := os.CreateTemp("", "rtm_import_*"+filepath.Ext(header.Filename))
+	if err != nil {
+		return "", "", fmt.Errorf("Error creating temp file: %v", err)
+	}
+	defer tempFile.Close()
+
+	// Copy uploaded file to temp file
+	if _, err := io.Copy(tempFile, file); err != nil {
+		os.Remove(tempFile.Name())
+		return "", "", fmt.Errorf("Error saving file: %v", err)
+	}
+
+	return tempFile.Name(), header.Filename, nil
+}
+
+// uploadedColumnMapping reads the optional "mapping" file of an upload, the
+// column mapping for a CSV/XLSX backlog. It returns nil when there is none.
+func uploadedColumnMapping(r *http.Request) (*importer.ColumnMapping, error) {
+	file, _, err := r.FormFile("mapping")
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error getting column mapping: %v", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading column mapping: %v", err)
+	}
+	return importer.ParseColumnMapping(data)
+}
+
 // Requirements API handlers
 func (s *Server) requirementsAPIHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2455,6 +2151,18 @@ func (s *Server) generateRequirementKeyHandler(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// rtmSchemaHandler serves the JSON Schema of the RTM import format
+func (s *Server) rtmSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("X-RTM-Schema-Version", schema.Version)
+	w.Write(schema.RTM())
+}
+
 // methodologyHandler handles GET and POST requests for methodology
 func (s *Server) methodologyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2529,9 +2237,9 @@ func (s *Server) projectContextHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
+			"success":     true,
 			"project_key": projectKey,
-			"context": context,
+			"context":     context,
 		})
 
 	case http.MethodPost:
@@ -2550,12 +2258,12 @@ func (s *Server) projectContextHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": "Project context saved successfully",
+			"success":     true,
+			"message":     "Project context saved successfully",
 			"project_key": projectKey,
 		})
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Generate a static HTML site of a project's traceability matrix",
+	Long: `Generate a multi-page static site of a project: an index page with the
+project's totals and components, a page per component with its requirement
+tree, and a page per requirement with its parents, children, implementation
+files and test cases, all cross-linked.
+
+Every page has a search box backed by a client-side index, and coverage badges
+show which share of the leaf requirements have tests linked. The badges are
+also written as SVG files for READMEs and dashboards: badges/coverage.svg for
+the project and badges/components/ for each component.
+
+The site needs no server: publish the output directory to any static host or
+keep it as a CI artifact, or open index.html straight from disk. Existing files
+in the output directory are overwritten but not removed.
+
+Example:
+  tracevibe site --project statsly -o ./public
+  tracevibe site --project statsly --component COMP-001,COMP-002 -o ./public`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		outputDir, _ := cmd.Flags().GetString("output")
+		var filter export.Filter
+		filter.Component, _ = cmd.Flags().GetString("component")
+
+		if err := runSite(projectKey, dbPath, outputDir, filter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating site: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(siteCmd)
+
+	siteCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	siteCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	siteCmd.Flags().StringP("output", "o", "public", "Output directory")
+	siteCmd.Flags().String("component", "", "Only these components (comma-separated keys)")
+
+	siteCmd.MarkFlagRequired("project")
+}
+
+func runSite(projectKey, dbPath, outputDir string, filter export.Filter) error {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	pages, err := export.WriteSite(db, projectKey, outputDir, filter)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated %d pages for project '%s' in %s\n", pages, projectKey, outputDir)
+	return nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run the tests linked to a component or requirement",
+	Long: `Run the test cases linked to the requirements of a component, or only those
+linked to one requirement and its descendants, and record the outcome of
+each in the test history.
+
+Only the linked test functions run: Go tests are selected with go test -run,
+Jest tests with jest -t and pytest tests with pytest -k. A whole component
+runs through the project's Makefile instead when it has a full-test or test
+target.
+
+Other frameworks, and different commands, environments or timeouts, are
+configured as test runners in .tracevibe.yaml at the root of the project, or
+saved for the project with tracevibe runners set FILE --project KEY; the
+server's /api/project/KEY/test-runners only shows them.
+Each runner maps globs of test files to a command template and names the
+parser of its results: the -v output of go test, Jest and pytest (output),
+go test -json (go-json), a JUnit XML report (junit) or TAP (tap).
+
+Each test command runs in its own process group, killed with everything it
+started when it exceeds --test-timeout or on Ctrl-C. --test-env limits the
+environment it inherits, --test-max-output caps the output kept and
+--test-temp-home gives it a throwaway HOME and build cache.
+
+Test files are resolved against the project's source root and the paths of
+its components. Projects without a source root use --project-base-path, which
+defaults to $TRACEVIBE_PROJECT_BASE_PATH or else the current directory. The command
+exits with status 1 when a test fails.
+
+Example:
+  tracevibe test --project statsly --component COMP-001
+  tracevibe test --project statsly --req SCOPE-2-US-1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectKey, _ := cmd.Flags().GetString("project")
+		dbPath, _ := cmd.Flags().GetString("db-path")
+		componentKey, _ := cmd.Flags().GetString("component")
+		requirementKey, _ := cmd.Flags().GetString("req")
+		projectBasePath, _ := cmd.Flags().GetString("project-base-path")
+		sandbox := sandboxFromFlags(cmd, []string{"*"})
+
+		if componentKey == "" && requirementKey == "" {
+			fmt.Fprintf(os.Stderr, "Error: either --component or --req is required\n")
+			os.Exit(1)
+		}
+		if projectBasePath == "" {
+			projectBasePath = os.Getenv("TRACEVIBE_PROJECT_BASE_PATH")
+		}
+		if projectBasePath == "" {
+			projectBasePath = "."
+		}
+
+		result, err := runTestCommand(projectKey, dbPath, componentKey, requirementKey, projectBasePath, sandbox)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running tests: %v\n", err)
+			os.Exit(1)
+		}
+
+		if result.RunID != "" {
+			fmt.Printf("Recorded as test run %s\n", result.RunID)
+		}
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringP("project", "p", "", "Project key/identifier (required)")
+	testCmd.Flags().StringP("db-path", "d", getDefaultDBPath(), "SQLite database path")
+	testCmd.Flags().StringP("component", "c", "", "Run the tests of this component")
+	testCmd.Flags().String("req", "", "Run the tests of this requirement and its descendants (requirement key or ID)")
+	testCmd.Flags().String("project-base-path", "", "Source root if the project has none of its own (default: the current directory)")
+	addSandboxFlags(testCmd, "default: all")
+
+	testCmd.MarkFlagRequired("project")
+}
+
+// addSandboxFlags adds the flags that limit test commands; envDefault
+// describes the variables passed on when --test-env is not given
+func addSandboxFlags(cmd *cobra.Command, envDefault string) {
+	cmd.Flags().Duration("test-timeout", 30*time.Minute, "Time limit of each test command without a timeout of its own (0 for none)")
+	cmd.Flags().StringSlice("test-env", nil, "Environment variables passed to test commands, as NAME, PREFIX* or * for all ("+envDefault+")")
+	cmd.Flags().Int("test-max-output", int(runner.DefaultOutputLimit>>20), "Megabytes of output kept per test command")
+	cmd.Flags().Bool("test-temp-home", false, "Run each test command with a temporary HOME, GOCACHE and TMPDIR")
+}
+
+// sandboxFromFlags returns the limits set by the flags of addSandboxFlags;
+// env is passed on when --test-env is not given
+func sandboxFromFlags(cmd *cobra.Command, env []string) *runner.Sandbox {
+	sandbox := &runner.Sandbox{Env: env}
+	sandbox.Timeout, _ = cmd.Flags().GetDuration("test-timeout")
+	sandbox.TempHome, _ = cmd.Flags().GetBool("test-temp-home")
+	if names, _ := cmd.Flags().GetStringSlice("test-env"); len(names) > 0 {
+		sandbox.Env = names
+	}
+	maxOutput, _ := cmd.Flags().GetInt("test-max-output")
+	sandbox.OutputLimit = int64(maxOutput) << 20
+	return sandbox
+}
+
+func runTestCommand(projectKey, dbPath, componentKey, requirementKey, projectBasePath string, sandbox *runner.Sandbox) (*TestResult, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	// Ctrl-C stops the tests; the interrupted run is not recorded
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	server := &Server{db: db, projectBasePath: projectBasePath, sandbox: sandbox}
+	result, err := server.runTests(ctx, "", projectKey, componentKey, requirementKey, os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		return nil, errors.New("interrupted")
+	}
+	return result, err
+}
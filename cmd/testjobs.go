@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Test job states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobCompleted = "completed" // the tests ran; Result says whether they passed
+	jobCancelled = "cancelled"
+	jobFailed    = "failed" // the tests could not be run
+)
+
+// maxFinishedJobs is how many finished jobs are kept for status queries
+const maxFinishedJobs = 100
+
+// testRunStatus is what the API reports about a test job
+type testRunStatus struct {
+	ID          string      `json:"id"`
+	Project     string      `json:"project"`
+	Component   string      `json:"component,omitempty"`
+	Requirement string      `json:"requirement,omitempty"`
+	Status      string      `json:"status"`
+	Error       string      `json:"error,omitempty"`
+	Result      *TestResult `json:"result,omitempty"`
+	QueuedAt    time.Time   `json:"queued_at"`
+	StartedAt   *time.Time  `json:"started_at,omitempty"`
+	FinishedAt  *time.Time  `json:"finished_at,omitempty"`
+}
+
+func (s testRunStatus) finished() bool {
+	return s.Status == jobCompleted || s.Status == jobCancelled || s.Status == jobFailed
+}
+
+// testJob is one test run submitted through /api/test/run. Its ID is also the
+// ID of the run in the test history.
+type testJob struct {
+	testRunStatus
+
+	mu      sync.Mutex
+	output  []byte
+	changed chan struct{} // closed and replaced whenever output or status change
+	cancel  context.CancelFunc
+	ctx     context.Context
+}
+
+// Write appends live output and wakes up everyone streaming it
+func (j *testJob) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	j.output = append(j.output, p...)
+	j.notifyLocked()
+	j.mu.Unlock()
+	return len(p), nil
+}
+
+func (j *testJob) notifyLocked() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// snapshot returns the job's state and the output from offset on, and a
+// channel that is closed when either changes
+func (j *testJob) snapshot(offset int) (state testRunStatus, output []byte, changed <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state = j.testRunStatus
+	if offset < len(j.output) {
+		output = append([]byte(nil), j.output[offset:]...)
+	}
+	return state, output, j.changed
+}
+
+// testJobs queues test runs and executes them on a fixed pool of workers
+type testJobs struct {
+	mu       sync.Mutex
+	jobs     map[string]*testJob
+	finished []string // IDs of finished jobs, oldest first
+	queue    chan *testJob
+	run      func(ctx context.Context, job *testJob) (*TestResult, error)
+}
+
+func newTestJobs(workers int, run func(ctx context.Context, job *testJob) (*TestResult, error)) *testJobs {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := &testJobs{
+		jobs:  make(map[string]*testJob),
+		queue: make(chan *testJob, 1000),
+		run:   run,
+	}
+	for i := 0; i < workers; i++ {
+		go jobs.work()
+	}
+	return jobs
+}
+
+// submit queues a test run of a component or of a requirement's subtree
+func (t *testJobs) submit(project, component, requirement string) (*testJob, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate run ID: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &testJob{
+		testRunStatus: testRunStatus{
+			ID:          strings.ToUpper(hex.EncodeToString(id)),
+			Project:     project,
+			Component:   component,
+			Requirement: requirement,
+			Status:      jobQueued,
+			QueuedAt:    time.Now().UTC(),
+		},
+		changed: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	t.mu.Lock()
+	t.jobs[job.ID] = job
+	t.mu.Unlock()
+
+	select {
+	case t.queue <- job:
+		return job, nil
+	default:
+		// The caller never learns the ID, so the job is not kept either
+		t.mu.Lock()
+		delete(t.jobs, job.ID)
+		t.mu.Unlock()
+		cancel()
+		return nil, errors.New("too many queued test runs")
+	}
+}
+
+func (t *testJobs) get(id string) *testJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.jobs[id]
+}
+
+// list returns the queued, running and recently finished jobs, oldest first
+func (t *testJobs) list() []*testJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	jobs := make([]*testJob, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].QueuedAt.Equal(jobs[j].QueuedAt) {
+			return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs
+}
+
+// cancel stops a queued or running job; it reports false for finished jobs
+func (t *testJobs) cancel(job *testJob) bool {
+	job.mu.Lock()
+	done := job.finished()
+	job.mu.Unlock()
+	if done {
+		return false
+	}
+	job.cancel()
+	return true
+}
+
+func (t *testJobs) work() {
+	for job := range t.queue {
+		job.mu.Lock()
+		if job.ctx.Err() != nil {
+			job.mu.Unlock()
+			t.finish(job, nil, job.ctx.Err())
+			continue
+		}
+		now := time.Now().UTC()
+		job.Status, job.StartedAt = jobRunning, &now
+		job.notifyLocked()
+		job.mu.Unlock()
+
+		result, err := t.run(job.ctx, job)
+		t.finish(job, result, err)
+	}
+}
+
+// finish records the outcome of a job and forgets the oldest finished jobs
+func (t *testJobs) finish(job *testJob, result *TestResult, err error) {
+	job.mu.Lock()
+	now := time.Now().UTC()
+	job.FinishedAt, job.Result = &now, result
+	switch {
+	case job.ctx.Err() != nil:
+		job.Status = jobCancelled
+	case err != nil:
+		job.Status, job.Error = jobFailed, err.Error()
+	default:
+		job.Status = jobCompleted
+	}
+	job.notifyLocked()
+	job.mu.Unlock()
+	job.cancel()
+
+	t.mu.Lock()
+	t.finished = append(t.finished, job.ID)
+	for len(t.finished) > maxFinishedJobs {
+		delete(t.jobs, t.finished[0])
+		t.finished = t.finished[1:]
+	}
+	t.mu.Unlock()
+}
+
+// testRunsHandler serves the test jobs:
+//
+//	GET    /api/test/runs             queued, running and recently finished runs
+//	GET    /api/test/runs/{id}        status, output so far and result of a run
+//	GET    /api/test/runs/{id}/events the output as Server-Sent Events
+//	DELETE /api/test/runs/{id}        cancel a run
+func (s *Server) testRunsHandler(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(strings.TrimPrefix(r.URL.Path, "/api/test/runs"))
+
+	if len(parts) == 0 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		states := []testRunStatus{}
+		for _, job := range s.jobs.list() {
+			state, _, _ := job.snapshot(0)
+			state.Result = nil
+			states = append(states, state)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(states)
+		return
+	}
+
+	job := s.jobs.get(parts[0])
+	if job == nil || len(parts) > 2 || (len(parts) == 2 && parts[1] != "events") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.streamTestRun(w, r, job)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		state, output, _ := job.snapshot(0)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			testRunStatus
+			Output string `json:"output"`
+		}{state, string(output)})
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if !s.jobs.cancel(job) {
+			http.Error(w, "Test run has already finished", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": job.ID, "status": "cancelling"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// streamTestRun sends the output of a run as "output" events, each a JSON
+// string, as it is produced; a "status" event whenever the run's status
+// changes; and a final "done" event with the finished run
+func (s *Server) streamTestRun(w http.ResponseWriter, r *http.Request, job *testJob) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event string, data interface{}) {
+		encoded, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+	}
+
+	offset := 0
+	lastStatus := ""
+	for {
+		state, output, changed := job.snapshot(offset)
+		if state.Status != lastStatus && !state.finished() {
+			send("status", state)
+		}
+		lastStatus = state.Status
+		if !state.finished() {
+			// A character split across writes is sent once it is complete
+			output = output[:completeRunes(output)]
+		}
+		if len(output) > 0 {
+			send("output", string(output))
+			offset += len(output)
+		}
+		if state.finished() {
+			send("done", state)
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// completeRunes returns the length of b without a UTF-8 encoded character
+// that is cut off at its end
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
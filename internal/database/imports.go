@@ -0,0 +1,100 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ImportCounts tallies what one import did to one kind of entity
+type ImportCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Pruned  int `json:"pruned,omitempty"`
+}
+
+// Import is one RTM file imported into a project
+type Import struct {
+	ID          string                   `json:"id"`
+	ProjectID   string                   `json:"project_id"`
+	ProjectKey  string                   `json:"project_key"`
+	Filename    string                   `json:"filename"`
+	ContentHash string                   `json:"content_hash"`
+	GeneratedBy string                   `json:"generated_by,omitempty"`
+	GeneratedAt string                   `json:"generated_at,omitempty"`
+	Mode        string                   `json:"mode"`
+	Prune       string                   `json:"prune,omitempty"`
+	Counts      map[string]*ImportCounts `json:"counts"`
+	DurationMs  int64                    `json:"duration_ms"`
+	ImportedAt  string                   `json:"imported_at"`
+	// Requirements is how many requirements this import was the last to touch
+	Requirements int `json:"requirements"`
+}
+
+// Created returns the number of created entities of one kind
+func (i *Import) Created(entity string) int {
+	if c, ok := i.Counts[entity]; ok {
+		return c.Created
+	}
+	return 0
+}
+
+// Updated returns the number of updated entities of one kind
+func (i *Import) Updated(entity string) int {
+	if c, ok := i.Counts[entity]; ok {
+		return c.Updated
+	}
+	return 0
+}
+
+// ShortHash returns the first 12 characters of the content hash
+func (i *Import) ShortHash() string {
+	if len(i.ContentHash) > 12 {
+		return i.ContentHash[:12]
+	}
+	return i.ContentHash
+}
+
+// ListImports returns the import history, newest first. An empty projectKey
+// lists every project; limit <= 0 means no limit.
+func (db *DB) ListImports(projectKey string, limit int) ([]*Import, error) {
+	query := `
+		SELECT i.id, i.project_id, p.project_key, i.filename, i.content_hash,
+			COALESCE(i.generated_by, ''), COALESCE(i.generated_at, ''), i.mode, COALESCE(i.prune, ''),
+			COALESCE(i.counts, '{}'), COALESCE(i.duration_ms, 0), i.imported_at,
+			(SELECT COUNT(*) FROM requirements r WHERE r.last_import_id = i.id)
+		FROM imports i
+		JOIN projects p ON i.project_id = p.id`
+	var args []interface{}
+	if projectKey != "" {
+		query += " WHERE p.project_key = ?"
+		args = append(args, projectKey)
+	}
+	query += " ORDER BY i.imported_at DESC, i.rowid DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []*Import
+	for rows.Next() {
+		var imp Import
+		var countsJSON string
+		err := rows.Scan(&imp.ID, &imp.ProjectID, &imp.ProjectKey, &imp.Filename, &imp.ContentHash,
+			&imp.GeneratedBy, &imp.GeneratedAt, &imp.Mode, &imp.Prune,
+			&countsJSON, &imp.DurationMs, &imp.ImportedAt, &imp.Requirements)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan import: %w", err)
+		}
+		if err := json.Unmarshal([]byte(countsJSON), &imp.Counts); err != nil {
+			return nil, fmt.Errorf("failed to parse import counts: %w", err)
+		}
+		imports = append(imports, &imp)
+	}
+
+	return imports, rows.Err()
+}
@@ -13,6 +13,8 @@ CREATE TABLE projects (
     repository_url TEXT,
     version TEXT,
     status TEXT DEFAULT 'active', -- active, archived, deprecated
+    test_runners TEXT, -- test runner configuration (YAML); overrides the checkout's .tracevibe.yaml
+    source_root TEXT, -- local checkout of the project's source code
     created_at TEXT DEFAULT (datetime('now')),
     updated_at TEXT DEFAULT (datetime('now'))
 );
@@ -39,6 +41,10 @@ CREATE TABLE system_components (
     technology TEXT, -- 'Go', 'React', 'PostgreSQL'
     description TEXT,
     tags TEXT, -- JSON array of tags like '["data-ingestion", "exchange", "go"]'
+    path TEXT, -- directory of the component in the checkout, e.g. 'frontend'
+    base_path TEXT, -- directory its file paths are relative to, when not the checkout root
+    source TEXT DEFAULT 'manual', -- 'manual' (UI), 'import' (RTM file)
+    pruned_at TEXT, -- set when pruned in mark mode: absent from the last import
     created_at TEXT DEFAULT (datetime('now')),
     UNIQUE(project_id, component_key)
 );
@@ -72,6 +78,9 @@ CREATE TABLE requirements (
     priority TEXT DEFAULT 'medium', -- 'low', 'medium', 'high', 'critical'
     status TEXT DEFAULT 'not_started', -- 'not_started', 'in_progress', 'completed', 'blocked'
     acceptance_criteria TEXT, -- JSON array as text
+    source TEXT DEFAULT 'manual', -- 'manual' (UI), 'import' (RTM file)
+    pruned_at TEXT, -- set when pruned in mark mode: absent from the last import
+    last_import_id TEXT REFERENCES imports(id) ON DELETE SET NULL, -- import that last created, updated or pruned it
     created_at TEXT DEFAULT (datetime('now')),
     updated_at TEXT DEFAULT (datetime('now')),
     UNIQUE(project_id, requirement_key)
@@ -86,6 +95,7 @@ CREATE TABLE implementations (
     functions TEXT, -- JSON array as text
     line_ranges TEXT, -- JSON array as text like ['10-25', '45-60']
     components TEXT, -- For frontend: component names as JSON array
+    source TEXT DEFAULT 'manual', -- 'manual' (UI), 'import' (RTM file), 'scan' (tracevibe scan)
     created_at TEXT DEFAULT (datetime('now')),
     updated_at TEXT DEFAULT (datetime('now'))
 );
@@ -99,6 +109,8 @@ CREATE TABLE api_endpoints (
     handler_file TEXT,
     handler_function TEXT,
     description TEXT,
+    source TEXT DEFAULT 'manual', -- 'manual' (UI), 'import' (RTM file)
+    pruned_at TEXT, -- set when pruned in mark mode: absent from the last import
     created_at TEXT DEFAULT (datetime('now')),
     UNIQUE(project_id, method, path)
 );
@@ -135,6 +147,33 @@ CREATE TABLE test_cases (
     UNIQUE(test_file_id, test_name)
 );
 
+-- Test runs - one execution of a project's tests from the web UI, or one ingested test report
+CREATE TABLE test_runs (
+    id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    component_key TEXT, -- component whose tests were run, NULL for a whole report
+    requirement_key TEXT, -- requirement whose subtree was run, NULL otherwise
+    source TEXT NOT NULL, -- 'runner' or e.g. 'junit:report.xml'
+    git_commit TEXT,
+    environment TEXT, -- where the tests ran, e.g. 'ci' or 'build-host linux/amd64'
+    passed INTEGER DEFAULT 0,
+    failed INTEGER DEFAULT 0,
+    errors INTEGER DEFAULT 0,
+    skipped INTEGER DEFAULT 0,
+    duration_ms INTEGER,
+    started_at TEXT DEFAULT (datetime('now'))
+);
+
+-- Outcome of a test case in a test run
+CREATE TABLE test_case_results (
+    id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+    run_id TEXT NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    test_case_id TEXT NOT NULL REFERENCES test_cases(id) ON DELETE CASCADE,
+    status TEXT NOT NULL, -- 'passed', 'failed', 'error', 'skipped'
+    duration_ms INTEGER,
+    message TEXT -- failure or skip message
+);
+
 -- Links test cases to requirements (with hierarchical mapping)
 -- System tests -> Scope, Acceptance tests -> User Stories, Unit tests -> Tech Specs
 CREATE TABLE requirement_test_coverage (
@@ -142,6 +181,8 @@ CREATE TABLE requirement_test_coverage (
     requirement_id TEXT NOT NULL REFERENCES requirements(id) ON DELETE CASCADE,
     test_case_id TEXT NOT NULL REFERENCES test_cases(id) ON DELETE CASCADE,
     coverage_type TEXT, -- 'requirement', 'implementation', 'integration'
+    source TEXT DEFAULT 'manual', -- 'manual' (UI), 'import' (RTM file), 'scan' (tracevibe scan)
+    pruned_at TEXT, -- set when pruned in mark mode: absent from the last import
     created_at TEXT DEFAULT (datetime('now')),
     UNIQUE(requirement_id, test_case_id)
 );
@@ -180,6 +221,21 @@ CREATE TABLE requirement_changes (
     created_at TEXT DEFAULT (datetime('now'))
 );
 
+-- Import history: one row per RTM file imported into a project
+CREATE TABLE imports (
+    id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_hash TEXT NOT NULL, -- sha256 of the file contents
+    generated_by TEXT, -- metadata.generated_by, usually the LLM that wrote the file
+    generated_at TEXT, -- metadata.generated_at
+    mode TEXT NOT NULL, -- 'update', 'overwrite', 'merge'
+    prune TEXT, -- 'delete', 'mark' or NULL
+    counts TEXT, -- JSON object: entity -> {"created", "updated", "pruned"}
+    duration_ms INTEGER,
+    imported_at TEXT DEFAULT (datetime('now'))
+);
+
 -- Indexes for performance
 CREATE INDEX idx_requirements_project_id ON requirements(project_id);
 CREATE INDEX idx_requirements_component_id ON requirements(component_id);
@@ -190,9 +246,13 @@ CREATE INDEX idx_requirements_status ON requirements(status);
 CREATE INDEX idx_requirements_type ON requirements(requirement_type);
 CREATE INDEX idx_implementations_requirement_id ON implementations(requirement_id);
 CREATE INDEX idx_implementations_layer ON implementations(layer);
+CREATE INDEX idx_imports_project_id ON imports(project_id);
 CREATE INDEX idx_api_endpoints_project_id ON api_endpoints(project_id);
 CREATE INDEX idx_test_files_project_id ON test_files(project_id);
 CREATE INDEX idx_test_cases_test_file_id ON test_cases(test_file_id);
+CREATE INDEX idx_test_runs_project_id ON test_runs(project_id);
+CREATE INDEX idx_test_case_results_run_id ON test_case_results(run_id);
+CREATE INDEX idx_test_case_results_test_case_id ON test_case_results(test_case_id);
 CREATE INDEX idx_frontend_components_project_id ON frontend_components(project_id);
 
 -- Views for common queries
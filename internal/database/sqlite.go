@@ -85,9 +85,36 @@ func (db *DB) InitSchema() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	// schema.sql predates some columns and tables; bring fresh databases up to date too
+	db.runMigrations()
 	return nil
 }
 
+func (db *DB) columnExists(table, column string) bool {
+	var count int
+	err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = ?", table), column).Scan(&count)
+	return err == nil && count > 0
+}
+
+// backfillSource guesses the source of records that predate the source column.
+// Requirements created in the UI have a 'created' audit entry; everything else
+// the UI cannot create came from an import.
+func (db *DB) backfillSource(table string) {
+	switch table {
+	case "requirements":
+		db.Exec(`UPDATE requirements SET source = 'import' WHERE id NOT IN
+			(SELECT requirement_id FROM requirement_changes WHERE change_type = 'created')`)
+	case "system_components":
+		db.Exec(`UPDATE system_components SET source = 'import' WHERE id IN
+			(SELECT component_id FROM requirements WHERE source = 'import')`)
+	case "api_endpoints", "requirement_test_coverage":
+		db.Exec(fmt.Sprintf("UPDATE %s SET source = 'import'", table))
+	case "implementations":
+		// Only scans record line ranges
+		db.Exec("UPDATE implementations SET source = CASE WHEN line_ranges IS NULL THEN 'import' ELSE 'scan' END")
+	}
+}
+
 // runMigrations adds any missing columns to existing databases
 func (db *DB) runMigrations() {
 	// Check if tags column exists in system_components
@@ -106,6 +133,94 @@ func (db *DB) runMigrations() {
 		db.Exec("ALTER TABLE projects ADD COLUMN project_context TEXT")
 	}
 
+	// Per-project test runner configuration
+	if !db.columnExists("projects", "test_runners") {
+		db.Exec("ALTER TABLE projects ADD COLUMN test_runners TEXT")
+	}
+
+	// Where the source code of each project and component is checked out
+	if !db.columnExists("projects", "source_root") {
+		db.Exec("ALTER TABLE projects ADD COLUMN source_root TEXT")
+	}
+	for _, column := range []string{"path", "base_path"} {
+		if !db.columnExists("system_components", column) {
+			db.Exec(fmt.Sprintf("ALTER TABLE system_components ADD COLUMN %s TEXT", column))
+		}
+	}
+
+	// Track where records came from so imports can prune only what they created
+	for _, table := range []string{"requirements", "system_components", "api_endpoints", "requirement_test_coverage"} {
+		if !db.columnExists(table, "source") {
+			db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN source TEXT DEFAULT 'manual'", table))
+			db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN pruned_at TEXT", table))
+			db.backfillSource(table)
+		}
+	}
+	// Implementations are replaced, not pruned, so they have no pruned_at
+	if !db.columnExists("implementations", "source") {
+		db.Exec("ALTER TABLE implementations ADD COLUMN source TEXT DEFAULT 'manual'")
+		db.backfillSource("implementations")
+	}
+
+	// Import history
+	var importsTableCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='imports'").Scan(&importsTableCount)
+	if err == nil && importsTableCount == 0 {
+		db.Exec(`CREATE TABLE imports (
+			id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+			project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			filename TEXT NOT NULL,
+			content_hash TEXT NOT NULL,
+			generated_by TEXT,
+			generated_at TEXT,
+			mode TEXT NOT NULL,
+			prune TEXT,
+			counts TEXT,
+			duration_ms INTEGER,
+			imported_at TEXT DEFAULT (datetime('now'))
+		)`)
+		db.Exec("CREATE INDEX idx_imports_project_id ON imports(project_id)")
+	}
+	if !db.columnExists("requirements", "last_import_id") {
+		db.Exec("ALTER TABLE requirements ADD COLUMN last_import_id TEXT REFERENCES imports(id) ON DELETE SET NULL")
+	}
+
+	// Test run history and per-test outcomes
+	var runsTableCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='test_runs'").Scan(&runsTableCount)
+	if err == nil && runsTableCount == 0 {
+		db.Exec(`CREATE TABLE test_runs (
+			id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+			project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			component_key TEXT,
+			requirement_key TEXT,
+			source TEXT NOT NULL,
+			git_commit TEXT,
+			environment TEXT,
+			passed INTEGER DEFAULT 0,
+			failed INTEGER DEFAULT 0,
+			errors INTEGER DEFAULT 0,
+			skipped INTEGER DEFAULT 0,
+			duration_ms INTEGER,
+			started_at TEXT DEFAULT (datetime('now'))
+		)`)
+		db.Exec("CREATE INDEX idx_test_runs_project_id ON test_runs(project_id)")
+	}
+	var resultsTableCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='test_case_results'").Scan(&resultsTableCount)
+	if err == nil && resultsTableCount == 0 {
+		db.Exec(`CREATE TABLE test_case_results (
+			id TEXT PRIMARY KEY DEFAULT (hex(randomblob(16))),
+			run_id TEXT NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+			test_case_id TEXT NOT NULL REFERENCES test_cases(id) ON DELETE CASCADE,
+			status TEXT NOT NULL,
+			duration_ms INTEGER,
+			message TEXT
+		)`)
+		db.Exec("CREATE INDEX idx_test_case_results_test_case_id ON test_case_results(test_case_id)")
+	}
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_test_case_results_run_id ON test_case_results(run_id)")
+
 	// Check if tool_settings table exists
 	var settingsTableCount int
 	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='tool_settings'").Scan(&settingsTableCount)
@@ -225,12 +340,12 @@ This methodology ensures that code generated from TraceVibe RTM follows consiste
 
 func (db *DB) GetProjectByKey(projectKey string) (*Project, error) {
 	var p Project
-	query := `SELECT id, project_key, name, description, repository_url, version, status, project_context, created_at, updated_at
+	query := `SELECT id, project_key, name, description, repository_url, version, status, project_context, source_root, created_at, updated_at
 			  FROM projects WHERE project_key = ?`
 
 	err := db.QueryRow(query, projectKey).Scan(
 		&p.ID, &p.ProjectKey, &p.Name, &p.Description,
-		&p.RepositoryURL, &p.Version, &p.Status, &p.ProjectContext, &p.CreatedAt, &p.UpdatedAt,
+		&p.RepositoryURL, &p.Version, &p.Status, &p.ProjectContext, &p.SourceRoot, &p.CreatedAt, &p.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -249,10 +364,10 @@ func (db *DB) Begin() (Tx, error) {
 }
 
 func (db *DB) CreateProject(p *Project) error {
-	query := `INSERT INTO projects (project_key, name, description, repository_url, version, status, project_context)
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO projects (project_key, name, description, repository_url, version, status, project_context, source_root)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := db.Exec(query, p.ProjectKey, p.Name, p.Description, p.RepositoryURL, p.Version, p.Status, p.ProjectContext)
+	_, err := db.Exec(query, p.ProjectKey, p.Name, p.Description, p.RepositoryURL, p.Version, p.Status, p.ProjectContext, p.SourceRoot)
 	return err
 }
 
@@ -286,6 +401,62 @@ func (db *DB) SaveProjectContext(projectKey, context string) error {
 	return err
 }
 
+// SaveSourceRoot sets where the source code of a project is checked out; ""
+// removes it
+func (db *DB) SaveSourceRoot(projectID, root string) error {
+	var value interface{}
+	if root != "" {
+		value = root
+	}
+	_, err := db.Exec(`UPDATE projects SET source_root = ?, updated_at = datetime('now') WHERE id = ?`, value, projectID)
+	return err
+}
+
+// ComponentPath locates a component in its project's checkout
+type ComponentPath struct {
+	Path     string `json:"path,omitempty"`      // directory of the component
+	BasePath string `json:"base_path,omitempty"` // directory its file paths are relative to
+}
+
+// ListComponentPaths returns the paths of a project's components by component key
+func (db *DB) ListComponentPaths(projectID string) (map[string]ComponentPath, error) {
+	rows, err := db.Query(`SELECT component_key, COALESCE(path, ''), COALESCE(base_path, '')
+		FROM system_components WHERE project_id = ?`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list component paths: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make(map[string]ComponentPath)
+	for rows.Next() {
+		var key string
+		var path ComponentPath
+		if err := rows.Scan(&key, &path.Path, &path.BasePath); err != nil {
+			return nil, fmt.Errorf("failed to scan component path: %w", err)
+		}
+		paths[key] = path
+	}
+	return paths, rows.Err()
+}
+
+// GetTestRunners returns the test runner configuration saved for a project,
+// or "" when it has none
+func (db *DB) GetTestRunners(projectID string) (string, error) {
+	var config sql.NullString
+	err := db.QueryRow(`SELECT test_runners FROM projects WHERE id = ?`, projectID).Scan(&config)
+	return config.String, err
+}
+
+// SaveTestRunners saves the test runner configuration of a project; "" removes it
+func (db *DB) SaveTestRunners(projectID, config string) error {
+	var value interface{}
+	if config != "" {
+		value = config
+	}
+	_, err := db.Exec(`UPDATE projects SET test_runners = ?, updated_at = datetime('now') WHERE id = ?`, value, projectID)
+	return err
+}
+
 func getDefaultMethodology() string {
 	return `# TraceVibe Code Generation Methodology
 
@@ -396,6 +567,7 @@ type Project struct {
 	Version        *string `json:"version"`
 	Status         string  `json:"status"`
 	ProjectContext *string `json:"project_context"`
+	SourceRoot     *string `json:"source_root"` // local checkout of the source code
 	CreatedAt      string  `json:"created_at"`
 	UpdatedAt      string  `json:"updated_at"`
 }
\ No newline at end of file
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Test outcomes recorded in test_case_results
+const (
+	TestPassed  = "passed"
+	TestFailed  = "failed"
+	TestError   = "error" // the test could not run to completion, e.g. a panic or setup failure
+	TestSkipped = "skipped"
+)
+
+// TestCaseRef is a test case of a project with the file it lives in
+type TestCaseRef struct {
+	ID       string
+	FilePath string
+	TestName string
+}
+
+// TestRun is one execution of a project's tests, or one ingested test report
+type TestRun struct {
+	ID             string `json:"id"`
+	ProjectID      string `json:"project_id"`
+	ComponentKey   string `json:"component_key,omitempty"`
+	RequirementKey string `json:"requirement_key,omitempty"` // set when only a requirement's subtree was run
+	Source         string `json:"source"`                    // "runner" or e.g. "junit:report.xml"
+	GitCommit      string `json:"git_commit,omitempty"`
+	Environment    string `json:"environment,omitempty"`
+	Passed         int    `json:"passed"`
+	Failed         int    `json:"failed"`
+	Errors         int    `json:"errors"`
+	Skipped        int    `json:"skipped"`
+	DurationMs     int64  `json:"duration_ms"`
+	StartedAt      string `json:"started_at"`
+}
+
+// ShortCommit returns the first 12 characters of the git commit
+func (r *TestRun) ShortCommit() string {
+	if len(r.GitCommit) > 12 {
+		return r.GitCommit[:12]
+	}
+	return r.GitCommit
+}
+
+// TestCaseResult is the outcome of one test case in one test run
+type TestCaseResult struct {
+	ID         string `json:"id"`
+	RunID      string `json:"run_id"`
+	TestCaseID string `json:"test_case_id"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Message    string `json:"message,omitempty"` // failure or skip message
+}
+
+// TestCaseRun is a test case result with the run it came from
+type TestCaseRun struct {
+	TestCaseResult
+	Source      string `json:"source"`
+	GitCommit   string `json:"git_commit,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	StartedAt   string `json:"started_at"`
+}
+
+// TestCaseStatus is a test case linked to a requirement with its latest
+// result, if it has been run
+type TestCaseStatus struct {
+	TestCaseID string       `json:"test_case_id"`
+	FilePath   string       `json:"file_path"`
+	TestName   string       `json:"test_name"`
+	Last       *TestCaseRun `json:"last,omitempty"`
+}
+
+// ListTestCases returns the test cases of a project, or only those linked to
+// the requirements of one component when componentKey is set
+func (db *DB) ListTestCases(projectID, componentKey string) ([]TestCaseRef, error) {
+	query := `
+		SELECT tc.id, tf.file_path, tc.test_name
+		FROM test_cases tc
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE tf.project_id = ?`
+	args := []interface{}{projectID}
+	if componentKey != "" {
+		query += `
+			AND tc.id IN (
+				SELECT rtc.test_case_id
+				FROM requirement_test_coverage rtc
+				JOIN requirements r ON rtc.requirement_id = r.id
+				JOIN system_components c ON r.component_id = c.id
+				WHERE c.component_key = ? AND c.project_id = ?)`
+		args = append(args, componentKey, projectID)
+	}
+	query += " ORDER BY tf.file_path, tc.test_name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+	defer rows.Close()
+
+	var testCases []TestCaseRef
+	for rows.Next() {
+		var tc TestCaseRef
+		if err := rows.Scan(&tc.ID, &tc.FilePath, &tc.TestName); err != nil {
+			return nil, fmt.Errorf("failed to scan test case: %w", err)
+		}
+		testCases = append(testCases, tc)
+	}
+	return testCases, rows.Err()
+}
+
+// ListRequirementTestCases returns the test cases linked to a requirement or
+// any of its descendants
+func (db *DB) ListRequirementTestCases(requirementID string) ([]TestCaseRef, error) {
+	rows, err := db.Query(`
+		WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION
+			SELECT r.id FROM requirements r JOIN subtree s ON r.parent_requirement_id = s.id
+		)
+		SELECT DISTINCT tc.id, tf.file_path, tc.test_name
+		FROM requirement_test_coverage rtc
+		JOIN subtree s ON rtc.requirement_id = s.id
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		ORDER BY tf.file_path, tc.test_name`, requirementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+	defer rows.Close()
+
+	var testCases []TestCaseRef
+	for rows.Next() {
+		var tc TestCaseRef
+		if err := rows.Scan(&tc.ID, &tc.FilePath, &tc.TestName); err != nil {
+			return nil, fmt.Errorf("failed to scan test case: %w", err)
+		}
+		testCases = append(testCases, tc)
+	}
+	return testCases, rows.Err()
+}
+
+// RecordTestRun stores a test run and its results in one transaction. The
+// run's ID and StartedAt are set when they are empty; the run's counts are
+// taken from the results.
+func (db *DB) RecordTestRun(run *TestRun, results []TestCaseResult) error {
+	run.Passed, run.Failed, run.Errors, run.Skipped = 0, 0, 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case TestPassed:
+			run.Passed++
+		case TestFailed:
+			run.Failed++
+		case TestError:
+			run.Errors++
+		case TestSkipped:
+			run.Skipped++
+		}
+	}
+	if run.StartedAt == "" {
+		run.StartedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO test_runs (id, project_id, component_key, requirement_key, source, git_commit, environment,
+			passed, failed, errors, skipped, duration_ms, started_at)
+		VALUES (COALESCE(NULLIF(?, ''), hex(randomblob(16))), ?, NULLIF(?, ''), NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		run.ID, run.ProjectID, run.ComponentKey, run.RequirementKey, run.Source, run.GitCommit, run.Environment,
+		run.Passed, run.Failed, run.Errors, run.Skipped, run.DurationMs, run.StartedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record test run: %w", err)
+	}
+
+	for _, result := range results {
+		_, err := tx.Exec(`
+			INSERT INTO test_case_results (run_id, test_case_id, status, duration_ms, message)
+			VALUES (?, ?, ?, ?, ?)`,
+			run.ID, result.TestCaseID, result.Status, result.DurationMs, result.Message)
+		if err != nil {
+			return fmt.Errorf("failed to record test result: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTestRuns returns the test runs of a project, newest first; limit <= 0
+// means no limit
+func (db *DB) ListTestRuns(projectID string, limit int) ([]*TestRun, error) {
+	query := `
+		SELECT id, project_id, COALESCE(component_key, ''), COALESCE(requirement_key, ''), source, COALESCE(git_commit, ''),
+			COALESCE(environment, ''), passed, failed, errors, skipped, COALESCE(duration_ms, 0), started_at
+		FROM test_runs
+		WHERE project_id = ?
+		ORDER BY started_at DESC, rowid DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*TestRun
+	for rows.Next() {
+		var run TestRun
+		err := rows.Scan(&run.ID, &run.ProjectID, &run.ComponentKey, &run.RequirementKey, &run.Source, &run.GitCommit,
+			&run.Environment, &run.Passed, &run.Failed, &run.Errors, &run.Skipped, &run.DurationMs, &run.StartedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan test run: %w", err)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// testCaseRunColumns selects a TestCaseRun from test_case_results r joined
+// with test_runs tr
+const testCaseRunColumns = `r.id, r.run_id, r.test_case_id, r.status, COALESCE(r.duration_ms, 0), COALESCE(r.message, ''),
+	tr.source, COALESCE(tr.git_commit, ''), COALESCE(tr.environment, ''), tr.started_at`
+
+func scanTestCaseRun(scanner interface{ Scan(...interface{}) error }) (*TestCaseRun, error) {
+	var result TestCaseRun
+	err := scanner.Scan(&result.ID, &result.RunID, &result.TestCaseID, &result.Status, &result.DurationMs, &result.Message,
+		&result.Source, &result.GitCommit, &result.Environment, &result.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan test result: %w", err)
+	}
+	return &result, nil
+}
+
+// TestCaseHistory returns the results of a test case, newest first; limit <= 0
+// means no limit
+func (db *DB) TestCaseHistory(testCaseID string, limit int) ([]*TestCaseRun, error) {
+	query := `
+		SELECT ` + testCaseRunColumns + `
+		FROM test_case_results r
+		JOIN test_runs tr ON r.run_id = tr.id
+		WHERE r.test_case_id = ?
+		ORDER BY tr.started_at DESC, tr.rowid DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := db.Query(query, testCaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*TestCaseRun
+	for rows.Next() {
+		result, err := scanTestCaseRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, result)
+	}
+	return history, rows.Err()
+}
+
+// LatestTestResults returns the test cases linked to each requirement of a
+// project, keyed by requirement ID, with the latest result of each
+func (db *DB) LatestTestResults(projectID string) (map[string][]TestCaseStatus, error) {
+	rows, err := db.Query(`
+		SELECT rtc.requirement_id, tc.id, tf.file_path, tc.test_name
+		FROM requirement_test_coverage rtc
+		JOIN requirements req ON rtc.requirement_id = req.id
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE req.project_id = ?
+		ORDER BY tf.file_path, tc.test_name`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test coverage: %w", err)
+	}
+	statuses := make(map[string][]TestCaseStatus)
+	for rows.Next() {
+		var requirementID string
+		var status TestCaseStatus
+		if err := rows.Scan(&requirementID, &status.TestCaseID, &status.FilePath, &status.TestName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan test coverage: %w", err)
+		}
+		statuses[requirementID] = append(statuses[requirementID], status)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The newest result of every test case of the project
+	rows, err = db.Query(`
+		SELECT `+testCaseRunColumns+`
+		FROM test_case_results r
+		JOIN test_runs tr ON r.run_id = tr.id
+		WHERE tr.project_id = ?
+		ORDER BY tr.started_at, tr.rowid`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test results: %w", err)
+	}
+	defer rows.Close()
+	latest := make(map[string]*TestCaseRun)
+	for rows.Next() {
+		result, err := scanTestCaseRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		latest[result.TestCaseID] = result
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, tests := range statuses {
+		for i := range tests {
+			tests[i].Last = latest[tests[i].TestCaseID]
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// Document is a project as shown by the HTML and Markdown exports
+type Document struct {
+	Project      *database.Project
+	Components   []ComponentSummary
+	Requirements []RequirementTree
+	Stats        Stats
+	ExportDate   string
+	// Diagrams holds a Mermaid flowchart per component key, when requested
+	Diagrams map[string]string
+}
+
+// Stats are the totals shown at the top of an export
+type Stats struct {
+	TotalComponents   int
+	TotalRequirements int
+	TotalScopes       int
+	TotalUserStories  int
+	TotalTechSpecs    int
+	TotalTestCases    int
+}
+
+// Load reads a project for the HTML and Markdown exports, keeping the
+// requirements selected by the filter and the components they belong to
+func Load(db *database.DB, projectKey string, filter Filter) (*Document, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+	selected, err := selectRequirements(db, project.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	allComponents, err := Components(db, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading components: %w", err)
+	}
+	var components []ComponentSummary
+	for _, component := range allComponents {
+		if selected.components[component.ComponentKey] {
+			components = append(components, component)
+		}
+	}
+
+	requirements, err := RequirementsTree(db, projectKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("error loading requirements: %w", err)
+	}
+	requirements = selected.prune(requirements)
+
+	doc := &Document{
+		Project:      project,
+		Components:   components,
+		Requirements: requirements,
+		ExportDate:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	doc.Stats.TotalComponents = len(components)
+	for _, req := range requirements {
+		CountRequirementsByType(req, &doc.Stats.TotalScopes, &doc.Stats.TotalUserStories, &doc.Stats.TotalTechSpecs)
+		doc.Stats.TotalTestCases += CountTestCases(req)
+	}
+	doc.Stats.TotalRequirements = doc.Stats.TotalScopes + doc.Stats.TotalUserStories + doc.Stats.TotalTechSpecs
+	return doc, nil
+}
@@ -0,0 +1,208 @@
+// Package export renders the requirements of a project stored in the database
+// in the formats offered for download by the web UI and 'tracevibe export'.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Filter narrows an export down to some requirements. Each field but Root
+// holds one value or a comma-separated list, compared case-insensitively;
+// empty fields match everything.
+type Filter struct {
+	Component string // component key
+	Tag       string // component tag
+	Status    string // requirement status
+	Type      string // requirement type: scope, user_story or tech_spec
+	Root      string // requirement key: only it and its descendants
+}
+
+// Options are the filter plus settings that only some formats use
+type Options struct {
+	Filter
+	// Mermaid embeds a Mermaid diagram of each component in the Markdown export
+	Mermaid bool
+}
+
+// String describes the filter for people, e.g. "component COMP-001, status
+// implemented"; it is empty when the filter keeps everything
+func (f Filter) String() string {
+	var parts []string
+	for _, field := range []struct{ name, value string }{
+		{"component", f.Component}, {"tag", f.Tag}, {"status", f.Status}, {"type", f.Type}, {"subtree of", f.Root},
+	} {
+		if field.value != "" {
+			parts = append(parts, field.name+" "+field.value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// selectsRequirements reports whether the filter looks at requirements
+// rather than only at components
+func (f Filter) selectsRequirements() bool {
+	return f.Status != "" || f.Type != "" || f.Root != ""
+}
+
+// selection is what a filter keeps of a project. Tree-shaped exports keep the
+// ancestors of every matching requirement too, so each one still hangs under
+// its scope and an RTM file stays valid for re-import; flat exports keep only
+// the matches.
+type selection struct {
+	matched    map[string]bool // requirement IDs
+	kept       map[string]bool // requirement IDs, matches and their ancestors
+	components map[string]bool // component keys
+}
+
+// selectRequirements applies a filter to the requirements of a project
+func selectRequirements(db *database.DB, projectID string, filter Filter) (*selection, error) {
+	type requirement struct {
+		id, parentID, key, reqType, status, componentKey string
+	}
+
+	componentTags := make(map[string][]string)
+	rows, err := db.Query(`
+		SELECT component_key, COALESCE(tags, '[]')
+		FROM system_components
+		WHERE project_id = ?`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load components: %w", err)
+	}
+	for rows.Next() {
+		var key, tagsJSON string
+		if err := rows.Scan(&key, &tagsJSON); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		componentTags[key], _ = models.UnmarshalStringSliceJSON(tagsJSON)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT r.id, COALESCE(r.parent_requirement_id, ''), r.requirement_key, r.requirement_type,
+			COALESCE(r.status, ''), COALESCE(c.component_key, '')
+		FROM requirements r
+		LEFT JOIN system_components c ON r.component_id = c.id
+		WHERE r.project_id = ?`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirements: %w", err)
+	}
+	var requirements []requirement
+	for rows.Next() {
+		var req requirement
+		if err := rows.Scan(&req.id, &req.parentID, &req.key, &req.reqType, &req.status, &req.componentKey); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		requirements = append(requirements, req)
+	}
+	rows.Close()
+
+	parents := make(map[string]string, len(requirements))
+	rootID := ""
+	for _, req := range requirements {
+		parents[req.id] = req.parentID
+		if filter.Root != "" && strings.EqualFold(req.key, filter.Root) {
+			rootID = req.id
+		}
+	}
+	if filter.Root != "" && rootID == "" {
+		return nil, fmt.Errorf("requirement '%s' not found", filter.Root)
+	}
+	// underRoot walks up from a requirement, guarding against parent cycles
+	underRoot := func(id string) bool {
+		for depth := 0; id != "" && depth <= len(parents); depth++ {
+			if id == rootID {
+				return true
+			}
+			id = parents[id]
+		}
+		return false
+	}
+
+	s := &selection{
+		matched:    make(map[string]bool),
+		kept:       make(map[string]bool),
+		components: make(map[string]bool),
+	}
+	for _, req := range requirements {
+		if !matches(filter.Component, req.componentKey) || !matchesAny(filter.Tag, componentTags[req.componentKey]) ||
+			!matches(filter.Status, req.status) || !matches(normalizeType(filter.Type), normalizeType(req.reqType)) ||
+			(rootID != "" && !underRoot(req.id)) {
+			continue
+		}
+		s.matched[req.id] = true
+		s.components[req.componentKey] = true
+		for id := req.id; id != "" && !s.kept[id]; id = parents[id] {
+			s.kept[id] = true
+		}
+	}
+	if !filter.selectsRequirements() {
+		// Components without requirements are still exported
+		for key, tags := range componentTags {
+			if matches(filter.Component, key) && matchesAny(filter.Tag, tags) {
+				s.components[key] = true
+			}
+		}
+	}
+	return s, nil
+}
+
+// prune drops the requirements of a forest that the selection does not keep
+// and recounts what is left
+func (s *selection) prune(requirements []RequirementTree) []RequirementTree {
+	var kept []RequirementTree
+	for _, req := range requirements {
+		if !s.kept[req.ID] {
+			continue
+		}
+		req.Children = s.prune(req.Children)
+		req.UserStoryCount, req.TechSpecCount, req.TestCaseCount = 0, 0, len(req.TestCases)
+		for _, child := range req.Children {
+			switch strings.ToUpper(child.RequirementType) {
+			case "USER_STORY":
+				req.UserStoryCount++
+			case "TECH_SPEC":
+				req.TechSpecCount++
+			}
+			req.TestCaseCount += child.TestCaseCount
+		}
+		kept = append(kept, req)
+	}
+	return kept
+}
+
+// normalizeType lets "tech-spec" and "TECH_SPEC" select tech_spec requirements
+func normalizeType(reqType string) string {
+	return strings.ReplaceAll(reqType, "-", "_")
+}
+
+// matches reports whether value is one of the comma-separated values of field
+func matches(field, value string) bool {
+	if field == "" {
+		return true
+	}
+	for _, want := range strings.Split(field, ",") {
+		if strings.EqualFold(strings.TrimSpace(want), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether any of values is one of the values of field
+func matchesAny(field string, values []string) bool {
+	if field == "" {
+		return true
+	}
+	for _, value := range values {
+		if matches(field, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/reqif"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is one of the export formats
+type Format struct {
+	Name        string // as passed to 'tracevibe export --format'
+	FileSuffix  string // appended to the project key to name downloads
+	ContentType string
+	write       func(w io.Writer, db *database.DB, projectKey string, opts Options) error
+}
+
+// Formats lists every export format
+var Formats = []*Format{
+	{"html", "rtm-export.html", "text/html; charset=utf-8", writeHTML},
+	{"compliance", "rtm-compliance.html", "text/html; charset=utf-8", writeCompliance},
+	{"json", "rtm-export.json", "application/json; charset=utf-8", writeJSON},
+	{"yaml", "rtm-export.yaml", "application/x-yaml; charset=utf-8", writeYAML},
+	{"markdown", "rtm-export.md", "text/markdown; charset=utf-8", writeMarkdown},
+	{"reqif", "rtm-export.reqif", "application/xml; charset=utf-8", writeReqIF},
+	{"dot", "rtm-graph.dot", "text/vnd.graphviz; charset=utf-8", writeDOT},
+	{"mermaid", "rtm-graph.mmd", "text/plain; charset=utf-8", writeMermaid},
+	{"csv", "rtm-matrix.csv", "text/csv; charset=utf-8", writeCSV},
+	{"xlsx", "rtm-matrix.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", writeXLSX},
+}
+
+// LookupFormat finds a format by name; "md", "yml", "gv" and "mmd" are
+// accepted too
+func LookupFormat(name string) (*Format, error) {
+	name = strings.ToLower(name)
+	switch name {
+	case "md":
+		name = "markdown"
+	case "yml":
+		name = "yaml"
+	case "graphviz", "gv":
+		name = "dot"
+	case "mmd":
+		name = "mermaid"
+	}
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		if format.Name == name {
+			return format, nil
+		}
+		names[i] = format.Name
+	}
+	return nil, fmt.Errorf("unknown export format %q (use %s)", name, strings.Join(names, ", "))
+}
+
+// Binary reports whether the format is not text
+func (f *Format) Binary() bool {
+	return f.Name == "xlsx"
+}
+
+// Write exports a project in this format
+func (f *Format) Write(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	return f.write(w, db, projectKey, opts)
+}
+
+func writeHTML(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	doc, err := Load(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteHTML(w, doc)
+}
+
+func writeCompliance(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	report, err := BuildReport(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteReport(w, report, "compliance")
+}
+
+func writeMarkdown(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	doc, err := Load(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	if opts.Mermaid {
+		doc.Diagrams = make(map[string]string)
+		for _, component := range doc.Components {
+			filter := opts.Filter
+			filter.Component = component.ComponentKey
+			graph, err := BuildGraph(db, projectKey, filter)
+			if err != nil {
+				return err
+			}
+			var diagram strings.Builder
+			if err := WriteMermaid(&diagram, graph); err != nil {
+				return err
+			}
+			doc.Diagrams[component.ComponentKey] = diagram.String()
+		}
+	}
+	return WriteMarkdown(w, doc)
+}
+
+func writeJSON(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	rtmData, err := RTM(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.MarshalIndent(rtmData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON: %w", err)
+	}
+	_, err = w.Write(jsonData)
+	return err
+}
+
+func writeYAML(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	rtmData, err := RTM(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	yamlData, err := yaml.Marshal(rtmData)
+	if err != nil {
+		return fmt.Errorf("failed to generate YAML: %w", err)
+	}
+	_, err = w.Write(yamlData)
+	return err
+}
+
+func writeReqIF(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	rtmData, err := RTM(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return reqif.Export(w, rtmData, time.Now())
+}
+
+func writeCSV(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	matrix, err := BuildMatrix(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteMatrixCSV(w, matrix)
+}
+
+func writeXLSX(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	matrix, err := BuildMatrix(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteMatrixXLSX(w, matrix)
+}
+
+func writeDOT(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	graph, err := BuildGraph(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteDOT(w, graph, projectKey)
+}
+
+func writeMermaid(w io.Writer, db *database.DB, projectKey string, opts Options) error {
+	graph, err := BuildGraph(db, projectKey, opts.Filter)
+	if err != nil {
+		return err
+	}
+	return WriteMermaid(w, graph)
+}
@@ -0,0 +1,262 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// Node kinds of a traceability graph
+const (
+	NodeComponent   = "component"
+	NodeRequirement = "requirement"
+	NodeFile        = "file"
+	NodeEndpoint    = "endpoint"
+	NodeTest        = "test"
+)
+
+// Edge kinds of a traceability graph
+const (
+	EdgeContains      = "contains"       // component -> top-level requirement, requirement -> child
+	EdgeImplementedIn = "implemented_in" // requirement -> file
+	EdgeServes        = "serves"         // file -> API endpoint
+	EdgeVerifies      = "verifies"       // test -> requirement
+)
+
+// Graph is the requirement -> implementation file -> test case graph of a
+// project
+type Graph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a component, requirement, file, API endpoint or test. ID is
+// unique within the graph, Component is the key of the component it belongs
+// to, if any.
+type GraphNode struct {
+	ID        string
+	Kind      string
+	Label     string
+	Detail    string
+	Component string
+}
+
+// GraphEdge is a typed edge between two node IDs
+type GraphEdge struct {
+	From string
+	To   string
+	Kind string
+}
+
+// BuildGraph reads the traceability graph of a project, limited to the
+// requirements selected by the filter, their ancestors and their components
+func BuildGraph(db *database.DB, projectKey string, filter Filter) (*Graph, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+	selected, err := selectRequirements(db, project.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+	components, err := Components(db, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading components: %w", err)
+	}
+
+	g := &Graph{}
+	seen := make(map[string]bool)
+	addNode := func(node GraphNode) {
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			g.Nodes = append(g.Nodes, node)
+		}
+	}
+	addEdge := func(from, to, kind string) {
+		g.Edges = append(g.Edges, GraphEdge{From: from, To: to, Kind: kind})
+	}
+
+	files := make(map[string][]string) // file path -> functions implemented in it
+	var walk func(req RequirementTree, componentKey string)
+	walk = func(req RequirementTree, componentKey string) {
+		id := "req:" + req.RequirementKey
+		addNode(GraphNode{ID: id, Kind: NodeRequirement, Label: req.RequirementKey, Detail: req.Title, Component: componentKey})
+		for _, impl := range req.Implementation {
+			fileID := "file:" + impl.FilePath
+			addNode(GraphNode{ID: fileID, Kind: NodeFile, Label: impl.FilePath, Detail: impl.Layer, Component: componentKey})
+			addEdge(id, fileID, EdgeImplementedIn)
+			files[impl.FilePath] = append(files[impl.FilePath], impl.Functions...)
+		}
+		for _, tc := range req.TestCases {
+			testID := "test:" + tc.FilePath + "::" + tc.TestName
+			addNode(GraphNode{ID: testID, Kind: NodeTest, Label: tc.TestName, Detail: tc.FilePath, Component: componentKey})
+			addEdge(testID, id, EdgeVerifies)
+		}
+		for _, child := range req.Children {
+			addEdge(id, "req:"+child.RequirementKey, EdgeContains)
+			walk(child, componentKey)
+		}
+	}
+
+	for _, component := range components {
+		if !selected.components[component.ComponentKey] {
+			continue
+		}
+		requirements, err := RequirementsTree(db, projectKey, component.ComponentKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading requirements: %w", err)
+		}
+		requirements = selected.prune(requirements)
+
+		componentID := "component:" + component.ComponentKey
+		addNode(GraphNode{ID: componentID, Kind: NodeComponent, Label: component.Name, Detail: component.ComponentKey, Component: component.ComponentKey})
+		for _, req := range requirements {
+			addEdge(componentID, "req:"+req.RequirementKey, EdgeContains)
+			walk(req, component.ComponentKey)
+		}
+	}
+
+	// API endpoints, linked to the file whose path or function is their handler
+	rows, err := db.Query(`
+		SELECT method, path, COALESCE(handler_file, ''), COALESCE(handler_function, '')
+		FROM api_endpoints
+		WHERE project_id = ? AND pruned_at IS NULL
+		ORDER BY path, method`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API endpoints: %w", err)
+	}
+	defer rows.Close()
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	unfiltered := filter == Filter{}
+	for rows.Next() {
+		var method, path, handlerFile, handlerFunction string
+		if err := rows.Scan(&method, &path, &handlerFile, &handlerFunction); err != nil {
+			return nil, err
+		}
+		endpointID := "endpoint:" + method + " " + path
+		var handlers []string
+		for _, file := range paths {
+			if handles(file, files[file], handlerFile, handlerFunction) {
+				handlers = append(handlers, file)
+			}
+		}
+		if len(handlers) == 0 && !unfiltered {
+			continue
+		}
+		addNode(GraphNode{ID: endpointID, Kind: NodeEndpoint, Label: method + " " + path, Detail: handlerFunction})
+		for _, file := range handlers {
+			addEdge("file:"+file, endpointID, EdgeServes)
+		}
+	}
+	return g, rows.Err()
+}
+
+// handles reports whether an implementation file is the handler of an API
+// endpoint. Handlers are written as a function name, a file path, or
+// "path:function".
+func handles(file string, functions []string, handlerFile, handlerFunction string) bool {
+	for _, handler := range []string{handlerFile, handlerFunction} {
+		if handler == "" {
+			continue
+		}
+		if handler == file || strings.HasPrefix(handler, file+":") {
+			return true
+		}
+		for _, function := range functions {
+			if handler == function {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteDOT writes the graph in Graphviz DOT format
+func WriteDOT(w io.Writer, g *Graph, name string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=8, color=\"#6b7280\"];\n\n")
+	for _, node := range g.Nodes {
+		label := node.Label
+		if node.Detail != "" {
+			label += "\n" + node.Detail
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", dotQuote(node.ID), dotQuote(label), dotStyles[node.Kind])
+	}
+	b.WriteString("\n")
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Kind))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var dotStyles = map[string]string{
+	NodeComponent:   `shape=box3d, style=filled, fillcolor="#ede9fe"`,
+	NodeRequirement: `shape=box, style="rounded,filled", fillcolor="#dbeafe"`,
+	NodeFile:        `shape=note, style=filled, fillcolor="#f3f4f6"`,
+	NodeEndpoint:    `shape=cds, style=filled, fillcolor="#fef3c7"`,
+	NodeTest:        `shape=ellipse, style=filled, fillcolor="#dcfce7"`,
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, without the ```mermaid
+// fence
+func WriteMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", i+1)
+		ids[node.ID] = id
+		label := mermaidEscape(node.Label)
+		if node.Detail != "" {
+			label += "<br/><small>" + mermaidEscape(node.Detail) + "</small>"
+		}
+		shape := mermaidShapes[node.Kind]
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s:::%s\n", id, shape[0], label, shape[1], node.Kind)
+	}
+	for _, edge := range g.Edges {
+		from, to := ids[edge.From], ids[edge.To]
+		if from == "" || to == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", from, edge.Kind, to)
+	}
+	b.WriteString("  classDef component fill:#ede9fe,stroke:#7c3aed\n")
+	b.WriteString("  classDef requirement fill:#dbeafe,stroke:#2563eb\n")
+	b.WriteString("  classDef file fill:#f3f4f6,stroke:#6b7280\n")
+	b.WriteString("  classDef endpoint fill:#fef3c7,stroke:#d97706\n")
+	b.WriteString("  classDef test fill:#dcfce7,stroke:#16a34a\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var mermaidShapes = map[string][2]string{
+	NodeComponent:   {"[[", "]]"},
+	NodeRequirement: {"(", ")"},
+	NodeFile:        {"[/", "/]"},
+	NodeEndpoint:    {"([", "])"},
+	NodeTest:        {"{{", "}}"},
+}
+
+// mermaidEscape makes text safe inside a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(s)
+}
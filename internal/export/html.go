@@ -0,0 +1,20 @@
+package export
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+)
+
+//go:embed templates/export.html templates/compliance.html
+var templatesFS embed.FS
+
+var htmlTemplate = template.Must(template.New("export.html").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).ParseFS(templatesFS, "templates/export.html"))
+
+// WriteHTML writes the standalone HTML report of a project
+func WriteHTML(w io.Writer, doc *Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
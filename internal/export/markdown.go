@@ -0,0 +1,137 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the Markdown report of a project
+func WriteMarkdown(w io.Writer, doc *Document) error {
+	_, err := io.WriteString(w, markdown(doc))
+	return err
+}
+
+func markdown(doc *Document) string {
+	project := doc.Project
+	stats := doc.Stats
+
+	var md strings.Builder
+
+	// Header
+	md.WriteString(fmt.Sprintf("# %s - Requirements Traceability Matrix\n\n", project.Name))
+	md.WriteString(fmt.Sprintf("**Project Key:** %s  \n", project.ProjectKey))
+	if project.Description != nil && *project.Description != "" {
+		md.WriteString(fmt.Sprintf("**Description:** %s  \n", *project.Description))
+	}
+	if project.RepositoryURL != nil && *project.RepositoryURL != "" {
+		md.WriteString(fmt.Sprintf("**Repository:** %s  \n", *project.RepositoryURL))
+	}
+	if project.Version != nil && *project.Version != "" {
+		md.WriteString(fmt.Sprintf("**Version:** %s  \n", *project.Version))
+	}
+	md.WriteString(fmt.Sprintf("**Export Date:** %s  \n\n", doc.ExportDate))
+
+	// Statistics
+	md.WriteString("## 📊 Project Statistics\n\n")
+	md.WriteString("| Metric | Count |\n")
+	md.WriteString("|--------|-------|\n")
+	md.WriteString(fmt.Sprintf("| Components | %d |\n", stats.TotalComponents))
+	md.WriteString(fmt.Sprintf("| Total Requirements | %d |\n", stats.TotalRequirements))
+	md.WriteString(fmt.Sprintf("| Scopes | %d |\n", stats.TotalScopes))
+	md.WriteString(fmt.Sprintf("| User Stories | %d |\n", stats.TotalUserStories))
+	md.WriteString(fmt.Sprintf("| Technical Specifications | %d |\n", stats.TotalTechSpecs))
+	md.WriteString(fmt.Sprintf("| Test Cases | %d |\n\n", stats.TotalTestCases))
+
+	// Components
+	md.WriteString("## 🏗️ System Components\n\n")
+	for _, comp := range doc.Components {
+		md.WriteString(fmt.Sprintf("### %s\n", comp.Name))
+		md.WriteString(fmt.Sprintf("- **Type:** %s\n", comp.ComponentType))
+		if comp.Technology != "" {
+			md.WriteString(fmt.Sprintf("- **Technology:** %s\n", comp.Technology))
+		}
+		if comp.Description != "" {
+			md.WriteString(fmt.Sprintf("- **Description:** %s\n", comp.Description))
+		}
+		md.WriteString(fmt.Sprintf("- **Requirements:** %d Scopes, %d User Stories, %d Tech Specs\n",
+			comp.ScopeCount, comp.UserStoryCount, comp.TechSpecCount))
+		md.WriteString(fmt.Sprintf("- **Test Cases:** %d\n\n", comp.TestCaseCount))
+		if diagram := doc.Diagrams[comp.ComponentKey]; diagram != "" {
+			md.WriteString("```mermaid\n" + diagram + "```\n\n")
+		}
+	}
+
+	// Requirements
+	md.WriteString("## 📋 Requirements Hierarchy\n\n")
+	for _, req := range doc.Requirements {
+		writeRequirementToMarkdown(&md, req, 3)
+	}
+
+	// Footer
+	md.WriteString("\n---\n")
+	md.WriteString("*Generated by TraceVibe RTM Export*\n")
+
+	return md.String()
+}
+
+// Helper function to write requirements recursively to markdown
+func writeRequirementToMarkdown(md *strings.Builder, req RequirementTree, level int) {
+	// Header with appropriate level
+	headerPrefix := strings.Repeat("#", level)
+
+	// Badge for requirement type
+	var badge string
+	switch strings.ToUpper(req.RequirementType) {
+	case "SCOPE":
+		badge = "🎯 SCOPE"
+	case "USER_STORY":
+		badge = "👤 USER STORY"
+	case "TECH_SPEC":
+		badge = "⚙️ TECH SPEC"
+	default:
+		badge = req.RequirementType
+	}
+
+	md.WriteString(fmt.Sprintf("%s %s: %s\n\n", headerPrefix, badge, req.Title))
+
+	// Metadata
+	md.WriteString(fmt.Sprintf("- **ID:** %s\n", req.RequirementKey))
+	md.WriteString(fmt.Sprintf("- **Status:** %s\n", req.Status))
+	md.WriteString(fmt.Sprintf("- **Priority:** %s\n", req.Priority))
+	md.WriteString(fmt.Sprintf("- **Category:** %s\n", req.Category))
+
+	if req.Description != "" {
+		md.WriteString(fmt.Sprintf("- **Description:** %s\n", req.Description))
+	}
+
+	// Test cases
+	if len(req.TestCases) > 0 {
+		md.WriteString("- **Test Cases:**\n")
+		for _, tc := range req.TestCases {
+			md.WriteString(fmt.Sprintf("  - %s: %s\n", tc.TestType, tc.FilePath))
+		}
+	}
+
+	// Implementation
+	if len(req.Implementation) > 0 {
+		md.WriteString("- **Implementation:**\n")
+		for _, impl := range req.Implementation {
+			md.WriteString(fmt.Sprintf("  - **%s:** %s", impl.Layer, impl.FilePath))
+			if len(impl.Functions) > 0 {
+				md.WriteString(fmt.Sprintf(" (Functions: %s)", strings.Join(impl.Functions, ", ")))
+			}
+			if len(impl.LineRanges) > 0 {
+				md.WriteString(fmt.Sprintf(" (Lines: %s)", strings.Join(impl.LineRanges, ", ")))
+			}
+			md.WriteString("\n")
+		}
+	}
+
+	md.WriteString("\n")
+
+	// Process children recursively
+	for _, child := range req.Children {
+		writeRequirementToMarkdown(md, child, level+1)
+	}
+}
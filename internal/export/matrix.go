@@ -0,0 +1,224 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/xlsx"
+)
+
+// Matrix is the flat traceability matrix of a project: one row per
+// requirement, parents before their children
+type Matrix struct {
+	ProjectKey string
+	// TestTypes are the test type columns: unit, integration and e2e, then any
+	// other type linked in the project
+	TestTypes []string
+	Rows      []MatrixRow
+}
+
+// MatrixRow is one requirement with its implementation and tests
+type MatrixRow struct {
+	Key           string
+	Type          string
+	Title         string
+	ParentChain   []string // keys from the top-level requirement down to the parent
+	ComponentKey  string
+	ComponentName string
+	Status        string
+	Priority      string
+	Category      string
+	Files         []string
+	Functions     []string
+	Tests         map[string][]string // test type -> "file::test"
+}
+
+var defaultTestTypes = []string{"unit", "integration", "e2e"}
+
+// BuildMatrix reads the traceability matrix of a project. Rows are kept when
+// their requirement matches the filter; the parent chain is always complete.
+// Unlike the tree-shaped exports, ancestors of a match do not get rows.
+func BuildMatrix(db *database.DB, projectKey string, filter Filter) (*Matrix, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+	selected, err := selectRequirements(db, project.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type requirement struct {
+		MatrixRow
+		id       string
+		parentID string
+	}
+
+	rows, err := db.Query(`
+		SELECT r.id, COALESCE(r.parent_requirement_id, ''), r.requirement_key, r.requirement_type, r.title,
+			COALESCE(c.component_key, ''), COALESCE(c.name, ''),
+			COALESCE(r.status, ''), COALESCE(r.priority, ''), COALESCE(r.category, '')
+		FROM requirements r
+		LEFT JOIN system_components c ON r.component_id = c.id
+		WHERE r.project_id = ?
+		ORDER BY r.requirement_key`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirements: %w", err)
+	}
+	var requirements []*requirement
+	byID := make(map[string]*requirement)
+	for rows.Next() {
+		req := &requirement{}
+		if err := rows.Scan(&req.id, &req.parentID, &req.Key, &req.Type, &req.Title, &req.ComponentKey,
+			&req.ComponentName, &req.Status, &req.Priority, &req.Category); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		req.Tests = make(map[string][]string)
+		requirements = append(requirements, req)
+		byID[req.id] = req
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT i.requirement_id, i.file_path, COALESCE(i.functions, '[]')
+		FROM implementations i
+		JOIN requirements r ON i.requirement_id = r.id
+		WHERE r.project_id = ?
+		ORDER BY i.created_at`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load implementations: %w", err)
+	}
+	for rows.Next() {
+		var requirementID, filePath, functionsJSON string
+		if err := rows.Scan(&requirementID, &filePath, &functionsJSON); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if req, ok := byID[requirementID]; ok {
+			functions, _ := models.UnmarshalStringSliceJSON(functionsJSON)
+			req.Files = append(req.Files, filePath)
+			req.Functions = append(req.Functions, functions...)
+		}
+	}
+	rows.Close()
+
+	matrix := &Matrix{ProjectKey: projectKey, TestTypes: append([]string(nil), defaultTestTypes...)}
+	rows, err = db.Query(`
+		SELECT rtc.requirement_id, tf.file_path, tc.test_name, COALESCE(NULLIF(tc.test_type, ''), 'unit')
+		FROM requirement_test_coverage rtc
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE tf.project_id = ?
+		ORDER BY tf.file_path, tc.test_name`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test links: %w", err)
+	}
+	knownTypes := make(map[string]bool)
+	for _, testType := range defaultTestTypes {
+		knownTypes[testType] = true
+	}
+	var extraTypes []string
+	for rows.Next() {
+		var requirementID, filePath, testName, testType string
+		if err := rows.Scan(&requirementID, &filePath, &testName, &testType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		req, ok := byID[requirementID]
+		if !ok {
+			continue
+		}
+		testType = strings.ToLower(testType)
+		if !knownTypes[testType] {
+			knownTypes[testType] = true
+			extraTypes = append(extraTypes, testType)
+		}
+		req.Tests[testType] = append(req.Tests[testType], filePath+"::"+testName)
+	}
+	rows.Close()
+	sort.Strings(extraTypes)
+	matrix.TestTypes = append(matrix.TestTypes, extraTypes...)
+
+	// Depth first, so every row follows its parent
+	children := make(map[string][]*requirement)
+	var roots []*requirement
+	for _, req := range requirements {
+		if _, ok := byID[req.parentID]; ok {
+			children[req.parentID] = append(children[req.parentID], req)
+		} else {
+			roots = append(roots, req)
+		}
+	}
+	var walk func(req *requirement, chain []string)
+	walk = func(req *requirement, chain []string) {
+		req.ParentChain = chain
+		if selected.matched[req.id] {
+			matrix.Rows = append(matrix.Rows, req.MatrixRow)
+		}
+		chain = append(chain[:len(chain):len(chain)], req.Key)
+		for _, child := range children[req.id] {
+			walk(child, chain)
+		}
+	}
+	for _, root := range roots {
+		walk(root, nil)
+	}
+
+	return matrix, nil
+}
+
+// Table returns the matrix as a header row followed by one row per
+// requirement. Cells with several values put one per line.
+func (m *Matrix) Table() [][]string {
+	header := []string{"Requirement Key", "Type", "Title", "Parent Chain", "Component", "Component Name",
+		"Status", "Priority", "Category", "Implementation Files", "Implementation Functions"}
+	for _, testType := range m.TestTypes {
+		header = append(header, testTypeLabel(testType)+" Tests")
+	}
+	header = append(header, "Test Count")
+
+	table := [][]string{header}
+	for _, row := range m.Rows {
+		cells := []string{row.Key, row.Type, row.Title, strings.Join(row.ParentChain, " > "), row.ComponentKey,
+			row.ComponentName, row.Status, row.Priority, row.Category,
+			strings.Join(row.Files, "\n"), strings.Join(row.Functions, "\n")}
+		count := 0
+		for _, testType := range m.TestTypes {
+			cells = append(cells, strings.Join(row.Tests[testType], "\n"))
+			count += len(row.Tests[testType])
+		}
+		table = append(table, append(cells, strconv.Itoa(count)))
+	}
+	return table
+}
+
+func testTypeLabel(testType string) string {
+	if testType == "e2e" {
+		return "E2E"
+	}
+	return strings.ToUpper(testType[:1]) + testType[1:]
+}
+
+// WriteMatrixCSV writes the matrix as CSV with a header row
+func WriteMatrixCSV(w io.Writer, m *Matrix) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(m.Table()); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// WriteMatrixXLSX writes the matrix as a single-sheet workbook
+func WriteMatrixXLSX(w io.Writer, m *Matrix) error {
+	return xlsx.WriteSheet(w, "Traceability Matrix", m.Table())
+}
@@ -0,0 +1,214 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// ReportTemplates lists the built-in report templates
+var ReportTemplates = []string{"compliance"}
+
+// reportFuncs are available to built-in and custom report templates
+var reportFuncs = map[string]interface{}{
+	"lower":         strings.ToLower,
+	"upper":         strings.ToUpper,
+	"join":          strings.Join,
+	"testTypeLabel": testTypeLabel,
+	"derefString":   derefString,
+	"add":           func(a, b int) int { return a + b },
+}
+
+var complianceTemplate = htmltemplate.Must(htmltemplate.New("compliance.html").
+	Funcs(htmltemplate.FuncMap(reportFuncs)).ParseFS(templatesFS, "templates/compliance.html"))
+
+// Report is the data of a traceability report, for the built-in compliance
+// template and for custom text/template files
+type Report struct {
+	Title       string
+	Project     *database.Project
+	GeneratedAt string
+	Filter      Filter
+	Stats       Stats
+	Coverage    Coverage // tested leaf requirements
+	TestTypes   []string // test type columns of Rows
+	Rows        []MatrixRow
+	Untested    []MatrixRow // leaf requirements without tests
+	Revisions   []Revision  // oldest first
+}
+
+// Revision is one entry of a requirement's audit trail
+type Revision struct {
+	Date           string
+	RequirementKey string
+	Change         string   // created, updated, status_changed, imported, pruned, ...
+	Author         string   // changed_by: "importer", "system" or a user
+	Reason         string   // change_reason
+	Fields         []string // fields that changed, when both snapshots are known
+}
+
+// revisionFields are the requirement fields compared between audit snapshots
+var revisionFields = []string{"title", "description", "category", "priority", "status", "acceptance_criteria"}
+
+// BuildReport reads the report data of a project: the requirement-to-test
+// matrix of the requirements selected by the filter, their untested leaves
+// and their revision history
+func BuildReport(db *database.DB, projectKey string, filter Filter) (*Report, error) {
+	matrix, err := BuildMatrix(db, projectKey, filter)
+	if err != nil {
+		return nil, err
+	}
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	report := &Report{
+		Title:       "Requirements Traceability Report",
+		Project:     project,
+		GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+		Filter:      filter,
+		TestTypes:   matrix.TestTypes,
+		Rows:        matrix.Rows,
+	}
+
+	parents := make(map[string]bool)
+	rows, err := db.Query(`
+		SELECT DISTINCT p.requirement_key
+		FROM requirements r
+		JOIN requirements p ON r.parent_requirement_id = p.id
+		WHERE r.project_id = ?`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirement hierarchy: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		parents[key] = true
+	}
+	rows.Close()
+
+	included := make(map[string]bool, len(matrix.Rows))
+	components := make(map[string]bool)
+	tests := make(map[string]bool)
+	for _, row := range matrix.Rows {
+		included[row.Key] = true
+		components[row.ComponentKey] = true
+		switch strings.ToUpper(row.Type) {
+		case "SCOPE":
+			report.Stats.TotalScopes++
+		case "USER_STORY":
+			report.Stats.TotalUserStories++
+		case "TECH_SPEC":
+			report.Stats.TotalTechSpecs++
+		}
+		tested := false
+		for _, names := range row.Tests {
+			for _, name := range names {
+				tests[name] = true
+				tested = true
+			}
+		}
+		if parents[row.Key] {
+			continue
+		}
+		report.Coverage.Total++
+		if tested {
+			report.Coverage.Tested++
+		} else {
+			report.Untested = append(report.Untested, row)
+		}
+	}
+	report.Stats.TotalRequirements = len(matrix.Rows)
+	report.Stats.TotalComponents = len(components)
+	report.Stats.TotalTestCases = len(tests)
+
+	rows, err = db.Query(`
+		SELECT COALESCE(rc.created_at, ''), r.requirement_key, COALESCE(rc.change_type, ''),
+			COALESCE(rc.changed_by, ''), COALESCE(rc.change_reason, ''),
+			COALESCE(rc.old_values, ''), COALESCE(rc.new_values, '')
+		FROM requirement_changes rc
+		JOIN requirements r ON rc.requirement_id = r.id
+		WHERE r.project_id = ?
+		ORDER BY rc.created_at, rc.rowid`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirement changes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var revision Revision
+		var oldValues, newValues string
+		if err := rows.Scan(&revision.Date, &revision.RequirementKey, &revision.Change,
+			&revision.Author, &revision.Reason, &oldValues, &newValues); err != nil {
+			return nil, err
+		}
+		if !included[revision.RequirementKey] {
+			continue
+		}
+		revision.Date = formatChangeDate(revision.Date)
+		revision.Fields = changedFields(oldValues, newValues)
+		report.Revisions = append(report.Revisions, revision)
+	}
+	return report, rows.Err()
+}
+
+// changedFields compares two requirement_changes JSON snapshots
+func changedFields(oldValues, newValues string) []string {
+	var before, after map[string]interface{}
+	if json.Unmarshal([]byte(oldValues), &before) != nil || json.Unmarshal([]byte(newValues), &after) != nil {
+		return nil
+	}
+	var fields []string
+	for _, field := range revisionFields {
+		if fmt.Sprint(before[field]) != fmt.Sprint(after[field]) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// formatChangeDate shortens the RFC 3339 and SQLite timestamps of the audit
+// trail to minutes
+func formatChangeDate(value string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02 15:04")
+		}
+	}
+	return value
+}
+
+// WriteReport renders a report with a built-in template (see ReportTemplates)
+// or with a text/template file. Built-in templates produce HTML; custom
+// templates may produce any text and are not escaped.
+func WriteReport(w io.Writer, report *Report, templateName string) error {
+	switch templateName {
+	case "compliance":
+		return complianceTemplate.Execute(w, report)
+	}
+
+	content, err := os.ReadFile(templateName)
+	if err != nil {
+		if os.IsNotExist(err) && !strings.ContainsAny(templateName, `./\`) {
+			return fmt.Errorf("unknown report template %q (use %s, or the path of a text/template file)",
+				templateName, strings.Join(ReportTemplates, ", "))
+		}
+		return fmt.Errorf("failed to read report template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(templateName)).Funcs(reportFuncs).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl.Execute(w, report)
+}
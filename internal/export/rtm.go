@@ -0,0 +1,300 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/schema"
+)
+
+// RTM reads a project back into the RTM file format, so the JSON, YAML and
+// ReqIF exports can be imported again. Only the requirements selected by the
+// filter are kept, with their ancestors, and the components they belong to.
+func RTM(db *database.DB, projectKey string, filter Filter) (*models.RTMData, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+
+	selected, err := selectRequirements(db, project.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all requirements for the project
+	requirements, err := db.GetRequirementsByProject(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading requirements: %w", err)
+	}
+
+	componentSummaries, err := Components(db, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading components: %w", err)
+	}
+
+	// Create RTMData structure
+	rtmData := &models.RTMData{
+		Metadata: models.RTMMetadata{
+			SchemaVersion: schema.Version,
+			GeneratedAt:   time.Now().Format("2006-01-02 15:04:05"),
+			GeneratedBy:   "TraceVibe Export",
+			Project: models.Project{
+				ID:          project.ProjectKey,
+				Name:        project.Name,
+				Description: derefString(project.Description),
+				Repository:  derefString(project.RepositoryURL),
+				Version:     derefString(project.Version),
+				LastUpdated: project.UpdatedAt,
+			},
+		},
+		Project: models.Project{
+			ID:          project.ProjectKey,
+			Name:        project.Name,
+			Description: derefString(project.Description),
+			Repository:  derefString(project.RepositoryURL),
+			Version:     derefString(project.Version),
+			LastUpdated: project.UpdatedAt,
+		},
+		SystemComponents: []models.SystemComponent{},
+		Scopes:           []models.Scope{},
+	}
+
+	// Convert components
+	componentKeys := make(map[string]string) // row ID -> component key
+	for _, comp := range componentSummaries {
+		if !selected.components[comp.ComponentKey] {
+			continue
+		}
+		componentKeys[comp.ID] = comp.ComponentKey
+		rtmData.SystemComponents = append(rtmData.SystemComponents, models.SystemComponent{
+			ID:            comp.ComponentKey,
+			Name:          comp.Name,
+			ComponentType: comp.ComponentType,
+			Technology:    comp.Technology,
+			Description:   comp.Description,
+			Tags:          comp.Tags,
+			Path:          comp.Path,
+			BasePath:      comp.BasePath,
+		})
+	}
+
+	// Build hierarchical requirements structure (Scopes -> UserStories -> TechSpecs).
+	// Positions are kept as indexes: appending to a slice may move it, which
+	// would leave pointers into it looking at a stale copy.
+	type storyIndex struct{ scope, story int }
+	scopeIndexMap := make(map[string]int)
+	userStoryMap := make(map[string]storyIndex)
+
+	// First pass: create the scopes of the exported components
+	for _, req := range requirements {
+		if _, ok := componentKeys[req.ComponentID]; !ok || !selected.kept[req.ID] {
+			continue
+		}
+		if strings.ToLower(req.RequirementType) == "scope" {
+			scope := models.Scope{
+				ID:          req.RequirementKey,
+				ComponentID: componentKeys[req.ComponentID],
+				Name:        req.Title,
+				Description: derefString(req.Description),
+				Priority:    req.Priority,
+				Status:      req.Status,
+				UserStories: []models.UserStory{},
+			}
+			scopeIndexMap[req.ID] = len(rtmData.Scopes)
+			rtmData.Scopes = append(rtmData.Scopes, scope)
+		}
+	}
+
+	// Second pass: create user stories and attach to scopes
+	for _, req := range requirements {
+		if strings.ToLower(req.RequirementType) == "user_story" && selected.kept[req.ID] {
+			if req.ParentRequirementID != nil {
+				if scopeIndex, exists := scopeIndexMap[*req.ParentRequirementID]; exists {
+					userStory := models.UserStory{
+						ID:          req.RequirementKey,
+						Name:        req.Title,
+						Description: derefString(req.Description),
+						Priority:    req.Priority,
+						Status:      req.Status,
+						TechSpecs:   []models.TechSpec{},
+					}
+					// Add to scope's user stories
+					rtmData.Scopes[scopeIndex].UserStories = append(rtmData.Scopes[scopeIndex].UserStories, userStory)
+					userStoryMap[req.ID] = storyIndex{scopeIndex, len(rtmData.Scopes[scopeIndex].UserStories) - 1}
+				}
+			}
+		}
+	}
+
+	// Third pass: create tech specs and attach to user stories
+	for _, req := range requirements {
+		if strings.ToLower(req.RequirementType) == "tech_spec" && selected.kept[req.ID] {
+			if req.ParentRequirementID != nil {
+				if index, exists := userStoryMap[*req.ParentRequirementID]; exists {
+					// Get implementation details
+					impl, _ := implementationForRequirement(db, req.ID)
+					// Get test coverage
+					testCov, _ := testCoverageForRequirement(db, req.ID)
+
+					techSpec := models.TechSpec{
+						ID:                 req.RequirementKey,
+						Name:               req.Title,
+						Description:        derefString(req.Description),
+						Priority:           req.Priority,
+						Status:             req.Status,
+						AcceptanceCriteria: req.AcceptanceCriteria,
+						Implementation:     impl,
+						TestCoverage:       testCov,
+					}
+					parentStory := &rtmData.Scopes[index.scope].UserStories[index.story]
+					parentStory.TechSpecs = append(parentStory.TechSpecs, techSpec)
+				}
+			}
+		}
+	}
+
+	return rtmData, nil
+}
+
+func derefString(str *string) string {
+	if str == nil {
+		return ""
+	}
+	return *str
+}
+
+// implementationForRequirement reads the implementation of a requirement in
+// RTM file form, or nil if it has none
+func implementationForRequirement(db *database.DB, requirementID string) (*models.Implementation, error) {
+	query := `
+		SELECT layer, file_path, functions
+		FROM implementations
+		WHERE requirement_id = ?`
+
+	rows, err := db.Query(query, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	impl := &models.Implementation{}
+	hasData := false
+
+	for rows.Next() {
+		var layer, filePath, functionsJSON string
+		if err := rows.Scan(&layer, &filePath, &functionsJSON); err != nil {
+			continue
+		}
+
+		hasData = true
+		var functions []string
+		if functionsJSON != "" && functionsJSON != "[]" {
+			if err := json.Unmarshal([]byte(functionsJSON), &functions); err == nil {
+				fileImpl := models.FileImpl{
+					Path:      filePath,
+					Functions: functions,
+				}
+
+				switch layer {
+				case "backend":
+					if impl.Backend == nil {
+						impl.Backend = &models.BackendImpl{Files: []models.FileImpl{}}
+					}
+					impl.Backend.Files = append(impl.Backend.Files, fileImpl)
+				case "frontend":
+					if impl.Frontend == nil {
+						impl.Frontend = &models.FrontendImpl{Files: []models.FileImpl{}}
+					}
+					impl.Frontend.Files = append(impl.Frontend.Files, fileImpl)
+				case "database":
+					if impl.Database == nil {
+						impl.Database = &models.DatabaseImpl{Files: []models.FileImpl{}}
+					}
+					impl.Database.Files = append(impl.Database.Files, fileImpl)
+				}
+			}
+		}
+	}
+
+	if !hasData {
+		return nil, nil
+	}
+	return impl, nil
+}
+
+// testCoverageForRequirement reads the tests linked to a requirement in RTM
+// file form, or nil if it has none
+func testCoverageForRequirement(db *database.DB, requirementID string) (*models.TestCoverage, error) {
+	// Query to get test cases for this requirement
+	query := `
+		SELECT tf.file_path, tc.test_name, tf.test_type
+		FROM requirement_test_coverage rtc
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE rtc.requirement_id = ?`
+
+	rows, err := db.Query(query, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	testCov := &models.TestCoverage{
+		UnitTests:        []models.TestFile{},
+		IntegrationTests: []models.TestFile{},
+		E2ETests:         []models.TestFile{},
+	}
+
+	// Group test cases by file and type
+	testFileMap := make(map[string]map[string][]string) // file -> type -> functions
+	hasData := false
+
+	for rows.Next() {
+		var filePath, testName, testType string
+		if err := rows.Scan(&filePath, &testName, &testType); err != nil {
+			continue
+		}
+
+		hasData = true
+		if _, exists := testFileMap[filePath]; !exists {
+			testFileMap[filePath] = make(map[string][]string)
+		}
+
+		if testType == "" {
+			testType = "unit"
+		}
+
+		testFileMap[filePath][testType] = append(testFileMap[filePath][testType], testName)
+	}
+
+	// Convert to TestFile structures
+	for filePath, typeMap := range testFileMap {
+		for testType, functions := range typeMap {
+			tf := models.TestFile{
+				File:      filePath,
+				Functions: functions,
+			}
+
+			switch testType {
+			case "unit":
+				testCov.UnitTests = append(testCov.UnitTests, tf)
+			case "integration":
+				testCov.IntegrationTests = append(testCov.IntegrationTests, tf)
+			case "e2e":
+				testCov.E2ETests = append(testCov.E2ETests, tf)
+			}
+		}
+	}
+
+	if !hasData {
+		return nil, nil
+	}
+	return testCov, nil
+}
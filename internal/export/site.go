@@ -0,0 +1,377 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+//go:embed templates/site
+var siteFS embed.FS
+
+// Site page templates, each the layout plus the page's "content" block. They
+// are cloned with the paths of one site before they are executed.
+var sitePages = func() map[string]*template.Template {
+	funcs := newSitePaths().funcs()
+	funcs["lower"] = strings.ToLower
+	funcs["coverageOf"] = requirementCoverage
+	funcs["derefString"] = derefString
+	layout := template.Must(template.New("layout.html").Funcs(funcs).ParseFS(siteFS, "templates/site/layout.html"))
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"index", "component", "requirement"} {
+		pages[name] = template.Must(template.Must(layout.Clone()).ParseFS(siteFS, "templates/site/"+name+".html"))
+	}
+	return pages
+}()
+
+// Coverage counts the leaf requirements of a subtree that have tests linked
+type Coverage struct {
+	Tested int
+	Total  int
+}
+
+// Percent is the share of tested leaves, rounded down
+func (c Coverage) Percent() int {
+	if c.Total == 0 {
+		return 0
+	}
+	return c.Tested * 100 / c.Total
+}
+
+// Level is "good", "fair" or "poor", for badge colours
+func (c Coverage) Level() string {
+	switch p := c.Percent(); {
+	case c.Total > 0 && p >= 80:
+		return "good"
+	case p >= 50:
+		return "fair"
+	default:
+		return "poor"
+	}
+}
+
+func (c *Coverage) add(other Coverage) {
+	c.Tested += other.Tested
+	c.Total += other.Total
+}
+
+// requirementCoverage counts the tested leaves below req, or req itself when
+// it has no children
+func requirementCoverage(req RequirementTree) Coverage {
+	if len(req.Children) == 0 {
+		if len(req.TestCases) > 0 {
+			return Coverage{Tested: 1, Total: 1}
+		}
+		return Coverage{Total: 1}
+	}
+	var c Coverage
+	for _, child := range req.Children {
+		c.add(requirementCoverage(child))
+	}
+	return c
+}
+
+// siteComponent is a component with its requirements, as shown on its page
+type siteComponent struct {
+	ComponentSummary
+	Requirements []RequirementTree
+	Coverage     Coverage
+}
+
+// siteRequirement is a requirement with where it sits in the hierarchy
+type siteRequirement struct {
+	RequirementTree
+	Component *siteComponent
+	Parents   []RequirementTree // from the top-level requirement down to the parent
+	Coverage  Coverage
+}
+
+// sitePage is the data of every page. Root is the relative path back to the
+// site root, so the site works from any host path or straight from disk.
+type sitePage struct {
+	Title       string
+	Root        string
+	Project     *database.Project
+	GeneratedAt string
+	Stats       Stats
+	Coverage    Coverage
+	Components  []*siteComponent
+	Component   *siteComponent
+	Requirement *siteRequirement
+}
+
+// searchEntry is one requirement in the client-side search index
+type searchEntry struct {
+	Key       string `json:"key"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	Component string `json:"component"`
+	URL       string `json:"url"`
+	Text      string `json:"text"` // description, files and tests, searched but not shown
+}
+
+// WriteSite generates a static site for a project in dir: an index page, a
+// page per component and a page per requirement, a search index and
+// coverage badges. Only the requirements selected by the filter, their
+// ancestors and their components are included. It returns the number of HTML
+// pages written.
+func WriteSite(db *database.DB, projectKey, dir string, filter Filter) (int, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return 0, fmt.Errorf("project '%s' not found", projectKey)
+	}
+	selected, err := selectRequirements(db, project.ID, filter)
+	if err != nil {
+		return 0, err
+	}
+	components, err := Components(db, projectKey)
+	if err != nil {
+		return 0, fmt.Errorf("error loading components: %w", err)
+	}
+
+	index := sitePage{
+		Title:       project.Name,
+		Project:     project,
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	var requirements []*siteRequirement
+	for _, summary := range components {
+		if !selected.components[summary.ComponentKey] {
+			continue
+		}
+		tree, err := RequirementsTree(db, projectKey, summary.ComponentKey)
+		if err != nil {
+			return 0, fmt.Errorf("error loading requirements: %w", err)
+		}
+		tree = selected.prune(tree)
+		component := &siteComponent{ComponentSummary: summary, Requirements: tree}
+		var walk func(req RequirementTree, parents []RequirementTree)
+		walk = func(req RequirementTree, parents []RequirementTree) {
+			requirements = append(requirements, &siteRequirement{
+				RequirementTree: req,
+				Component:       component,
+				Parents:         parents,
+				Coverage:        requirementCoverage(req),
+			})
+			parents = append(parents[:len(parents):len(parents)], req)
+			for _, child := range req.Children {
+				walk(child, parents)
+			}
+		}
+		for _, req := range tree {
+			walk(req, nil)
+			component.Coverage.add(requirementCoverage(req))
+			CountRequirementsByType(req, &index.Stats.TotalScopes, &index.Stats.TotalUserStories, &index.Stats.TotalTechSpecs)
+			index.Stats.TotalTestCases += CountTestCases(req)
+		}
+		index.Coverage.add(component.Coverage)
+		index.Components = append(index.Components, component)
+	}
+	index.Stats.TotalComponents = len(index.Components)
+	index.Stats.TotalRequirements = index.Stats.TotalScopes + index.Stats.TotalUserStories + index.Stats.TotalTechSpecs
+
+	// Assign the page files up front, in page order, so the first of two keys
+	// with the same slug keeps the plain file name
+	paths := newSitePaths()
+	for _, component := range index.Components {
+		paths.component(component.ComponentKey)
+	}
+	for _, req := range requirements {
+		paths.requirement(req.RequirementKey)
+	}
+	templates := make(map[string]*template.Template, len(sitePages))
+	for name, page := range sitePages {
+		clone, err := page.Clone()
+		if err != nil {
+			return 0, fmt.Errorf("failed to prepare %s template: %w", name, err)
+		}
+		templates[name] = clone.Funcs(paths.funcs())
+	}
+
+	files := map[string][]byte{
+		"assets/style.css":    mustReadSiteFile("style.css"),
+		"assets/search.js":    mustReadSiteFile("search.js"),
+		"badges/coverage.svg": coverageBadge("coverage", index.Coverage),
+	}
+	pages := 0
+	render := func(name, path string, page sitePage) error {
+		var buf bytes.Buffer
+		if err := templates[name].Execute(&buf, page); err != nil {
+			return fmt.Errorf("failed to render %s: %w", path, err)
+		}
+		files[path] = buf.Bytes()
+		pages++
+		return nil
+	}
+
+	if err := render("index", "index.html", index); err != nil {
+		return 0, err
+	}
+	for _, component := range index.Components {
+		page := index
+		page.Title = component.Name
+		page.Root = "../"
+		page.Component = component
+		if err := render("component", paths.component(component.ComponentKey), page); err != nil {
+			return 0, err
+		}
+		files[paths.badge(component.ComponentKey)] = coverageBadge("coverage", component.Coverage)
+	}
+	var entries []searchEntry
+	for _, req := range requirements {
+		page := index
+		page.Title = req.RequirementKey + " " + req.Title
+		page.Root = "../"
+		page.Component = req.Component
+		page.Requirement = req
+		if err := render("requirement", paths.requirement(req.RequirementKey), page); err != nil {
+			return 0, err
+		}
+		entries = append(entries, newSearchEntry(req, paths))
+	}
+
+	indexJSON, err := json.Marshal(entries)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build search index: %w", err)
+	}
+	// A script rather than JSON, so search also works from file:// URLs
+	files["assets/search-index.js"] = []byte("window.TRACEVIBE_SEARCH_INDEX = " + string(indexJSON) + ";\n")
+
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return 0, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return pages, nil
+}
+
+func newSearchEntry(req *siteRequirement, paths *sitePaths) searchEntry {
+	text := []string{req.Description, req.Status, req.Category}
+	for _, impl := range req.Implementation {
+		text = append(text, impl.FilePath)
+		text = append(text, impl.Functions...)
+	}
+	for _, tc := range req.TestCases {
+		text = append(text, tc.FilePath, tc.TestName)
+	}
+	return searchEntry{
+		Key:       req.RequirementKey,
+		Title:     req.Title,
+		Type:      req.RequirementType,
+		Component: req.Component.Name,
+		URL:       paths.requirement(req.RequirementKey),
+		Text:      strings.Join(text, " "),
+	}
+}
+
+func mustReadSiteFile(name string) []byte {
+	content, err := siteFS.ReadFile("templates/site/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return content
+}
+
+// sitePaths assigns the pages of a site their paths relative to the site
+// root. Keys whose slug is already taken, compared case-insensitively for
+// macOS and Windows file systems, get a short hash of the key appended, so no
+// page overwrites another.
+type sitePaths struct {
+	names map[string]string // directory + "/" + key -> file name without extension
+	taken map[string]bool   // lower-cased directory + "/" + file name
+}
+
+func newSitePaths() *sitePaths {
+	return &sitePaths{names: make(map[string]string), taken: make(map[string]bool)}
+}
+
+func (p *sitePaths) name(dir, key string) string {
+	if name, ok := p.names[dir+"/"+key]; ok {
+		return name
+	}
+	name := slug(key)
+	if p.taken[strings.ToLower(dir+"/"+name)] {
+		sum := sha256.Sum256([]byte(key))
+		name = fmt.Sprintf("%s-%x", name, sum[:4])
+		for i := 2; p.taken[strings.ToLower(dir+"/"+name)]; i++ {
+			name = fmt.Sprintf("%s-%x-%d", slug(key), sum[:4], i)
+		}
+	}
+	p.taken[strings.ToLower(dir+"/"+name)] = true
+	p.names[dir+"/"+key] = name
+	return name
+}
+
+// component is the path of a component page
+func (p *sitePaths) component(key string) string {
+	return "components/" + p.name("components", key) + ".html"
+}
+
+// requirement is the path of a requirement page
+func (p *sitePaths) requirement(key string) string {
+	return "requirements/" + p.name("requirements", key) + ".html"
+}
+
+// badge is the path of a component's coverage badge, in a directory of its
+// own so it cannot replace the project's badges/coverage.svg
+func (p *sitePaths) badge(key string) string {
+	return "badges/components/" + p.name("components", key) + ".svg"
+}
+
+func (p *sitePaths) funcs() template.FuncMap {
+	return template.FuncMap{
+		"componentURL":   p.component,
+		"requirementURL": p.requirement,
+		"badgeURL":       p.badge,
+	}
+}
+
+// slug turns a key into a file name, keeping letters, digits, '.', '_' and '-'
+func slug(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, key)
+}
+
+var badgeColors = map[string]string{"good": "#16a34a", "fair": "#d97706", "poor": "#dc2626"}
+
+// coverageBadge draws a shields-style SVG badge, for READMEs and dashboards
+func coverageBadge(label string, c Coverage) []byte {
+	value := fmt.Sprintf("%d%%", c.Percent())
+	if c.Total == 0 {
+		value = "n/a"
+	}
+	// Roughly 7px per character at 11px Verdana, plus padding
+	labelWidth, valueWidth := 7*len(label)+10, 7*len(value)+10
+	width := labelWidth + valueWidth
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, value)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" rx="3" fill="#555"/>`, width)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" rx="3" fill="%s"/>`, labelWidth, valueWidth, badgeColors[c.Level()])
+	fmt.Fprintf(&b, `<rect x="%d" width="4" height="20" fill="%s"/>`, labelWidth, badgeColors[c.Level()])
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, labelWidth/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, labelWidth+valueWidth/2, value)
+	b.WriteString("</g></svg>\n")
+	return b.Bytes()
+}
@@ -0,0 +1,68 @@
+// Client-side search over window.TRACEVIBE_SEARCH_INDEX (assets/search-index.js)
+(function () {
+    var input = document.getElementById('search-input');
+    var results = document.getElementById('search-results');
+    var index = window.TRACEVIBE_SEARCH_INDEX || [];
+    var root = document.body.getAttribute('data-root') || '';
+    var maxResults = 20;
+
+    function matches(entry, terms) {
+        var haystack = (entry.key + ' ' + entry.title + ' ' + entry.type + ' ' + entry.component + ' ' + entry.text).toLowerCase();
+        return terms.every(function (term) { return haystack.indexOf(term) !== -1; });
+    }
+
+    function render(query) {
+        results.innerHTML = '';
+        var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+        if (terms.length === 0) {
+            results.hidden = true;
+            return;
+        }
+        var found = index.filter(function (entry) { return matches(entry, terms); });
+        found.slice(0, maxResults).forEach(function (entry) {
+            var item = document.createElement('li');
+            var link = document.createElement('a');
+            link.href = root + entry.url;
+            var key = document.createElement('strong');
+            key.textContent = entry.key;
+            link.appendChild(key);
+            link.appendChild(document.createTextNode(' ' + entry.title));
+            var meta = document.createElement('span');
+            meta.className = 'muted';
+            meta.textContent = entry.type + ' · ' + entry.component;
+            link.appendChild(meta);
+            item.appendChild(link);
+            results.appendChild(item);
+        });
+        if (found.length === 0) {
+            var empty = document.createElement('li');
+            empty.className = 'muted';
+            empty.textContent = 'No matches';
+            results.appendChild(empty);
+        } else if (found.length > maxResults) {
+            var more = document.createElement('li');
+            more.className = 'muted';
+            more.textContent = (found.length - maxResults) + ' more, refine the search';
+            results.appendChild(more);
+        }
+        results.hidden = false;
+    }
+
+    input.addEventListener('input', function () { render(input.value); });
+    input.addEventListener('keydown', function (event) {
+        if (event.key === 'Escape') {
+            input.value = '';
+            render('');
+        } else if (event.key === 'Enter') {
+            var first = results.querySelector('a');
+            if (first) {
+                window.location.href = first.href;
+            }
+        }
+    });
+    document.addEventListener('click', function (event) {
+        if (!event.target.closest('.search')) {
+            results.hidden = true;
+        }
+    });
+})();
@@ -0,0 +1,365 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// ComponentSummary is a component with its requirement and test counts
+type ComponentSummary struct {
+	ID                  string   `json:"id"`
+	ComponentKey        string   `json:"component_key"`
+	Name                string   `json:"name"`
+	ComponentType       string   `json:"component_type"`
+	Technology          string   `json:"technology"`
+	Description         string   `json:"description"`
+	Tags                []string `json:"tags"`
+	Path                string   `json:"path,omitempty"`      // directory in the checkout
+	BasePath            string   `json:"base_path,omitempty"` // directory file paths are relative to
+	TotalRequirements   int      `json:"total_requirements"`
+	ScopeCount          int      `json:"scope_count"`
+	UserStoryCount      int      `json:"user_story_count"`
+	TechSpecCount       int      `json:"tech_spec_count"`
+	ImplementationCount int      `json:"implementation_count"`
+	TestCaseCount       int      `json:"test_case_count"`
+	ScopeIDs            []string `json:"scope_ids"`
+}
+
+// RequirementTree is a requirement with its children, implementation and tests
+type RequirementTree struct {
+	ID              string               `json:"id"`
+	RequirementKey  string               `json:"requirement_key"`
+	RequirementType string               `json:"requirement_type"`
+	Title           string               `json:"title"`
+	Description     string               `json:"description"`
+	Category        string               `json:"category"`
+	Status          string               `json:"status"`
+	Priority        string               `json:"priority"`
+	Children        []RequirementTree    `json:"children"`
+	Implementation  []ImplementationInfo `json:"implementation"`
+	TestCases       []TestCaseInfo       `json:"test_cases"`
+	UserStoryCount  int                  `json:"user_story_count"`
+	TechSpecCount   int                  `json:"tech_spec_count"`
+	TestCaseCount   int                  `json:"test_case_count"`
+}
+
+type ImplementationInfo struct {
+	Layer      string   `json:"layer"`
+	FilePath   string   `json:"file_path"`
+	Functions  []string `json:"functions"`
+	LineRanges []string `json:"line_ranges,omitempty"`
+}
+
+type TestCaseInfo struct {
+	FilePath string `json:"file_path"`
+	TestName string `json:"test_name"`
+	TestType string `json:"test_type"`
+}
+
+// Components lists the components of a project with their requirement and
+// test counts, ordered by name
+func Components(db *database.DB, projectKey string) ([]ComponentSummary, error) {
+	// First check if tags column exists
+	var tagCount int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('system_components') WHERE name='tags'").Scan(&tagCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check table structure: %w", err)
+	}
+
+	var query string
+	if tagCount > 0 {
+		// Tags column exists, include it in the query
+		query = `
+			SELECT c.id, c.component_key, c.name, c.component_type,
+				   COALESCE(c.technology, '') as technology, COALESCE(c.description, '') as description,
+				   COALESCE(c.tags, '[]') as tags, COALESCE(c.path, ''), COALESCE(c.base_path, ''),
+				   COALESCE(cs.total_requirements, 0), COALESCE(cs.scope_count, 0),
+				   COALESCE(cs.user_story_count, 0), COALESCE(cs.tech_spec_count, 0),
+				   COALESCE(cs.implementation_count, 0), COALESCE(cs.test_case_count, 0)
+			FROM system_components c
+			JOIN projects p ON c.project_id = p.id
+			LEFT JOIN component_summary cs ON c.id = cs.id
+			WHERE p.project_key = ?
+			ORDER BY c.name`
+	} else {
+		// Tags column doesn't exist, exclude it from query
+		query = `
+			SELECT c.id, c.component_key, c.name, c.component_type,
+				   COALESCE(c.technology, '') as technology, COALESCE(c.description, '') as description,
+				   COALESCE(cs.total_requirements, 0), COALESCE(cs.scope_count, 0),
+				   COALESCE(cs.user_story_count, 0), COALESCE(cs.tech_spec_count, 0),
+				   COALESCE(cs.implementation_count, 0), COALESCE(cs.test_case_count, 0)
+			FROM system_components c
+			JOIN projects p ON c.project_id = p.id
+			LEFT JOIN component_summary cs ON c.id = cs.id
+			WHERE p.project_key = ?
+			ORDER BY c.name`
+	}
+
+	rows, err := db.Query(query, projectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []ComponentSummary
+	for rows.Next() {
+		var c ComponentSummary
+		var tagsJSON string
+
+		if tagCount > 0 {
+			// Scan with tags column
+			err := rows.Scan(&c.ID, &c.ComponentKey, &c.Name, &c.ComponentType,
+				&c.Technology, &c.Description, &tagsJSON, &c.Path, &c.BasePath, &c.TotalRequirements, &c.ScopeCount,
+				&c.UserStoryCount, &c.TechSpecCount, &c.ImplementationCount, &c.TestCaseCount)
+			if err != nil {
+				return nil, err
+			}
+
+			// Parse tags JSON
+			if tagsJSON != "" && tagsJSON != "[]" {
+				json.Unmarshal([]byte(tagsJSON), &c.Tags)
+			}
+		} else {
+			// Scan without tags column
+			err := rows.Scan(&c.ID, &c.ComponentKey, &c.Name, &c.ComponentType,
+				&c.Technology, &c.Description, &c.TotalRequirements, &c.ScopeCount,
+				&c.UserStoryCount, &c.TechSpecCount, &c.ImplementationCount, &c.TestCaseCount)
+			if err != nil {
+				return nil, err
+			}
+			// Tags will remain empty slice (initialized by struct)
+		}
+
+		// Get scope IDs for this component
+		scopeQuery := `
+			SELECT r.requirement_key
+			FROM requirements r
+			WHERE r.component_id = ? AND UPPER(r.requirement_type) = 'SCOPE'
+			ORDER BY r.requirement_key`
+
+		scopeRows, err := db.Query(scopeQuery, c.ID)
+		if err == nil {
+			defer scopeRows.Close()
+			for scopeRows.Next() {
+				var scopeID string
+				if err := scopeRows.Scan(&scopeID); err == nil {
+					c.ScopeIDs = append(c.ScopeIDs, scopeID)
+				}
+			}
+		}
+
+		components = append(components, c)
+	}
+
+	return components, nil
+}
+
+// RequirementsTree loads the top-level requirements of a project, or of one
+// component, with their children, implementations and test cases
+func RequirementsTree(db *database.DB, projectKey, componentKey string) ([]RequirementTree, error) {
+	// This is a simplified version - in practice you'd need recursive queries or multiple queries
+	// to build the complete hierarchical tree with implementations and test cases
+
+	whereClause := "WHERE p.project_key = ? AND r.parent_requirement_id IS NULL"
+	args := []interface{}{projectKey}
+
+	if componentKey != "" {
+		whereClause += " AND c.component_key = ?"
+		args = append(args, componentKey)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT r.id, r.requirement_key, r.requirement_type, r.title,
+			   COALESCE(r.description, '') as description, r.category, r.status,
+			   COALESCE(r.priority, 'medium') as priority
+		FROM requirements r
+		JOIN projects p ON r.project_id = p.id
+		JOIN system_components c ON r.component_id = c.id
+		%s
+		ORDER BY r.requirement_key`, whereClause)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requirements []RequirementTree
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		var req RequirementTree
+		err := rows.Scan(&req.ID, &req.RequirementKey, &req.RequirementType,
+			&req.Title, &req.Description, &req.Category, &req.Status, &req.Priority)
+		if err != nil {
+			return nil, err
+		}
+
+		// Get children recursively (simplified for now)
+		children, err := childRequirements(db, req.ID)
+		if err == nil {
+			req.Children = children
+			// Calculate counts from children
+			for _, child := range children {
+				switch strings.ToUpper(child.RequirementType) {
+				case "USER_STORY":
+					req.UserStoryCount++
+				case "TECH_SPEC":
+					req.TechSpecCount++
+				}
+				req.TestCaseCount += child.TestCaseCount
+			}
+		}
+
+		// Get implementation and test info
+		req.Implementation, _ = implementationInfo(db, req.ID)
+		req.TestCases, _ = testCaseInfo(db, req.ID)
+		req.TestCaseCount += len(req.TestCases)
+
+		requirements = append(requirements, req)
+	}
+
+	return requirements, nil
+}
+
+func childRequirements(db *database.DB, parentID string) ([]RequirementTree, error) {
+	query := `
+		SELECT id, requirement_key, requirement_type, title,
+			   COALESCE(description, '') as description, category, status,
+			   COALESCE(priority, 'medium') as priority
+		FROM requirements
+		WHERE parent_requirement_id = ?
+		ORDER BY requirement_key`
+
+	rows, err := db.Query(query, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var children []RequirementTree
+	for rows.Next() {
+		var child RequirementTree
+		err := rows.Scan(&child.ID, &child.RequirementKey, &child.RequirementType,
+			&child.Title, &child.Description, &child.Category, &child.Status, &child.Priority)
+		if err != nil {
+			continue
+		}
+
+		// Recursively get children
+		grandchildren, err := childRequirements(db, child.ID)
+		if err == nil {
+			child.Children = grandchildren
+			// Calculate counts from grandchildren
+			for _, grandchild := range grandchildren {
+				switch strings.ToUpper(grandchild.RequirementType) {
+				case "USER_STORY":
+					child.UserStoryCount++
+				case "TECH_SPEC":
+					child.TechSpecCount++
+				}
+				child.TestCaseCount += grandchild.TestCaseCount
+			}
+		}
+
+		// Get implementation and test info
+		child.Implementation, _ = implementationInfo(db, child.ID)
+		child.TestCases, _ = testCaseInfo(db, child.ID)
+		child.TestCaseCount += len(child.TestCases)
+
+		children = append(children, child)
+	}
+
+	return children, nil
+}
+
+func implementationInfo(db *database.DB, requirementID string) ([]ImplementationInfo, error) {
+	query := `SELECT layer, file_path, COALESCE(functions, '[]') as functions,
+			  COALESCE(line_ranges, '[]') as line_ranges
+			  FROM implementations WHERE requirement_id = ?`
+
+	rows, err := db.Query(query, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var implementations []ImplementationInfo
+	for rows.Next() {
+		var impl ImplementationInfo
+		var functionsJSON, lineRangesJSON string
+
+		err := rows.Scan(&impl.Layer, &impl.FilePath, &functionsJSON, &lineRangesJSON)
+		if err != nil {
+			continue
+		}
+
+		// Parse functions JSON
+		if functionsJSON != "" && functionsJSON != "[]" {
+			json.Unmarshal([]byte(functionsJSON), &impl.Functions)
+		}
+		if lineRangesJSON != "" && lineRangesJSON != "[]" {
+			json.Unmarshal([]byte(lineRangesJSON), &impl.LineRanges)
+		}
+
+		implementations = append(implementations, impl)
+	}
+
+	return implementations, nil
+}
+
+func testCaseInfo(db *database.DB, requirementID string) ([]TestCaseInfo, error) {
+	query := `
+		SELECT tf.file_path, tc.test_name, tc.test_type
+		FROM requirement_test_coverage rtc
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE rtc.requirement_id = ?`
+
+	rows, err := db.Query(query, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var testCases []TestCaseInfo
+	for rows.Next() {
+		var tc TestCaseInfo
+		err := rows.Scan(&tc.FilePath, &tc.TestName, &tc.TestType)
+		if err != nil {
+			continue
+		}
+		testCases = append(testCases, tc)
+	}
+
+	return testCases, nil
+}
+
+// CountRequirementsByType adds req and its descendants to the per-type counts
+func CountRequirementsByType(req RequirementTree, scopeCount, userStoryCount, techSpecCount *int) {
+	switch strings.ToUpper(req.RequirementType) {
+	case "SCOPE":
+		*scopeCount++
+	case "USER_STORY":
+		*userStoryCount++
+	case "TECH_SPEC":
+		*techSpecCount++
+	}
+
+	for _, child := range req.Children {
+		CountRequirementsByType(child, scopeCount, userStoryCount, techSpecCount)
+	}
+}
+
+// CountTestCases counts the test cases linked to req and its descendants
+func CountTestCases(req RequirementTree) int {
+	count := len(req.TestCases)
+	for _, child := range req.Children {
+		count += CountTestCases(child)
+	}
+	return count
+}
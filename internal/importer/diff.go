@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// FieldChange is one field whose stored value differs from the file
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ModifiedEntity is an entity present in both the file and the database
+type ModifiedEntity struct {
+	Key     string        `json:"key"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffSection lists the differences for one kind of entity. Added entries are
+// only in the file, removed entries only in the database.
+type DiffSection struct {
+	Added    []string         `json:"added,omitempty"`
+	Removed  []string         `json:"removed,omitempty"`
+	Modified []ModifiedEntity `json:"modified,omitempty"`
+}
+
+func (d *DiffSection) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Diff compares an RTM file with the stored state of its project
+type Diff struct {
+	ProjectKey      string            `json:"project_key"`
+	ProjectExists   bool              `json:"project_exists"`
+	Issues          []ValidationIssue `json:"issues,omitempty"`
+	Components      DiffSection       `json:"components"`
+	Requirements    DiffSection       `json:"requirements"`
+	Implementations DiffSection       `json:"implementations"`
+	TestLinks       DiffSection       `json:"test_links"`
+}
+
+// Empty reports whether the file matches the database
+func (d *Diff) Empty() bool {
+	return d.Components.empty() && d.Requirements.empty() && d.Implementations.empty() && d.TestLinks.empty()
+}
+
+// DiffRTMFile parses an RTM file or spreadsheet and compares it with the
+// database. mapping is only used for spreadsheets and may be nil.
+func (imp *Importer) DiffRTMFile(filePath, projectKey string, mapping *ColumnMapping) (*Diff, error) {
+	file, err := imp.parseFile(filePath, projectKey, mapping)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := imp.Diff(file.data)
+	if err != nil {
+		return nil, err
+	}
+	diff.Issues = mergeIssues(file.issues, file.locate(diff.Issues))
+	return diff, nil
+}
+
+// Diff compares rtmData with the database, field by field for components and
+// requirements. Nothing is written.
+func (imp *Importer) Diff(rtmData *models.RTMData) (*Diff, error) {
+	state, err := loadProjectState(imp.db, rtmData.Project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{
+		ProjectKey:    rtmData.Project.ID,
+		ProjectExists: state.ProjectID != "",
+		Issues:        Validate(rtmData),
+	}
+
+	// Components
+	inFile := make(map[string]bool)
+	for i := range rtmData.SystemComponents {
+		component := &rtmData.SystemComponents[i]
+		inFile[component.ID] = true
+		stored, exists := state.Components[component.ID]
+		if !exists {
+			diff.Components.Added = append(diff.Components.Added, component.ID)
+			continue
+		}
+		if changes := componentFieldChanges(component, stored); len(changes) > 0 {
+			diff.Components.Modified = append(diff.Components.Modified, ModifiedEntity{Key: component.ID, Changes: changes})
+		}
+	}
+	for _, key := range sortedKeys(state.Components) {
+		if !inFile[key] {
+			diff.Components.Removed = append(diff.Components.Removed, key)
+		}
+	}
+
+	// Requirements and their implementation and test rows
+	inFile = make(map[string]bool)
+	for _, req := range flattenRequirements(rtmData) {
+		inFile[req.Key] = true
+		stored, exists := state.Requirements[req.Key]
+		if !exists {
+			diff.Requirements.Added = append(diff.Requirements.Added, req.Key)
+			stored = &storedRequirement{}
+		} else if changes := requirementFieldChanges(&req, stored); len(changes) > 0 {
+			diff.Requirements.Modified = append(diff.Requirements.Modified, ModifiedEntity{Key: req.Key, Changes: changes})
+		}
+
+		diffImplementations(&diff.Implementations, req.Key, stored.Implementations, implementationRows(req.Implementation))
+		diffTestLinks(&diff.TestLinks, req.Key, stored.TestLinks, testLinkRows(req.Tests))
+	}
+	for _, key := range sortedKeys(state.Requirements) {
+		if inFile[key] {
+			continue
+		}
+		diff.Requirements.Removed = append(diff.Requirements.Removed, key)
+		stored := state.Requirements[key]
+		diffImplementations(&diff.Implementations, key, stored.Implementations, nil)
+		diffTestLinks(&diff.TestLinks, key, stored.TestLinks, nil)
+	}
+
+	return diff, nil
+}
+
+func componentFieldChanges(component *models.SystemComponent, stored *storedComponent) []FieldChange {
+	var changes []FieldChange
+	compare := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	compare("name", stored.Name, component.Name)
+	compare("type", stored.ComponentType, component.ComponentType)
+	compare("technology", stored.Technology, component.Technology)
+	compare("description", stored.Description, component.Description)
+	compare("tags", strings.Join(stored.Tags, ", "), strings.Join(component.Tags, ", "))
+	return changes
+}
+
+// requirementFieldChanges lists the fields an update-mode import would overwrite
+func requirementFieldChanges(req *flatRequirement, stored *storedRequirement) []FieldChange {
+	var changes []FieldChange
+	compare := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	compare("name", stored.Title, req.Title)
+	compare("description", stored.Description, req.Description)
+	compare("type", stored.Type, req.Type)
+	compare("category", stored.Category, req.Category)
+	compare("priority", stored.Priority, req.Priority)
+	compare("status", stored.Status, req.Status)
+	compare("parent", stored.ParentKey, req.ParentKey)
+	compare("component", stored.ComponentKey, req.ComponentKey)
+	compare("acceptance_criteria", strings.Join(stored.AcceptanceCriteria, "\n"), strings.Join(req.AcceptanceCriteria, "\n"))
+	return changes
+}
+
+func diffImplementations(section *DiffSection, reqKey string, stored, incoming []implementationRow) {
+	storedByKey := make(map[string]implementationRow)
+	for _, row := range stored {
+		storedByKey[row.Layer+":"+row.FilePath] = row
+	}
+	seen := make(map[string]bool)
+	for _, row := range incoming {
+		key := row.Layer + ":" + row.FilePath
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		old, exists := storedByKey[key]
+		switch {
+		case !exists:
+			section.Added = append(section.Added, reqKey+" → "+key)
+		case strings.Join(old.Functions, ", ") != strings.Join(row.Functions, ", "):
+			section.Modified = append(section.Modified, ModifiedEntity{
+				Key:     reqKey + " → " + key,
+				Changes: []FieldChange{{Field: "functions", Old: strings.Join(old.Functions, ", "), New: strings.Join(row.Functions, ", ")}},
+			})
+		}
+	}
+	for _, row := range stored {
+		key := row.Layer + ":" + row.FilePath
+		if !seen[key] && row.Source == "import" {
+			seen[key] = true
+			section.Removed = append(section.Removed, reqKey+" → "+key)
+		}
+	}
+}
+
+func diffTestLinks(section *DiffSection, reqKey string, stored, incoming []testLinkRow) {
+	storedKeys := make(map[string]bool)
+	for _, key := range testLinkKeys(stored) {
+		storedKeys[key] = true
+	}
+	seen := make(map[string]bool)
+	for _, key := range testLinkKeys(incoming) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !storedKeys[key] {
+			section.Added = append(section.Added, reqKey+" → "+key)
+		}
+	}
+	for _, key := range testLinkKeys(importedTestLinks(stored)) {
+		if !seen[key] {
+			seen[key] = true
+			section.Removed = append(section.Removed, reqKey+" → "+key)
+		}
+	}
+}
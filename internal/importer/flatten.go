@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// flatRequirement is one requirement of an RTM file with its position in the
+// hierarchy resolved, regardless of whether it came from the flat
+// "requirements" array or the nested "scopes" format
+type flatRequirement struct {
+	Path               string // JSON path of the requirement within the file
+	Key                string
+	ParentKey          string
+	ComponentKey       string
+	Type               string
+	Title              string
+	Description        string
+	Category           string
+	Priority           string
+	Status             string
+	AcceptanceCriteria []string
+	Implementation     *models.Implementation
+	Tests              *models.TestCoverage
+}
+
+// implementationRow is one row of the implementations table
+type implementationRow struct {
+	Layer     string
+	FilePath  string
+	Functions []string
+	Source    string // stored rows only: import, manual or scan
+}
+
+// testLinkRow is one requirement -> test case link
+type testLinkRow struct {
+	FilePath string
+	TestName string
+	TestType string
+	Layer    string
+	Source   string // stored rows only: import, manual or scan
+}
+
+// flattenRequirements walks both requirement formats in import order. Children
+// in the flat format inherit the component of their top-level ancestor, the
+// same way importRequirement stores them.
+func flattenRequirements(rtmData *models.RTMData) []flatRequirement {
+	var flat []flatRequirement
+
+	var walk func(req *models.Requirement, path, parentKey, componentKey string)
+	walk = func(req *models.Requirement, path, parentKey, componentKey string) {
+		flat = append(flat, flatRequirement{
+			Path:               path,
+			Key:                req.ID,
+			ParentKey:          parentKey,
+			ComponentKey:       componentKey,
+			Type:               req.RequirementType,
+			Title:              req.Title,
+			Description:        req.Description,
+			Category:           req.Category,
+			Priority:           req.Priority,
+			Status:             req.Status,
+			AcceptanceCriteria: req.AcceptanceCriteria,
+			Implementation:     req.Implementation,
+			Tests:              req.Tests,
+		})
+		for i := range req.Children {
+			walk(&req.Children[i], fmt.Sprintf("%s.children[%d]", path, i), req.ID, componentKey)
+		}
+	}
+
+	for i := range rtmData.Requirements {
+		req := &rtmData.Requirements[i]
+		walk(req, fmt.Sprintf("requirements[%d]", i), "", req.ComponentID)
+	}
+
+	for i, scope := range rtmData.Scopes {
+		scopePath := fmt.Sprintf("scopes[%d]", i)
+		flat = append(flat, flatRequirement{
+			Path:         scopePath,
+			Key:          scope.ID,
+			ComponentKey: scope.ComponentID,
+			Type:         "SCOPE",
+			Title:        scope.Name,
+			Description:  scope.Description,
+			Category:     "scope",
+			Priority:     scope.Priority,
+			Status:       scope.Status,
+		})
+		for j, story := range scope.UserStories {
+			storyPath := fmt.Sprintf("%s.user_stories[%d]", scopePath, j)
+			flat = append(flat, flatRequirement{
+				Path:         storyPath,
+				Key:          story.ID,
+				ParentKey:    scope.ID,
+				ComponentKey: scope.ComponentID,
+				Type:         "USER_STORY",
+				Title:        story.Name,
+				Description:  story.Description,
+				Category:     "user_story",
+				Priority:     story.Priority,
+				Status:       story.Status,
+			})
+			for k, spec := range story.TechSpecs {
+				flat = append(flat, flatRequirement{
+					Path:               fmt.Sprintf("%s.tech_specs[%d]", storyPath, k),
+					Key:                spec.ID,
+					ParentKey:          story.ID,
+					ComponentKey:       scope.ComponentID,
+					Type:               "TECH_SPEC",
+					Title:              spec.Name,
+					Description:        spec.Description,
+					Category:           "tech_spec",
+					Priority:           spec.Priority,
+					Status:             spec.Status,
+					AcceptanceCriteria: spec.AcceptanceCriteria,
+					Implementation:     spec.Implementation,
+					Tests:              spec.TestCoverage,
+				})
+			}
+		}
+	}
+
+	return flat
+}
+
+// implementationRows lists the rows importImplementation would insert
+func implementationRows(impl *models.Implementation) []implementationRow {
+	if impl == nil {
+		return nil
+	}
+
+	var rows []implementationRow
+	if impl.Backend != nil {
+		for _, file := range impl.Backend.Files {
+			rows = append(rows, implementationRow{Layer: "backend", FilePath: file.Path, Functions: file.Functions})
+		}
+	}
+	if impl.Frontend != nil {
+		for _, file := range impl.Frontend.Files {
+			rows = append(rows, implementationRow{Layer: "frontend", FilePath: file.Path, Functions: file.Functions})
+		}
+	}
+	if impl.Database != nil {
+		for _, file := range impl.Database.Files {
+			rows = append(rows, implementationRow{Layer: "database", FilePath: file.Path, Functions: file.Functions})
+		}
+	}
+	return rows
+}
+
+// testLinkRows lists the test links importTestCoverage would create, using the
+// same layer and test type mapping
+func testLinkRows(tests *models.TestCoverage) []testLinkRow {
+	if tests == nil {
+		return nil
+	}
+
+	var rows []testLinkRow
+	add := func(layer, testType string, files []models.TestFile) {
+		for _, file := range files {
+			for _, fn := range file.Functions {
+				rows = append(rows, testLinkRow{FilePath: file.File, TestName: fn, TestType: testType, Layer: layer})
+			}
+		}
+	}
+	add("backend", "unit", tests.Backend)
+	add("frontend", "unit", tests.Frontend)
+	add("backend", "unit", tests.UnitTests)
+	add("backend", "integration", tests.IntegrationTests)
+	add("frontend", "e2e", tests.E2ETests)
+	return rows
+}
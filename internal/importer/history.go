@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// importSource identifies the file an import was read from
+type importSource struct {
+	Filename    string
+	ContentHash string
+}
+
+// fileSource hashes an RTM file. name, if given, is recorded instead of the
+// file's base name (uploads are saved under a temporary name).
+func fileSource(filePath, name string) (importSource, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return importSource{}, fmt.Errorf("failed to read RTM file: %w", err)
+	}
+	if name == "" {
+		name = filepath.Base(filePath)
+	}
+	sum := sha256.Sum256(content)
+	return importSource{Filename: name, ContentHash: hex.EncodeToString(sum[:])}, nil
+}
+
+// startImportRecord inserts the imports row up front so requirements can
+// point at it while they are written
+func startImportRecord(tx database.Tx, projectID string, rtmData *models.RTMData, session *importSession) error {
+	var prune *string
+	if session.prune != "" && session.mode != ModeOverwrite {
+		prune = &session.prune
+	}
+	query := `INSERT INTO imports (project_id, filename, content_hash, generated_by, generated_at, mode, prune, imported_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			  RETURNING id`
+	err := tx.QueryRow(query, projectID, session.source.Filename, session.source.ContentHash,
+		nullIfEmpty(rtmData.Metadata.GeneratedBy), nullIfEmpty(rtmData.Metadata.GeneratedAt),
+		string(session.mode), prune, session.started.UTC().Format(time.RFC3339)).Scan(&session.report.ImportID)
+	if err != nil {
+		return fmt.Errorf("failed to record import: %w", err)
+	}
+	return nil
+}
+
+// finishImportRecord stores the counts and duration of the import
+func finishImportRecord(tx database.Tx, session *importSession) error {
+	counts := session.report.Counts
+	if counts == nil {
+		counts = map[string]*database.ImportCounts{}
+	}
+	countsJSON, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal import counts: %w", err)
+	}
+	session.report.DurationMs = time.Since(session.started).Milliseconds()
+
+	_, err = tx.Exec("UPDATE imports SET counts = ?, duration_ms = ? WHERE id = ?",
+		string(countsJSON), session.report.DurationMs, session.report.ImportID)
+	if err != nil {
+		return fmt.Errorf("failed to record import: %w", err)
+	}
+	return nil
+}
+
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
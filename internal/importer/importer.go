@@ -2,14 +2,19 @@ package importer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/peshwar9/tracevibe/internal/database"
 	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/schema"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,42 +27,272 @@ func New(db *database.DB) *Importer {
 }
 
 func (imp *Importer) ImportRTMFile(filePath, projectKey string, overwrite bool) error {
+	mode := ModeUpdate
+	if overwrite {
+		mode = ModeOverwrite
+	}
+	_, err := imp.Import(filePath, projectKey, Options{Mode: mode})
+	return err
+}
+
+// Import validates and imports an RTM file using the given mode. In merge mode
+// the report lists the conflicts found and how they were resolved.
+func (imp *Importer) Import(filePath, projectKey string, opts Options) (*ImportReport, error) {
+	return imp.importFile(filePath, projectKey, opts, false)
+}
+
+// MergeConflicts runs a merge import without committing it and returns the
+// conflicts the file would raise, so they can be resolved before the real
+// import. mapping is only used for spreadsheets and may be nil.
+func (imp *Importer) MergeConflicts(filePath, projectKey string, mapping *ColumnMapping) ([]Conflict, error) {
+	report, err := imp.importFile(filePath, projectKey, Options{Mode: ModeMerge, Mapping: mapping}, true)
+	if err != nil {
+		return nil, err
+	}
+	return report.Conflicts, nil
+}
+
+func (imp *Importer) importFile(filePath, projectKey string, opts Options, dryRun bool) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeUpdate
+	}
+	report := &ImportReport{ProjectKey: projectKey, Filename: opts.Filename, Mode: opts.Mode}
+	if report.Filename == "" {
+		report.Filename = filepath.Base(filePath)
+	}
+
+	file, err := imp.parseFile(filePath, projectKey, opts.Mapping)
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			report.Errors = validationErr.Issues
+		} else {
+			report.Errors = []ValidationIssue{{Message: err.Error()}}
+		}
+		return report, err
+	}
+	rtmData := file.data
+	report.ProjectKey = rtmData.Project.ID
+	report.Warnings = file.warnings
+
+	// Report every problem up front instead of failing partway through the import
+	if issues := mergeIssues(file.issues, file.locate(Validate(rtmData))); len(issues) > 0 {
+		report.Errors = issues
+		return report, &ValidationError{Issues: issues}
+	}
+
+	source, err := fileSource(filePath, opts.Filename)
+	if err != nil {
+		report.Errors = []ValidationIssue{{Message: err.Error()}}
+		return report, err
+	}
+
+	session := &importSession{
+		mode:        opts.Mode,
+		resolutions: opts.Resolutions,
+		prune:       opts.Prune,
+		dryRun:      dryRun,
+		source:      source,
+		started:     time.Now(),
+		report:      report,
+	}
+	if err := imp.importRTMData(rtmData, session); err != nil {
+		// The transaction was rolled back: nothing was created or recorded
+		report.ImportID = ""
+		report.Counts = nil
+		report.Pruned = nil
+		if len(report.Errors) == 0 {
+			report.Errors = []ValidationIssue{{Message: err.Error()}}
+		}
+		return report, err
+	}
+
+	report.Success = true
+	slog.Debug("import finished", "project", report.ProjectKey, "file", report.Filename,
+		"mode", report.Mode, "duration_ms", report.DurationMs, "warnings", len(report.Warnings))
+	return report, nil
+}
+
+// parsedFile is an RTM file or spreadsheet decoded into RTM data
+type parsedFile struct {
+	data     *models.RTMData
+	issues   []ValidationIssue // problems found while decoding
+	warnings []ValidationIssue
+	// Spreadsheets and ReqIF only: where each requirement path was read from
+	// ("row 7", "SPEC-OBJECT REQ-1"), and the spreadsheet header of each mapped column
+	locations map[string]string
+	headers   map[string]string
+}
+
+// parseFile decodes an RTM file, a ReqIF document, or a CSV/XLSX backlog
+// through the column mapping (nil for the default one). Backlogs and ReqIF
+// documents without a title keep the project details already stored.
+func (imp *Importer) parseFile(filePath, projectKey string, mapping *ColumnMapping) (*parsedFile, error) {
+	var file *parsedFile
+	var err error
+	switch {
+	case isSpreadsheet(filePath):
+		file, err = parseSpreadsheet(filePath, projectKey, mapping)
+	case strings.EqualFold(filepath.Ext(filePath), ".reqif"):
+		file, err = parseReqIF(filePath, projectKey)
+	default:
+		rtmData, issues, warnings, err := parseRTMFile(filePath, projectKey)
+		if err != nil {
+			return nil, err
+		}
+		return &parsedFile{data: rtmData, issues: issues, warnings: warnings}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if project := &file.data.Project; project.Name == "" {
+		stored, err := imp.db.GetProjectByKey(projectKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		project.Name = projectKey
+		if stored != nil {
+			project.Name = stored.Name
+			project.Description = derefString(stored.Description)
+			project.Repository = derefString(stored.RepositoryURL)
+			project.Version = derefString(stored.Version)
+		}
+	}
+	slog.Debug("parsed spreadsheet or ReqIF document", "file", filePath, "components", len(file.data.SystemComponents),
+		"rows", len(file.locations), "issues", len(file.issues))
+	return file, nil
+}
+
+// locate rewrites the JSON paths of validation issues about a spreadsheet or
+// ReqIF document into the row and column, or the SPEC-OBJECT, they came from
+func (f *parsedFile) locate(issues []ValidationIssue) []ValidationIssue {
+	if f.locations == nil {
+		return issues
+	}
+	// Longest first, so requirements[1] does not match inside requirements[12]
+	paths := make([]string, 0, len(f.locations))
+	for path := range f.locations {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+
+	located := make([]ValidationIssue, len(issues))
+	for i, issue := range issues {
+		located[i] = issue
+		for _, path := range paths {
+			if strings.Contains(issue.Message, path) {
+				located[i].Message = strings.ReplaceAll(issue.Message, path, f.locations[path])
+				break
+			}
+		}
+		// The longest matching requirement path is the row itself, not an ancestor
+		match := ""
+		for _, path := range paths {
+			if issue.Path == path || strings.HasPrefix(issue.Path, path+".") {
+				match = path
+				break
+			}
+		}
+		if match == "" {
+			continue
+		}
+		field := strings.TrimPrefix(strings.TrimPrefix(issue.Path, match), ".")
+		located[i].Path = f.cellPath(f.locations[match], jsonFields[field])
+	}
+	return located
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ParseRTMFile reads a JSON or YAML RTM file, checks it against the RTM JSON
+// Schema and upgrades it to the current schema version. Schema violations are
+// returned alongside the parsed data so callers can report them with the rest
+// of the validation issues. The project key, if given, overrides the one in the file.
+func ParseRTMFile(filePath, projectKey string) (*models.RTMData, []ValidationIssue, error) {
+	rtmData, issues, _, err := parseRTMFile(filePath, projectKey)
+	return rtmData, issues, err
+}
+
+// parseRTMFile is ParseRTMFile that also returns the warnings found in the file
+func parseRTMFile(filePath, projectKey string) (*models.RTMData, []ValidationIssue, []ValidationIssue, error) {
 	// Read file
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open RTM file: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to open RTM file: %w", err)
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read RTM file: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to read RTM file: %w", err)
 	}
 
-	// Parse based on file extension
-	var rtmData models.RTMData
 	ext := strings.ToLower(filepath.Ext(filePath))
 
+	// Decode into generic values first so the schema sees exactly what the file contains
+	var doc interface{}
 	switch ext {
 	case ".json":
-		// Debug: Print first part of JSON to see structure
-		fmt.Printf("DEBUG: First 500 chars of JSON: %.500s\n", string(data))
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	case ".yaml", ".yml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		doc = yamlValue(&root)
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported file format: %s (use .json, .yaml, .yml, .reqif, .csv or .xlsx)", ext)
+	}
 
-		if err := json.Unmarshal(data, &rtmData); err != nil {
-			return fmt.Errorf("failed to parse JSON: %w", err)
+	schemaErrors, err := schema.Validate(doc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var issues []ValidationIssue
+	for _, e := range schemaErrors {
+		issues = append(issues, ValidationIssue{Path: e.Path, Message: e.Message})
+	}
+
+	if root, ok := doc.(map[string]interface{}); ok {
+		upgraded, err := schema.Upgrade(root)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if upgraded {
+			if data, err = json.Marshal(root); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to encode upgraded RTM data: %w", err)
+			}
+			ext = ".json"
 		}
+	}
 
-		// Debug: Check what was parsed
-		fmt.Printf("DEBUG: After parsing - found %d scopes\n", len(rtmData.Scopes))
-		if len(rtmData.Scopes) > 0 && len(rtmData.Scopes[0].UserStories) > 0 {
-			fmt.Printf("DEBUG: First user story in first scope: %+v\n", rtmData.Scopes[0].UserStories[0])
+	// Parse based on file extension
+	var rtmData models.RTMData
+	var parseErr error
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(data, &rtmData); err != nil {
+			parseErr = fmt.Errorf("failed to parse JSON: %w", err)
 		}
 	case ".yaml", ".yml":
 		if err := yaml.Unmarshal(data, &rtmData); err != nil {
-			return fmt.Errorf("failed to parse YAML: %w", err)
+			parseErr = fmt.Errorf("failed to parse YAML: %w", err)
 		}
-	default:
-		return fmt.Errorf("unsupported file format: %s (use .json, .yaml, or .yml)", ext)
+	}
+	if parseErr != nil {
+		// A wrongly typed field usually explains the failure better than the decoder does
+		if len(issues) > 0 {
+			return nil, nil, nil, &ValidationError{Issues: issues}
+		}
+		return nil, nil, nil, parseErr
 	}
 
 	// Use project from metadata if available, otherwise from top level
@@ -70,10 +305,46 @@ func (imp *Importer) ImportRTMFile(filePath, projectKey string, overwrite bool)
 		rtmData.Project.ID = projectKey
 	}
 
-	return imp.importRTMData(&rtmData, overwrite)
+	slog.Debug("parsed RTM file", "file", filePath, "components", len(rtmData.SystemComponents),
+		"requirements", len(rtmData.Requirements), "scopes", len(rtmData.Scopes), "schema_issues", len(issues))
+
+	return &rtmData, issues, fileWarnings(doc, &rtmData), nil
 }
 
-func (imp *Importer) importRTMData(rtmData *models.RTMData, overwrite bool) error {
+// yamlValue converts a YAML node tree into the generic values encoding/json
+// produces. Scalars stay strings, as the typed decoder accepts an unquoted
+// 1.0 or true for a string field.
+func yamlValue(n *yaml.Node) interface{} {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return yamlValue(n.Content[0])
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			m[n.Content[i].Value] = yamlValue(n.Content[i+1])
+		}
+		return m
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			list = append(list, yamlValue(item))
+		}
+		return list
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil
+		}
+		return n.Value
+	}
+	return nil
+}
+
+func (imp *Importer) importRTMData(rtmData *models.RTMData, session *importSession) error {
 	// Start transaction
 	tx, err := imp.db.Begin()
 	if err != nil {
@@ -82,7 +353,7 @@ func (imp *Importer) importRTMData(rtmData *models.RTMData, overwrite bool) erro
 	defer tx.Rollback()
 
 	// Import project
-	if err := imp.importProject(tx, &rtmData.Project, overwrite); err != nil {
+	if err := imp.importProject(tx, &rtmData.Project); err != nil {
 		return fmt.Errorf("failed to import project: %w", err)
 	}
 
@@ -93,8 +364,12 @@ func (imp *Importer) importRTMData(rtmData *models.RTMData, overwrite bool) erro
 		return fmt.Errorf("failed to get project ID: %w", err)
 	}
 
+	if err := startImportRecord(tx, projectID, rtmData, session); err != nil {
+		return err
+	}
+
 	// If overwrite mode, clean up existing project data
-	if overwrite {
+	if session.mode == ModeOverwrite {
 		if err := imp.cleanupProjectData(tx, projectID); err != nil {
 			return fmt.Errorf("failed to cleanup existing project data: %w", err)
 		}
@@ -102,58 +377,62 @@ func (imp *Importer) importRTMData(rtmData *models.RTMData, overwrite bool) erro
 
 	// Import system components
 	componentMap := make(map[string]string) // component_key -> component_id
-	fmt.Printf("DEBUG: Found %d components to import\n", len(rtmData.SystemComponents))
-	for _, component := range rtmData.SystemComponents {
-		fmt.Printf("DEBUG: Importing component %s (%s)\n", component.ID, component.Name)
-		componentID, err := imp.importComponent(tx, projectID, &component)
+	for i, component := range rtmData.SystemComponents {
+		componentID, err := imp.importComponent(tx, projectID, &component, session)
 		if err != nil {
-			fmt.Printf("DEBUG: Failed to import component %s: %v\n", component.ID, err)
-			return fmt.Errorf("failed to import component %s: %w", component.ID, err)
+			return session.fail(fmt.Sprintf("components[%d]", i), fmt.Errorf("failed to import component %s: %w", component.ID, err))
 		}
 		componentMap[component.ID] = componentID
-		fmt.Printf("DEBUG: Successfully imported component %s with ID %s\n", component.ID, componentID)
+		slog.Debug("imported component", "key", component.ID, "id", componentID)
 	}
-	fmt.Printf("DEBUG: Component map has %d entries\n", len(componentMap))
 
 	// Import requirements hierarchically (legacy flat format)
-	for _, req := range rtmData.Requirements {
-		if err := imp.importRequirement(tx, projectID, componentMap[req.ComponentID], &req, "", overwrite); err != nil {
-			return fmt.Errorf("failed to import requirement %s: %w", req.ID, err)
+	for i, req := range rtmData.Requirements {
+		path := fmt.Sprintf("requirements[%d]", i)
+		componentID, exists := componentMap[req.ComponentID]
+		if !exists {
+			return session.fail(path+".component_id", fmt.Errorf("requirement %s references unknown component %s", req.ID, req.ComponentID))
+		}
+		if err := imp.importRequirement(tx, projectID, componentID, &req, "", session); err != nil {
+			return session.fail(path, fmt.Errorf("failed to import requirement %s: %w", req.ID, err))
 		}
 	}
 
 	// Import scopes hierarchically (new nested format)
-	fmt.Printf("DEBUG: Found %d scopes in JSON\n", len(rtmData.Scopes))
 	for i, scope := range rtmData.Scopes {
-		fmt.Printf("DEBUG: Scope %d: %s has %d user stories, ComponentID: %s\n", i, scope.ID, len(scope.UserStories), scope.ComponentID)
-		for j, userStory := range scope.UserStories {
-			fmt.Printf("DEBUG: User story %d: %s has %d tech specs\n", j, userStory.ID, len(userStory.TechSpecs))
-		}
+		path := fmt.Sprintf("scopes[%d]", i)
+		slog.Debug("importing scope", "key", scope.ID, "component", scope.ComponentID, "user_stories", len(scope.UserStories))
 		componentID, exists := componentMap[scope.ComponentID]
 		if !exists {
-			fmt.Printf("DEBUG: WARNING - Component ID %s not found in component map for scope %s\n", scope.ComponentID, scope.ID)
-			// Try to find a default component or create one
-			if len(componentMap) > 0 {
-				// Use the first component as fallback
-				for _, cid := range componentMap {
-					componentID = cid
-					fmt.Printf("DEBUG: Using fallback component ID %s\n", componentID)
-					break
-				}
-			}
+			return session.fail(path+".component_id", fmt.Errorf("scope %s references unknown component %s", scope.ID, scope.ComponentID))
 		}
-		if err := imp.importScope(tx, projectID, componentID, &scope, overwrite); err != nil {
-			return fmt.Errorf("failed to import scope %s: %w", scope.ID, err)
+		if err := imp.importScope(tx, projectID, componentID, &scope, session); err != nil {
+			return session.fail(path, fmt.Errorf("failed to import scope %s: %w", scope.ID, err))
 		}
 	}
 
 	// Import API endpoints
-	for _, endpoint := range rtmData.APIEndpoints {
-		if err := imp.importAPIEndpoint(tx, projectID, &endpoint); err != nil {
-			return fmt.Errorf("failed to import API endpoint %s %s: %w", endpoint.Method, endpoint.Path, err)
+	for i, endpoint := range rtmData.APIEndpoints {
+		if err := imp.importAPIEndpoint(tx, projectID, &endpoint, session); err != nil {
+			return session.fail(fmt.Sprintf("api_endpoints[%d]", i),
+				fmt.Errorf("failed to import API endpoint %s %s: %w", endpoint.Method, endpoint.Path, err))
+		}
+	}
+
+	// Overwrite mode already removed everything the file does not contain
+	if session.prune != "" && session.mode != ModeOverwrite {
+		if err := imp.prune(tx, projectID, rtmData, session); err != nil {
+			return fmt.Errorf("failed to prune records missing from the file: %w", err)
 		}
 	}
 
+	if err := finishImportRecord(tx, session); err != nil {
+		return err
+	}
+
+	if session.dryRun {
+		return nil
+	}
 	return tx.Commit()
 }
 
@@ -212,16 +491,17 @@ func (imp *Importer) cleanupProjectData(tx database.Tx, projectID string) error
 	return nil
 }
 
-// cleanupRequirementData removes implementation and test data for a specific requirement
+// cleanupRequirementData removes the implementation and test data a previous
+// import wrote for a specific requirement
 func (imp *Importer) cleanupRequirementData(tx database.Tx, requirementID string) error {
-	// Delete requirement test coverage
-	_, err := tx.Exec("DELETE FROM requirement_test_coverage WHERE requirement_id = ?", requirementID)
+	// Delete the test links of earlier imports; scanned ones stay
+	_, err := tx.Exec("DELETE FROM requirement_test_coverage WHERE requirement_id = ? AND source = 'import'", requirementID)
 	if err != nil {
 		return fmt.Errorf("failed to delete requirement test coverage: %w", err)
 	}
 
-	// Delete implementations for this requirement
-	_, err = tx.Exec("DELETE FROM implementations WHERE requirement_id = ?", requirementID)
+	// Delete the implementations of earlier imports; scanned ones stay
+	_, err = tx.Exec("DELETE FROM implementations WHERE requirement_id = ? AND source = 'import'", requirementID)
 	if err != nil {
 		return fmt.Errorf("failed to delete implementations: %w", err)
 	}
@@ -229,7 +509,7 @@ func (imp *Importer) cleanupRequirementData(tx database.Tx, requirementID string
 	return nil
 }
 
-func (imp *Importer) importProject(tx database.Tx, project *models.Project, overwrite bool) error {
+func (imp *Importer) importProject(tx database.Tx, project *models.Project) error {
 	// Check if project exists
 	var count int
 	err := tx.QueryRow("SELECT COUNT(*) FROM projects WHERE project_key = ?", project.ID).Scan(&count)
@@ -252,7 +532,7 @@ func (imp *Importer) importProject(tx database.Tx, project *models.Project, over
 	return err
 }
 
-func (imp *Importer) importComponent(tx database.Tx, projectID string, component *models.SystemComponent) (string, error) {
+func (imp *Importer) importComponent(tx database.Tx, projectID string, component *models.SystemComponent, session *importSession) (string, error) {
 	// Check if component exists
 	var componentID string
 	err := tx.QueryRow("SELECT id FROM system_components WHERE project_id = ? AND component_key = ?",
@@ -266,21 +546,35 @@ func (imp *Importer) importComponent(tx database.Tx, projectID string, component
 		}
 
 		// Insert new component and get its generated ID
-		query := `INSERT INTO system_components (project_id, component_key, name, component_type, technology, description, tags)
-				  VALUES (?, ?, ?, ?, ?, ?, ?)
+		query := `INSERT INTO system_components (project_id, component_key, name, component_type, technology, description, tags, path, base_path, source)
+				  VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), 'import')
 				  RETURNING id`
 		err = tx.QueryRow(query, projectID, component.ID, component.Name, component.ComponentType,
-			component.Technology, component.Description, tagsJSON).Scan(&componentID)
+			component.Technology, component.Description, tagsJSON, component.Path, component.BasePath).Scan(&componentID)
 		if err != nil {
 			return "", err
 		}
+		session.count("component", "created")
 		return componentID, nil
 	}
 
+	// Present in the file again: undo an earlier prune mark
+	if _, err := tx.Exec("UPDATE system_components SET pruned_at = NULL WHERE id = ?", componentID); err != nil {
+		return "", err
+	}
+
+	// Where the component lives follows the checkout, so the file wins
+	if component.Path != "" || component.BasePath != "" {
+		if _, err := tx.Exec(`UPDATE system_components SET path = COALESCE(NULLIF(?, ''), path), base_path = COALESCE(NULLIF(?, ''), base_path)
+			WHERE id = ?`, component.Path, component.BasePath, componentID); err != nil {
+			return "", err
+		}
+	}
+
 	return componentID, nil
 }
 
-func (imp *Importer) importRequirement(tx database.Tx, projectID, componentID string, req *models.Requirement, parentID string, overwrite bool) error {
+func (imp *Importer) importRequirement(tx database.Tx, projectID, componentID string, req *models.Requirement, parentID string, session *importSession) error {
 	// Marshal acceptance criteria to JSON
 	criteriaJSON, err := req.MarshalAcceptanceCriteriaJSON()
 	if err != nil {
@@ -294,18 +588,20 @@ func (imp *Importer) importRequirement(tx database.Tx, projectID, componentID st
 
 	var reqIDStr string
 
-	if overwrite {
+	if session.mode == ModeOverwrite {
 		// In overwrite mode, always insert (old data was already cleaned up)
 		query := `INSERT INTO requirements (project_id, component_id, parent_requirement_id, requirement_key,
-			  requirement_type, title, description, category, priority, status, acceptance_criteria)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  requirement_type, title, description, category, priority, status, acceptance_criteria, source, last_import_id)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'import', ?)
 			  RETURNING id`
 
 		err := tx.QueryRow(query, projectID, componentID, parentIDPtr, req.ID, req.RequirementType,
-			req.Title, req.Description, req.Category, req.Priority, req.Status, criteriaJSON).Scan(&reqIDStr)
+			req.Title, req.Description, req.Category, req.Priority, req.Status, criteriaJSON,
+			session.report.ImportID).Scan(&reqIDStr)
 		if err != nil {
 			return err
 		}
+		session.count("requirement", "created")
 	} else {
 		// In update mode, check if requirement exists and update or insert
 		var existingID string
@@ -315,53 +611,78 @@ func (imp *Importer) importRequirement(tx database.Tx, projectID, componentID st
 		if err != nil {
 			// Requirement doesn't exist, insert new
 			query := `INSERT INTO requirements (project_id, component_id, parent_requirement_id, requirement_key,
-				  requirement_type, title, description, category, priority, status, acceptance_criteria)
-				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				  requirement_type, title, description, category, priority, status, acceptance_criteria, source, last_import_id)
+				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'import', ?)
 				  RETURNING id`
 
 			err := tx.QueryRow(query, projectID, componentID, parentIDPtr, req.ID, req.RequirementType,
-				req.Title, req.Description, req.Category, req.Priority, req.Status, criteriaJSON).Scan(&reqIDStr)
+				req.Title, req.Description, req.Category, req.Priority, req.Status, criteriaJSON,
+				session.report.ImportID).Scan(&reqIDStr)
 			if err != nil {
 				return err
 			}
+			session.count("requirement", "created")
 		} else {
-			// Requirement exists, update it
+			// Requirement exists, update it. Merge mode keeps fields a human
+			// edited since the last import.
+			reqIDStr = existingID
+			values := valuesFromModel(req)
+			if session.mode == ModeMerge {
+				current, err := loadCurrentValues(tx, existingID)
+				if err != nil {
+					return fmt.Errorf("failed to load current values: %w", err)
+				}
+				audit, err := loadAuditState(tx, existingID)
+				if err != nil {
+					return err
+				}
+				values = session.mergeValues(req.ID, current, values, audit)
+			}
+
 			query := `UPDATE requirements SET component_id = ?, parent_requirement_id = ?, requirement_type = ?,
 				  title = ?, description = ?, category = ?, priority = ?, status = ?, acceptance_criteria = ?,
-				  updated_at = datetime('now')
+				  pruned_at = NULL, last_import_id = ?, updated_at = datetime('now')
 				  WHERE id = ?`
 
 			_, err = tx.Exec(query, componentID, parentIDPtr, req.RequirementType,
-				req.Title, req.Description, req.Category, req.Priority, req.Status, criteriaJSON, existingID)
+				values["title"], values["description"], values["category"], values["priority"], values["status"],
+				values["acceptance_criteria"], session.report.ImportID, existingID)
 			if err != nil {
 				return err
 			}
-			reqIDStr = existingID
+			session.count("requirement", "updated")
 
-			// Clean up existing implementation and test data for this requirement
-			if err := imp.cleanupRequirementData(tx, reqIDStr); err != nil {
-				return fmt.Errorf("failed to cleanup existing requirement data: %w", err)
+			// Clean up existing implementation and test data for this requirement;
+			// merge mode adds to it instead
+			if session.mode != ModeMerge {
+				if err := imp.cleanupRequirementData(tx, reqIDStr); err != nil {
+					return fmt.Errorf("failed to cleanup existing requirement data: %w", err)
+				}
 			}
 		}
 	}
 
-	// Import implementation if present
+	if err := logImport(tx, reqIDStr, session.mode, req); err != nil {
+		return fmt.Errorf("failed to record import in change history: %w", err)
+	}
+
+	// Import implementation if present, next to the rows that were kept
 	if req.Implementation != nil {
-		if err := imp.importImplementation(tx, reqIDStr, req.Implementation); err != nil {
+		if err := mergeImplementation(tx, reqIDStr, req.Implementation); err != nil {
 			return fmt.Errorf("failed to import implementation: %w", err)
 		}
 	}
 
 	// Import test coverage if present
 	if req.Tests != nil {
-		if err := imp.importTestCoverage(tx, projectID, reqIDStr, req.Tests); err != nil {
+		if err := imp.importTestCoverage(tx, projectID, reqIDStr, req.Tests, session); err != nil {
 			return fmt.Errorf("failed to import test coverage: %w", err)
 		}
 	}
 
 	// Recursively import children
 	for _, child := range req.Children {
-		if err := imp.importRequirement(tx, projectID, componentID, &child, reqIDStr, overwrite); err != nil {
+		if err := imp.importRequirement(tx, projectID, componentID, &child, reqIDStr, session); err != nil {
 			return fmt.Errorf("failed to import child requirement %s: %w", child.ID, err)
 		}
 	}
@@ -369,91 +690,36 @@ func (imp *Importer) importRequirement(tx database.Tx, projectID, componentID st
 	return nil
 }
 
-func (imp *Importer) importImplementation(tx database.Tx, requirementID string, impl *models.Implementation) error {
-	// Import backend implementation
-	if impl.Backend != nil {
-		for _, file := range impl.Backend.Files {
-			functionsJSON, err := models.MarshalStringSliceJSON(file.Functions)
-			if err != nil {
-				return err
-			}
-
-			query := `INSERT INTO implementations (requirement_id, layer, file_path, functions)
-					  VALUES (?, 'backend', ?, ?)`
-			_, err = tx.Exec(query, requirementID, file.Path, functionsJSON)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Import frontend implementation
-	if impl.Frontend != nil {
-		for _, file := range impl.Frontend.Files {
-			functionsJSON, err := models.MarshalStringSliceJSON(file.Functions)
-			if err != nil {
-				return err
-			}
-
-			query := `INSERT INTO implementations (requirement_id, layer, file_path, functions)
-					  VALUES (?, 'frontend', ?, ?)`
-			_, err = tx.Exec(query, requirementID, file.Path, functionsJSON)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Import database implementation
-	if impl.Database != nil {
-		for _, file := range impl.Database.Files {
-			functionsJSON, err := models.MarshalStringSliceJSON(file.Functions)
-			if err != nil {
-				return err
-			}
-
-			query := `INSERT INTO implementations (requirement_id, layer, file_path, functions)
-					  VALUES (?, 'database', ?, ?)`
-			_, err = tx.Exec(query, requirementID, file.Path, functionsJSON)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-func (imp *Importer) importTestCoverage(tx database.Tx, projectID, requirementID string, tests *models.TestCoverage) error {
+func (imp *Importer) importTestCoverage(tx database.Tx, projectID, requirementID string, tests *models.TestCoverage, session *importSession) error {
 	// Import backend tests
-	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "unit", tests.Backend); err != nil {
+	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "unit", tests.Backend, session); err != nil {
 		return err
 	}
 
 	// Import frontend tests
-	if err := imp.importTestFiles(tx, projectID, requirementID, "frontend", "unit", tests.Frontend); err != nil {
+	if err := imp.importTestFiles(tx, projectID, requirementID, "frontend", "unit", tests.Frontend, session); err != nil {
 		return err
 	}
 
 	// Import unit tests
-	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "unit", tests.UnitTests); err != nil {
+	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "unit", tests.UnitTests, session); err != nil {
 		return err
 	}
 
 	// Import integration tests
-	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "integration", tests.IntegrationTests); err != nil {
+	if err := imp.importTestFiles(tx, projectID, requirementID, "backend", "integration", tests.IntegrationTests, session); err != nil {
 		return err
 	}
 
 	// Import e2e tests
-	if err := imp.importTestFiles(tx, projectID, requirementID, "frontend", "e2e", tests.E2ETests); err != nil {
+	if err := imp.importTestFiles(tx, projectID, requirementID, "frontend", "e2e", tests.E2ETests, session); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (imp *Importer) importTestFiles(tx database.Tx, projectID, requirementID, layer, testType string, testFiles []models.TestFile) error {
+func (imp *Importer) importTestFiles(tx database.Tx, projectID, requirementID, layer, testType string, testFiles []models.TestFile, session *importSession) error {
 	for _, testFile := range testFiles {
 		// Ensure test file exists in test_files table
 		testFileID, err := imp.ensureTestFile(tx, projectID, testFile.File, layer, testType)
@@ -469,9 +735,17 @@ func (imp *Importer) importTestFiles(tx database.Tx, projectID, requirementID, l
 			}
 
 			// Link test case to requirement
-			query := `INSERT OR IGNORE INTO requirement_test_coverage (requirement_id, test_case_id, coverage_type)
-					  VALUES (?, ?, 'requirement')`
-			_, err = tx.Exec(query, requirementID, testCaseID)
+			query := `INSERT OR IGNORE INTO requirement_test_coverage (requirement_id, test_case_id, coverage_type, source)
+					  VALUES (?, ?, 'requirement', 'import')`
+			res, err := tx.Exec(query, requirementID, testCaseID)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				session.count("test_link", "created")
+			}
+			_, err = tx.Exec("UPDATE requirement_test_coverage SET pruned_at = NULL WHERE requirement_id = ? AND test_case_id = ?",
+				requirementID, testCaseID)
 			if err != nil {
 				return err
 			}
@@ -528,18 +802,26 @@ func (imp *Importer) ensureTestCase(tx database.Tx, testFileID, testName, testTy
 	return testCaseID, nil
 }
 
-func (imp *Importer) importAPIEndpoint(tx database.Tx, projectID string, endpoint *models.APIEndpoint) error {
-	query := `INSERT OR IGNORE INTO api_endpoints (project_id, method, path, handler_file, handler_function, description)
-			  VALUES (?, ?, ?, ?, ?, ?)`
+func (imp *Importer) importAPIEndpoint(tx database.Tx, projectID string, endpoint *models.APIEndpoint, session *importSession) error {
+	query := `INSERT OR IGNORE INTO api_endpoints (project_id, method, path, handler_file, handler_function, description, source)
+			  VALUES (?, ?, ?, ?, ?, ?, 'import')`
 
-	_, err := tx.Exec(query, projectID, endpoint.Method, endpoint.Path,
+	res, err := tx.Exec(query, projectID, endpoint.Method, endpoint.Path,
 		endpoint.Handler, endpoint.Handler, endpoint.Description)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		session.count("api_endpoint", "created")
+	}
 
+	_, err = tx.Exec("UPDATE api_endpoints SET pruned_at = NULL WHERE project_id = ? AND method = ? AND path = ?",
+		projectID, endpoint.Method, endpoint.Path)
 	return err
 }
 
 // importScope converts nested scopes structure to flat requirements format
-func (imp *Importer) importScope(tx database.Tx, projectID, componentID string, scope *models.Scope, overwrite bool) error {
+func (imp *Importer) importScope(tx database.Tx, projectID, componentID string, scope *models.Scope, session *importSession) error {
 	// Convert scope to requirement (SCOPE type)
 	scopeReq := models.Requirement{
 		ID:              scope.ID,
@@ -553,7 +835,7 @@ func (imp *Importer) importScope(tx database.Tx, projectID, componentID string,
 	}
 
 	// Import the scope as a requirement
-	if err := imp.importRequirement(tx, projectID, componentID, &scopeReq, "", overwrite); err != nil {
+	if err := imp.importRequirement(tx, projectID, componentID, &scopeReq, "", session); err != nil {
 		return fmt.Errorf("failed to import scope requirement: %w", err)
 	}
 
@@ -567,7 +849,7 @@ func (imp *Importer) importScope(tx database.Tx, projectID, componentID string,
 
 	// Import user stories under this scope
 	for _, userStory := range scope.UserStories {
-		if err := imp.importUserStory(tx, projectID, componentID, &userStory, scopeReqID, overwrite); err != nil {
+		if err := imp.importUserStory(tx, projectID, componentID, &userStory, scopeReqID, session); err != nil {
 			return fmt.Errorf("failed to import user story %s: %w", userStory.ID, err)
 		}
 	}
@@ -576,7 +858,7 @@ func (imp *Importer) importScope(tx database.Tx, projectID, componentID string,
 }
 
 // importUserStory converts user story to requirement format
-func (imp *Importer) importUserStory(tx database.Tx, projectID, componentID string, userStory *models.UserStory, parentID string, overwrite bool) error {
+func (imp *Importer) importUserStory(tx database.Tx, projectID, componentID string, userStory *models.UserStory, parentID string, session *importSession) error {
 	// Convert user story to requirement (USER_STORY type)
 	userStoryReq := models.Requirement{
 		ID:              userStory.ID,
@@ -590,7 +872,7 @@ func (imp *Importer) importUserStory(tx database.Tx, projectID, componentID stri
 	}
 
 	// Import the user story as a requirement
-	if err := imp.importRequirement(tx, projectID, componentID, &userStoryReq, parentID, overwrite); err != nil {
+	if err := imp.importRequirement(tx, projectID, componentID, &userStoryReq, parentID, session); err != nil {
 		return fmt.Errorf("failed to import user story requirement: %w", err)
 	}
 
@@ -603,23 +885,17 @@ func (imp *Importer) importUserStory(tx database.Tx, projectID, componentID stri
 	}
 
 	// Import tech specs under this user story
-	fmt.Printf("DEBUG: User story %s has %d tech specs\n", userStory.ID, len(userStory.TechSpecs))
 	for _, techSpec := range userStory.TechSpecs {
-		fmt.Printf("DEBUG: Importing tech spec %s\n", techSpec.ID)
-		if err := imp.importTechSpec(tx, projectID, componentID, &techSpec, userStoryReqID, overwrite); err != nil {
-			fmt.Printf("DEBUG: Failed to import tech spec %s: %v\n", techSpec.ID, err)
+		if err := imp.importTechSpec(tx, projectID, componentID, &techSpec, userStoryReqID, session); err != nil {
 			return fmt.Errorf("failed to import tech spec %s: %w", techSpec.ID, err)
 		}
-		fmt.Printf("DEBUG: Successfully imported tech spec %s\n", techSpec.ID)
 	}
 
 	return nil
 }
 
 // importTechSpec converts tech spec to requirement format
-func (imp *Importer) importTechSpec(tx database.Tx, projectID, componentID string, techSpec *models.TechSpec, parentID string, overwrite bool) error {
-	fmt.Printf("DEBUG: importTechSpec called with techSpec.ID=%s, projectID=%s, componentID=%s, parentID=%s\n", techSpec.ID, projectID, componentID, parentID)
-
+func (imp *Importer) importTechSpec(tx database.Tx, projectID, componentID string, techSpec *models.TechSpec, parentID string, session *importSession) error {
 	// Convert tech spec to requirement (TECH_SPEC type)
 	techSpecReq := models.Requirement{
 		ID:                 techSpec.ID,
@@ -635,14 +911,12 @@ func (imp *Importer) importTechSpec(tx database.Tx, projectID, componentID strin
 		Tests:              techSpec.TestCoverage,
 	}
 
-	fmt.Printf("DEBUG: About to call importRequirement for tech spec %s\n", techSpec.ID)
 	// Import the tech spec as a requirement
-	if err := imp.importRequirement(tx, projectID, componentID, &techSpecReq, parentID, overwrite); err != nil {
-		fmt.Printf("DEBUG: importRequirement failed for tech spec %s: %v\n", techSpec.ID, err)
+	if err := imp.importRequirement(tx, projectID, componentID, &techSpecReq, parentID, session); err != nil {
 		return fmt.Errorf("failed to import tech spec requirement: %w", err)
 	}
 
-	fmt.Printf("DEBUG: Successfully imported tech spec requirement %s\n", techSpec.ID)
+	slog.Debug("imported tech spec", "key", techSpec.ID, "parent", parentID)
 	return nil
 }
 
@@ -0,0 +1,340 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Mode selects how an import treats data that is already in the database
+type Mode string
+
+const (
+	// ModeUpdate overwrites every field of matched requirements with the file
+	ModeUpdate Mode = "update"
+	// ModeOverwrite deletes all project data before importing
+	ModeOverwrite Mode = "overwrite"
+	// ModeMerge keeps fields a human edited since the last import and applies
+	// the file to everything else
+	ModeMerge Mode = "merge"
+)
+
+// ParseMode accepts "update", "overwrite" or "merge"; empty means update
+func ParseMode(value string) (Mode, error) {
+	switch Mode(strings.ToLower(value)) {
+	case "", ModeUpdate:
+		return ModeUpdate, nil
+	case ModeOverwrite:
+		return ModeOverwrite, nil
+	case ModeMerge:
+		return ModeMerge, nil
+	}
+	return "", fmt.Errorf("unknown import mode %q (use update, overwrite or merge)", value)
+}
+
+// Conflict resolutions
+const (
+	ResolveFile     = "file"     // take the value from the imported file
+	ResolveDatabase = "database" // keep the human edit
+)
+
+// changedByImporter marks requirement_changes rows written by imports. Every
+// other author is treated as a human edit.
+const changedByImporter = "importer"
+
+// Options controls a single import
+type Options struct {
+	Mode Mode
+	// Resolutions maps Conflict.ID() to ResolveFile or ResolveDatabase (merge mode)
+	Resolutions map[string]string
+	// Prune is PruneDelete or PruneMark to handle imported records the file no
+	// longer contains; empty leaves them alone
+	Prune string
+	// Filename is recorded in the import history instead of the file's base
+	// name, e.g. the original name of an uploaded file
+	Filename string
+	// Mapping maps the columns of a CSV or XLSX backlog; nil uses DefaultColumnMapping
+	Mapping *ColumnMapping
+}
+
+// Conflict is a field that was edited by a human after the last import and
+// also changed by the file being imported
+type Conflict struct {
+	RequirementKey string `json:"requirement_key"`
+	Field          string `json:"field"`
+	Base           string `json:"base"`     // value written by the last import, empty if unknown
+	Database       string `json:"database"` // the human edit currently stored
+	File           string `json:"file"`     // value in the file being imported
+	Resolution     string `json:"resolution,omitempty"`
+}
+
+// ID identifies the conflict in Options.Resolutions
+func (c Conflict) ID() string {
+	return c.RequirementKey + ":" + c.Field
+}
+
+// importSession carries the options and findings of one importRTMData call
+type importSession struct {
+	mode        Mode
+	resolutions map[string]string
+	prune       string
+	dryRun      bool // roll back instead of committing
+	source      importSource
+	started     time.Time
+	report      *ImportReport
+}
+
+// mergeFields are the requirement fields humans can edit in the UI, keyed by
+// their name in the requirement_changes JSON
+var mergeFields = []string{"title", "description", "category", "priority", "status", "acceptance_criteria"}
+
+// requirementValues is the merge view of a requirement's editable fields
+type requirementValues map[string]string
+
+func valuesFromModel(req *models.Requirement) requirementValues {
+	return requirementValues{
+		"title":               req.Title,
+		"description":         req.Description,
+		"category":            req.Category,
+		"priority":            req.Priority,
+		"status":              req.Status,
+		"acceptance_criteria": criteriaValue(req.AcceptanceCriteria),
+	}
+}
+
+// criteriaValue encodes acceptance criteria the way the requirements table stores them
+func criteriaValue(criteria []string) string {
+	value, _ := models.MarshalStringSliceJSON(criteria)
+	return value
+}
+
+// valuesFromAudit reads the fields of a requirement_changes JSON snapshot, as
+// written by database.logRequirementChange or logImport
+func valuesFromAudit(data string) (requirementValues, bool) {
+	if data == "" {
+		return nil, false
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, false
+	}
+	values := requirementValues{}
+	for _, field := range mergeFields {
+		switch v := raw[field].(type) {
+		case string:
+			values[field] = v
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[field] = criteriaValue(items)
+		default:
+			if field == "acceptance_criteria" {
+				values[field] = criteriaValue(nil)
+			} else {
+				values[field] = ""
+			}
+		}
+	}
+	return values, true
+}
+
+// auditState summarises the requirement_changes history of one requirement
+type auditState struct {
+	base   requirementValues // values written by the last import, nil if never imported
+	edited map[string]bool   // fields a human changed after the last import
+}
+
+func loadAuditState(tx database.Tx, requirementID string) (*auditState, error) {
+	var changes []struct {
+		ChangeType string `json:"change_type"`
+		ChangedBy  string `json:"changed_by"`
+		OldValues  string `json:"old_values"`
+		NewValues  string `json:"new_values"`
+	}
+	err := queryRowsJSON(tx, `
+		SELECT COALESCE(json_group_array(json_object(
+			'change_type', change_type, 'changed_by', changed_by,
+			'old_values', COALESCE(old_values, ''), 'new_values', COALESCE(new_values, ''))), '[]')
+		FROM (SELECT * FROM requirement_changes WHERE requirement_id = ? ORDER BY created_at, rowid)`,
+		&changes, requirementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load change history: %w", err)
+	}
+
+	state := &auditState{edited: make(map[string]bool)}
+	for _, change := range changes {
+		if change.ChangedBy == changedByImporter {
+			state.base, _ = valuesFromAudit(change.NewValues)
+			state.edited = make(map[string]bool)
+			continue
+		}
+		switch change.ChangeType {
+		case "created":
+			// Created by hand: every field is a human decision
+			for _, field := range mergeFields {
+				state.edited[field] = true
+			}
+		case "updated", "status_changed":
+			oldValues, okOld := valuesFromAudit(change.OldValues)
+			newValues, okNew := valuesFromAudit(change.NewValues)
+			if !okOld || !okNew {
+				continue
+			}
+			for _, field := range mergeFields {
+				if oldValues[field] != newValues[field] {
+					state.edited[field] = true
+				}
+			}
+		}
+	}
+	return state, nil
+}
+
+// mergeValues decides, field by field, what a merge import writes for an
+// existing requirement. Conflicts are recorded on the session.
+func (session *importSession) mergeValues(key string, current, incoming requirementValues, audit *auditState) requirementValues {
+	merged := requirementValues{}
+	for _, field := range mergeFields {
+		merged[field] = incoming[field]
+		// A kept human edit still differs from the last imported value, even
+		// though the audit trail shows an import after it
+		edited := audit.edited[field] || (audit.base != nil && current[field] != audit.base[field])
+		if !edited || current[field] == incoming[field] {
+			continue
+		}
+
+		// The file still says what the last import wrote: keep the human edit
+		if audit.base != nil && audit.base[field] == incoming[field] {
+			merged[field] = current[field]
+			continue
+		}
+
+		conflict := Conflict{
+			RequirementKey: key,
+			Field:          field,
+			Base:           audit.base[field],
+			Database:       current[field],
+			File:           incoming[field],
+		}
+		switch session.resolutions[conflict.ID()] {
+		case ResolveFile:
+			conflict.Resolution = ResolveFile
+		case ResolveDatabase:
+			conflict.Resolution = ResolveDatabase
+			merged[field] = current[field]
+		default:
+			merged[field] = current[field]
+		}
+		session.report.Conflicts = append(session.report.Conflicts, conflict)
+	}
+	return merged
+}
+
+// loadCurrentValues reads the editable fields of a stored requirement
+func loadCurrentValues(tx database.Tx, requirementID string) (requirementValues, error) {
+	var title, description, category, priority, status, criteriaJSON string
+	err := tx.QueryRow(`SELECT title, COALESCE(description, ''), COALESCE(category, ''), COALESCE(priority, ''),
+		COALESCE(status, ''), COALESCE(acceptance_criteria, '[]') FROM requirements WHERE id = ?`,
+		requirementID).Scan(&title, &description, &category, &priority, &status, &criteriaJSON)
+	if err != nil {
+		return nil, err
+	}
+	criteria, _ := models.UnmarshalStringSliceJSON(criteriaJSON)
+	return requirementValues{
+		"title":               title,
+		"description":         description,
+		"category":            category,
+		"priority":            priority,
+		"status":              status,
+		"acceptance_criteria": criteriaValue(criteria),
+	}, nil
+}
+
+// logImport records what the file said about a requirement. The next merge
+// import uses it as the common base.
+func logImport(tx database.Tx, requirementID string, mode Mode, req *models.Requirement) error {
+	criteria := req.AcceptanceCriteria
+	if criteria == nil {
+		criteria = []string{}
+	}
+	snapshot, err := json.Marshal(map[string]interface{}{
+		"requirement_key":     req.ID,
+		"requirement_type":    req.RequirementType,
+		"title":               req.Title,
+		"description":         req.Description,
+		"category":            req.Category,
+		"priority":            req.Priority,
+		"status":              req.Status,
+		"acceptance_criteria": criteria,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal import snapshot: %w", err)
+	}
+
+	query := `INSERT INTO requirement_changes (requirement_id, change_type, new_values, changed_by, change_reason, created_at)
+			  VALUES (?, 'imported', ?, ?, ?, ?)`
+	_, err = tx.Exec(query, requirementID, string(snapshot), changedByImporter,
+		fmt.Sprintf("%s import", mode), time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// mergeImplementation adds the file's implementation rows without dropping
+// rows that came from elsewhere (scans, manual edits). Functions of rows that
+// exist on both sides are unioned; line ranges and sources are kept.
+func mergeImplementation(tx database.Tx, requirementID string, impl *models.Implementation) error {
+	for _, row := range implementationRows(impl) {
+		var existingID, existingFunctions string
+		err := tx.QueryRow(`SELECT id, COALESCE(functions, '[]') FROM implementations
+			WHERE requirement_id = ? AND layer = ? AND file_path = ? LIMIT 1`,
+			requirementID, row.Layer, row.FilePath).Scan(&existingID, &existingFunctions)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err != nil {
+			functionsJSON, err := models.MarshalStringSliceJSON(row.Functions)
+			if err != nil {
+				return err
+			}
+			query := `INSERT INTO implementations (requirement_id, layer, file_path, functions, source)
+					  VALUES (?, ?, ?, ?, 'import')`
+			if _, err := tx.Exec(query, requirementID, row.Layer, row.FilePath, functionsJSON); err != nil {
+				return err
+			}
+			continue
+		}
+
+		functions, _ := models.UnmarshalStringSliceJSON(existingFunctions)
+		for _, fn := range row.Functions {
+			if !containsString(functions, fn) {
+				functions = append(functions, fn)
+			}
+		}
+		functionsJSON, err := models.MarshalStringSliceJSON(functions)
+		if err != nil {
+			return err
+		}
+		query := `UPDATE implementations SET functions = ?, updated_at = datetime('now') WHERE id = ?`
+		if _, err := tx.Exec(query, functionsJSON, existingID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestLoadAuditState(t *testing.T) {
+	imported := `{"requirement_key":"REQ-1","title":"Login","description":"","category":"auth","priority":"high","status":"planned","acceptance_criteria":[]}`
+	edited := `{"title":"Sign in","category":"auth","priority":"high","status":"planned"}`
+	original := `{"title":"Login","category":"auth","priority":"high","status":"planned"}`
+	base := requirementValues{
+		"title":               "Login",
+		"description":         "",
+		"category":            "auth",
+		"priority":            "high",
+		"status":              "planned",
+		"acceptance_criteria": "[]",
+	}
+	allFields := make(map[string]bool)
+	for _, field := range mergeFields {
+		allFields[field] = true
+	}
+
+	type change struct{ changeType, changedBy, oldValues, newValues string }
+	tests := []struct {
+		name       string
+		changes    []change
+		wantBase   requirementValues
+		wantEdited map[string]bool
+	}{
+		{"no history", nil, nil, map[string]bool{}},
+		{"untouched since import", []change{
+			{"imported", changedByImporter, "", imported},
+		}, base, map[string]bool{}},
+		{"edited after import", []change{
+			{"imported", changedByImporter, "", imported},
+			{"updated", "system", original, edited},
+		}, base, map[string]bool{"title": true}},
+		{"edited before import", []change{
+			{"updated", "system", original, edited},
+			{"imported", changedByImporter, "", imported},
+		}, base, map[string]bool{}},
+		{"created by hand", []change{
+			{"created", "system", "", original},
+		}, nil, allFields},
+		{"unreadable snapshot", []change{
+			{"imported", changedByImporter, "", imported},
+			{"updated", "system", "not json", edited},
+		}, base, map[string]bool{}},
+	}
+
+	db, err := database.New(filepath.Join(t.TempDir(), "tracevibe.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		`INSERT INTO projects (id, project_key, name) VALUES ('p-1', 'demo', 'Demo')`,
+		`INSERT INTO system_components (id, project_id, component_key, name, component_type) VALUES ('c-1', 'p-1', 'COMP-1', 'API', 'api_server')`,
+		`INSERT INTO requirements (id, project_id, component_id, requirement_key, requirement_type, title, category)
+			VALUES ('req-1', 'p-1', 'c-1', 'REQ-1', 'tech_spec', 'Login', 'auth')`,
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			for i, c := range tt.changes {
+				_, err := tx.Exec(`INSERT INTO requirement_changes (requirement_id, change_type, old_values, new_values, changed_by, created_at)
+					VALUES ('req-1', ?, ?, ?, ?, ?)`, c.changeType, c.oldValues, c.newValues, c.changedBy, i)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			state, err := loadAuditState(tx, "req-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state.base, tt.wantBase) {
+				t.Errorf("base = %v, want %v", state.base, tt.wantBase)
+			}
+			if !reflect.DeepEqual(state.edited, tt.wantEdited) {
+				t.Errorf("edited = %v, want %v", state.edited, tt.wantEdited)
+			}
+		})
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := requirementValues{"title": "Login", "status": "planned"}
+	tests := []struct {
+		name          string
+		current       requirementValues
+		incoming      requirementValues
+		audit         *auditState
+		resolutions   map[string]string
+		want          requirementValues
+		wantConflicts []Conflict
+	}{
+		{
+			name:     "untouched field takes the file",
+			current:  requirementValues{"title": "Login", "status": "planned"},
+			incoming: requirementValues{"title": "Log in", "status": "implemented"},
+			audit:    &auditState{base: base, edited: map[string]bool{}},
+			want:     requirementValues{"title": "Log in", "status": "implemented"},
+		},
+		{
+			name:     "edit kept when the file is unchanged",
+			current:  requirementValues{"title": "Sign in", "status": "planned"},
+			incoming: requirementValues{"title": "Login", "status": "implemented"},
+			audit:    &auditState{base: base, edited: map[string]bool{"title": true}},
+			want:     requirementValues{"title": "Sign in", "status": "implemented"},
+		},
+		{
+			name:     "edit kept across imports",
+			current:  requirementValues{"title": "Sign in", "status": "planned"},
+			incoming: requirementValues{"title": "Login", "status": "planned"},
+			audit:    &auditState{base: base, edited: map[string]bool{}},
+			want:     requirementValues{"title": "Sign in", "status": "planned"},
+		},
+		{
+			name:     "edit matching the file",
+			current:  requirementValues{"title": "Sign in", "status": "planned"},
+			incoming: requirementValues{"title": "Sign in", "status": "planned"},
+			audit:    &auditState{base: base, edited: map[string]bool{"title": true}},
+			want:     requirementValues{"title": "Sign in", "status": "planned"},
+		},
+		{
+			name:     "conflict keeps the edit",
+			current:  requirementValues{"title": "Sign in", "status": "planned"},
+			incoming: requirementValues{"title": "Log in", "status": "planned"},
+			audit:    &auditState{base: base, edited: map[string]bool{"title": true}},
+			want:     requirementValues{"title": "Sign in", "status": "planned"},
+			wantConflicts: []Conflict{
+				{RequirementKey: "REQ-1", Field: "title", Base: "Login", Database: "Sign in", File: "Log in"},
+			},
+		},
+		{
+			name:        "conflict resolved to the file",
+			current:     requirementValues{"title": "Sign in", "status": "planned"},
+			incoming:    requirementValues{"title": "Log in", "status": "planned"},
+			audit:       &auditState{base: base, edited: map[string]bool{"title": true}},
+			resolutions: map[string]string{"REQ-1:title": ResolveFile},
+			want:        requirementValues{"title": "Log in", "status": "planned"},
+			wantConflicts: []Conflict{
+				{RequirementKey: "REQ-1", Field: "title", Base: "Login", Database: "Sign in", File: "Log in", Resolution: ResolveFile},
+			},
+		},
+		{
+			name:        "conflict resolved to the database",
+			current:     requirementValues{"title": "Sign in", "status": "planned"},
+			incoming:    requirementValues{"title": "Log in", "status": "planned"},
+			audit:       &auditState{base: base, edited: map[string]bool{"title": true}},
+			resolutions: map[string]string{"REQ-1:title": ResolveDatabase},
+			want:        requirementValues{"title": "Sign in", "status": "planned"},
+			wantConflicts: []Conflict{
+				{RequirementKey: "REQ-1", Field: "title", Base: "Login", Database: "Sign in", File: "Log in", Resolution: ResolveDatabase},
+			},
+		},
+		{
+			name:     "never imported",
+			current:  requirementValues{"title": "Sign in", "status": "planned"},
+			incoming: requirementValues{"title": "Log in", "status": "planned"},
+			audit:    &auditState{edited: map[string]bool{"title": true, "status": true}},
+			want:     requirementValues{"title": "Sign in", "status": "planned"},
+			wantConflicts: []Conflict{
+				{RequirementKey: "REQ-1", Field: "title", Database: "Sign in", File: "Log in"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &importSession{mode: ModeMerge, resolutions: tt.resolutions, report: &ImportReport{}}
+			got := session.mergeValues("REQ-1", tt.current, tt.incoming, tt.audit)
+			for _, field := range mergeFields {
+				if got[field] != tt.want[field] {
+					t.Errorf("%s = %q, want %q", field, got[field], tt.want[field])
+				}
+			}
+			if !reflect.DeepEqual(session.report.Conflicts, tt.wantConflicts) {
+				t.Errorf("conflicts = %+v, want %+v", session.report.Conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Plan actions
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionMark      = "mark" // kept but flagged as pruned
+	ActionUnchanged = "unchanged"
+	ActionConflict  = "conflict" // merge mode: edited by a human and changed in the file
+)
+
+// PlanEntry describes what an import would do to a single entity
+type PlanEntry struct {
+	Entity string `json:"entity"` // project, component, requirement, implementation, test_link, api_endpoint
+	Key    string `json:"key"`
+	Action string `json:"action"`
+}
+
+// ImportPlan is the result of a dry run: the validation issues found and, per
+// entity, whether it would be created, updated, deleted or left unchanged
+type ImportPlan struct {
+	ProjectKey string            `json:"project_key"`
+	Mode       Mode              `json:"mode"`
+	Overwrite  bool              `json:"overwrite"`
+	Prune      string            `json:"prune,omitempty"`
+	Issues     []ValidationIssue `json:"issues,omitempty"`
+	Entries    []PlanEntry       `json:"entries"`
+}
+
+// Counts tallies plan entries by entity and action
+func (p *ImportPlan) Counts() map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+	for _, entry := range p.Entries {
+		if counts[entry.Entity] == nil {
+			counts[entry.Entity] = make(map[string]int)
+		}
+		counts[entry.Entity][entry.Action]++
+	}
+	return counts
+}
+
+// storedRequirement is the database state of one requirement
+type storedRequirement struct {
+	ID                 string
+	Key                string
+	ParentKey          string
+	ComponentKey       string
+	Type               string
+	Title              string
+	Description        string
+	Category           string
+	Priority           string
+	Status             string
+	AcceptanceCriteria []string
+	Source             string // import, manual or scan
+	Implementations    []implementationRow
+	TestLinks          []testLinkRow
+}
+
+// storedComponent is the database state of one component
+type storedComponent struct {
+	Name          string
+	ComponentType string
+	Technology    string
+	Description   string
+	Tags          []string
+	Source        string
+}
+
+// projectState is everything the importer may touch for one project
+type projectState struct {
+	ProjectID    string
+	Components   map[string]*storedComponent
+	Requirements map[string]*storedRequirement
+	Endpoints    map[string]string // "METHOD path" -> source
+	TestFiles    []string
+}
+
+// loadProjectState reads the current database state of a project. A project
+// that does not exist yet yields an empty state.
+func loadProjectState(db *database.DB, projectKey string) (*projectState, error) {
+	state := &projectState{
+		Components:   make(map[string]*storedComponent),
+		Requirements: make(map[string]*storedRequirement),
+		Endpoints:    make(map[string]string),
+	}
+
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return state, nil
+	}
+	state.ProjectID = project.ID
+
+	rows, err := db.Query(`
+		SELECT component_key, name, component_type, COALESCE(technology, ''), COALESCE(description, ''), COALESCE(tags, '[]'),
+			COALESCE(source, 'manual')
+		FROM system_components WHERE project_id = ?`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load components: %w", err)
+	}
+	for rows.Next() {
+		var key, tagsJSON string
+		var component storedComponent
+		if err := rows.Scan(&key, &component.Name, &component.ComponentType, &component.Technology,
+			&component.Description, &tagsJSON, &component.Source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		component.Tags, _ = models.UnmarshalStringSliceJSON(tagsJSON)
+		state.Components[key] = &component
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT r.id, r.requirement_key, COALESCE(parent.requirement_key, ''), COALESCE(c.component_key, ''),
+			r.requirement_type, r.title, COALESCE(r.description, ''), COALESCE(r.category, ''),
+			COALESCE(r.priority, ''), COALESCE(r.status, ''), COALESCE(r.acceptance_criteria, '[]'),
+			COALESCE(r.source, 'manual')
+		FROM requirements r
+		LEFT JOIN requirements parent ON r.parent_requirement_id = parent.id
+		LEFT JOIN system_components c ON r.component_id = c.id
+		WHERE r.project_id = ?`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirements: %w", err)
+	}
+	byID := make(map[string]*storedRequirement)
+	for rows.Next() {
+		var req storedRequirement
+		var criteriaJSON string
+		if err := rows.Scan(&req.ID, &req.Key, &req.ParentKey, &req.ComponentKey, &req.Type, &req.Title,
+			&req.Description, &req.Category, &req.Priority, &req.Status, &criteriaJSON, &req.Source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		req.AcceptanceCriteria, _ = models.UnmarshalStringSliceJSON(criteriaJSON)
+		state.Requirements[req.Key] = &req
+		byID[req.ID] = &req
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT i.requirement_id, i.layer, i.file_path, COALESCE(i.functions, '[]'), COALESCE(i.source, 'manual')
+		FROM implementations i
+		JOIN requirements r ON i.requirement_id = r.id
+		WHERE r.project_id = ?
+		ORDER BY i.created_at`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load implementations: %w", err)
+	}
+	for rows.Next() {
+		var requirementID, functionsJSON string
+		var impl implementationRow
+		if err := rows.Scan(&requirementID, &impl.Layer, &impl.FilePath, &functionsJSON, &impl.Source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		impl.Functions, _ = models.UnmarshalStringSliceJSON(functionsJSON)
+		if req, ok := byID[requirementID]; ok {
+			req.Implementations = append(req.Implementations, impl)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT rtc.requirement_id, tf.file_path, tc.test_name, COALESCE(tc.test_type, ''), COALESCE(tf.layer, ''),
+			COALESCE(rtc.source, 'manual')
+		FROM requirement_test_coverage rtc
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE tf.project_id = ?`, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test links: %w", err)
+	}
+	for rows.Next() {
+		var requirementID string
+		var link testLinkRow
+		if err := rows.Scan(&requirementID, &link.FilePath, &link.TestName, &link.TestType, &link.Layer, &link.Source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if req, ok := byID[requirementID]; ok {
+			req.TestLinks = append(req.TestLinks, link)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT method, path, COALESCE(source, 'manual') FROM api_endpoints WHERE project_id = ?", project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API endpoints: %w", err)
+	}
+	for rows.Next() {
+		var method, path, source string
+		if err := rows.Scan(&method, &path, &source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		state.Endpoints[method+" "+path] = source
+	}
+	rows.Close()
+
+	return state, nil
+}
+
+// PlanRTMFile parses and validates an RTM file and works out what importing it
+// would change, without writing anything
+func (imp *Importer) PlanRTMFile(filePath, projectKey string, opts Options) (*ImportPlan, error) {
+	file, err := imp.parseFile(filePath, projectKey, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := imp.Plan(file.data, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan.Issues = mergeIssues(file.issues, file.locate(plan.Issues))
+	return plan, nil
+}
+
+// Plan validates rtmData and compares it with the database
+func (imp *Importer) Plan(rtmData *models.RTMData, opts Options) (*ImportPlan, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = ModeUpdate
+	}
+	overwrite := mode == ModeOverwrite
+	plan := &ImportPlan{
+		ProjectKey: rtmData.Project.ID,
+		Mode:       mode,
+		Overwrite:  overwrite,
+		Issues:     Validate(rtmData),
+	}
+	if !overwrite {
+		plan.Prune = opts.Prune
+	}
+
+	state, err := loadProjectState(imp.db, rtmData.Project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	add := func(entity, key, action string) {
+		plan.Entries = append(plan.Entries, PlanEntry{Entity: entity, Key: key, Action: action})
+	}
+
+	if state.ProjectID == "" {
+		add("project", rtmData.Project.ID, ActionCreate)
+	} else {
+		add("project", rtmData.Project.ID, ActionUpdate)
+	}
+
+	// In overwrite mode every stored entity is removed before the file is imported
+	if overwrite {
+		for _, key := range sortedKeys(state.Components) {
+			add("component", key, ActionDelete)
+		}
+		for _, key := range sortedKeys(state.Requirements) {
+			add("requirement", key, ActionDelete)
+		}
+		for _, key := range sortedKeys(state.Endpoints) {
+			add("api_endpoint", key, ActionDelete)
+		}
+		state = &projectState{
+			ProjectID:    state.ProjectID,
+			Components:   map[string]*storedComponent{},
+			Requirements: map[string]*storedRequirement{},
+			Endpoints:    map[string]string{},
+		}
+	}
+
+	for _, component := range rtmData.SystemComponents {
+		if _, exists := state.Components[component.ID]; exists {
+			// importComponent leaves existing components untouched
+			add("component", component.ID, ActionUnchanged)
+		} else {
+			add("component", component.ID, ActionCreate)
+		}
+	}
+
+	// Merge mode reads the change history to tell human edits apart
+	var tx database.Tx
+	if mode == ModeMerge {
+		if tx, err = imp.db.Begin(); err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+	}
+
+	for _, req := range flattenRequirements(rtmData) {
+		stored, exists := state.Requirements[req.Key]
+		switch {
+		case !exists:
+			add("requirement", req.Key, ActionCreate)
+		case mode == ModeMerge:
+			action, err := mergeAction(tx, &req, stored, opts.Resolutions)
+			if err != nil {
+				return nil, err
+			}
+			add("requirement", req.Key, action)
+		case requirementChanged(&req, stored):
+			add("requirement", req.Key, ActionUpdate)
+		default:
+			add("requirement", req.Key, ActionUnchanged)
+		}
+
+		var storedImpls []implementationRow
+		var storedTests []testLinkRow
+		if exists {
+			storedImpls = stored.Implementations
+			storedTests = stored.TestLinks
+		}
+		planRows(mode, req.Key, "implementation", implementationKeys(storedImpls), implementationKeys(importedImplementations(storedImpls)),
+			implementationKeys(implementationRows(req.Implementation)), add)
+		planRows(mode, req.Key, "test_link", testLinkKeys(storedTests), testLinkKeys(importedTestLinks(storedTests)),
+			testLinkKeys(testLinkRows(req.Tests)), add)
+	}
+
+	for _, endpoint := range rtmData.APIEndpoints {
+		key := endpoint.Method + " " + endpoint.Path
+		if _, exists := state.Endpoints[key]; exists {
+			add("api_endpoint", key, ActionUnchanged)
+		} else {
+			add("api_endpoint", key, ActionCreate)
+		}
+	}
+
+	if plan.Prune != "" {
+		planPrune(rtmData, state, plan.Prune, add)
+	}
+
+	return plan, nil
+}
+
+// planPrune lists the import-sourced records that are absent from the file.
+// Requirements with children that stay, and components that still hold
+// requirements, are marked even in delete mode.
+func planPrune(rtmData *models.RTMData, state *projectState, prune string, add func(entity, key, action string)) {
+	action := ActionDelete
+	if prune == PruneMark {
+		action = ActionMark
+	}
+
+	inFile := make(map[string]bool)
+	for _, req := range flattenRequirements(rtmData) {
+		inFile[req.Key] = true
+	}
+	pruned := func(req *storedRequirement) bool {
+		return req.Source == "import" && !inFile[req.Key]
+	}
+	// A requirement that stays keeps its ancestors and its component
+	remaining := make(map[string]bool) // requirement keys and "component:KEY"
+	for _, req := range state.Requirements {
+		if pruned(req) {
+			continue
+		}
+		remaining["component:"+req.ComponentKey] = true
+		for parent := state.Requirements[req.ParentKey]; parent != nil && !remaining[parent.Key]; parent = state.Requirements[parent.ParentKey] {
+			remaining[parent.Key] = true
+			remaining["component:"+parent.ComponentKey] = true
+		}
+	}
+	for _, key := range sortedKeys(state.Requirements) {
+		if !pruned(state.Requirements[key]) {
+			continue
+		}
+		if action == ActionDelete && remaining[key] {
+			add("requirement", key, ActionMark)
+		} else {
+			add("requirement", key, action)
+		}
+	}
+
+	inFile = make(map[string]bool)
+	for _, endpoint := range rtmData.APIEndpoints {
+		inFile[endpoint.Method+" "+endpoint.Path] = true
+	}
+	for _, key := range sortedKeys(state.Endpoints) {
+		if state.Endpoints[key] == "import" && !inFile[key] {
+			add("api_endpoint", key, action)
+		}
+	}
+
+	inFile = make(map[string]bool)
+	for _, component := range rtmData.SystemComponents {
+		inFile[component.ID] = true
+	}
+	for _, key := range sortedKeys(state.Components) {
+		if state.Components[key].Source != "import" || inFile[key] {
+			continue
+		}
+		if action == ActionDelete && remaining["component:"+key] {
+			add("component", key, ActionMark)
+		} else {
+			add("component", key, action)
+		}
+	}
+}
+
+// planRows compares the stored and incoming child rows of one requirement.
+// An update import replaces the stored rows in replaced, so those only in the
+// database are deleted; the others are kept. A merge import only adds rows.
+func planRows(mode Mode, reqKey, entity string, stored, replaced, incoming []string, add func(entity, key, action string)) {
+	replacedSet := make(map[string]bool)
+	for _, key := range replaced {
+		replacedSet[key] = true
+	}
+	storedSet := make(map[string]bool)
+	for _, key := range stored {
+		storedSet[key] = true
+	}
+	incomingSet := make(map[string]bool)
+	for _, key := range incoming {
+		if incomingSet[key] {
+			continue
+		}
+		incomingSet[key] = true
+		if storedSet[key] {
+			add(entity, reqKey+" → "+key, ActionUnchanged)
+		} else {
+			add(entity, reqKey+" → "+key, ActionCreate)
+		}
+	}
+	for _, key := range stored {
+		switch {
+		case incomingSet[key]:
+		case mode != ModeMerge && replacedSet[key]:
+			add(entity, reqKey+" → "+key, ActionDelete)
+		default:
+			add(entity, reqKey+" → "+key, ActionUnchanged)
+		}
+		incomingSet[key] = true
+	}
+}
+
+func requirementChanged(req *flatRequirement, stored *storedRequirement) bool {
+	return len(requirementFieldChanges(req, stored)) > 0
+}
+
+// mergeAction is what a merge import does to a stored requirement. Fields a
+// human edited since the last import are kept, so they are not changes; a
+// conflict without a resolution is reported as such.
+func mergeAction(tx database.Tx, req *flatRequirement, stored *storedRequirement, resolutions map[string]string) (string, error) {
+	current, err := loadCurrentValues(tx, stored.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load current values: %w", err)
+	}
+	audit, err := loadAuditState(tx, stored.ID)
+	if err != nil {
+		return "", err
+	}
+	incoming := requirementValues{
+		"title":               req.Title,
+		"description":         req.Description,
+		"category":            req.Category,
+		"priority":            req.Priority,
+		"status":              req.Status,
+		"acceptance_criteria": criteriaValue(req.AcceptanceCriteria),
+	}
+	session := &importSession{mode: ModeMerge, resolutions: resolutions, report: &ImportReport{}}
+	merged := session.mergeValues(req.Key, current, incoming, audit)
+
+	for _, conflict := range session.report.Conflicts {
+		if conflict.Resolution == "" {
+			return ActionConflict, nil
+		}
+	}
+	// The hierarchy always follows the file
+	if stored.Type != req.Type || stored.ParentKey != req.ParentKey || stored.ComponentKey != req.ComponentKey {
+		return ActionUpdate, nil
+	}
+	for _, field := range mergeFields {
+		if merged[field] != current[field] {
+			return ActionUpdate, nil
+		}
+	}
+	return ActionUnchanged, nil
+}
+
+// importedImplementations are the stored rows an update-mode import replaces
+func importedImplementations(rows []implementationRow) []implementationRow {
+	var imported []implementationRow
+	for _, row := range rows {
+		if row.Source == "import" {
+			imported = append(imported, row)
+		}
+	}
+	return imported
+}
+
+func implementationKeys(rows []implementationRow) []string {
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, fmt.Sprintf("%s:%s", row.Layer, row.FilePath))
+	}
+	return keys
+}
+
+// importedTestLinks are the stored links an update-mode import replaces
+func importedTestLinks(rows []testLinkRow) []testLinkRow {
+	var imported []testLinkRow
+	for _, row := range rows {
+		if row.Source == "import" {
+			imported = append(imported, row)
+		}
+	}
+	return imported
+}
+
+func testLinkKeys(rows []testLinkRow) []string {
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, fmt.Sprintf("%s::%s", row.FilePath, row.TestName))
+	}
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
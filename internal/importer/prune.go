@@ -0,0 +1,266 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Prune modes for records that came from an earlier import but are absent
+// from the file being imported. Records created by hand are never pruned.
+const (
+	PruneDelete = "delete" // remove them
+	PruneMark   = "mark"   // keep them with pruned_at set; requirements become deprecated
+)
+
+// ParsePrune accepts "", "delete", "mark", or "true" as an alias for delete
+func ParsePrune(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", "false":
+		return "", nil
+	case PruneDelete, "true":
+		return PruneDelete, nil
+	case PruneMark:
+		return PruneMark, nil
+	}
+	return "", fmt.Errorf("unknown prune mode %q (use delete or mark)", value)
+}
+
+// PrunedRecord is one record removed or marked by a pruning import
+type PrunedRecord struct {
+	Entity string `json:"entity"` // component, requirement, api_endpoint, test_link
+	Key    string `json:"key"`
+	Action string `json:"action"` // deleted, marked
+	Reason string `json:"reason,omitempty"`
+}
+
+// queryRowsJSON runs a query whose single column is a JSON array (built with
+// json_group_array) and decodes it into dest. Tx has no Query method.
+func queryRowsJSON(tx database.Tx, query string, dest interface{}, args ...interface{}) error {
+	var data string
+	if err := tx.QueryRow(query, args...).Scan(&data); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dest)
+}
+
+// prune deletes or marks the import-sourced records of a project that the
+// file no longer contains
+func (imp *Importer) prune(tx database.Tx, projectID string, rtmData *models.RTMData, session *importSession) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	record := func(entity, key, action, reason string) {
+		session.report.Pruned = append(session.report.Pruned, PrunedRecord{Entity: entity, Key: key, Action: action, Reason: reason})
+		session.count(entity, "pruned")
+	}
+
+	// Delete mode also removes records an earlier run only marked
+	unpruned := func(alias string) string {
+		if session.prune == PruneDelete {
+			return ""
+		}
+		return " AND " + alias + "pruned_at IS NULL"
+	}
+
+	requirementKeys := make(map[string]bool)
+	testLinks := make(map[string]bool)
+	for _, req := range flattenRequirements(rtmData) {
+		requirementKeys[req.Key] = true
+		for _, key := range testLinkKeys(testLinkRows(req.Tests)) {
+			testLinks[req.Key+" → "+key] = true
+		}
+	}
+
+	// Test links of requirements that stay
+	var links []struct {
+		ID             string `json:"id"`
+		RequirementKey string `json:"requirement_key"`
+		FilePath       string `json:"file_path"`
+		TestName       string `json:"test_name"`
+	}
+	err := queryRowsJSON(tx, `
+		SELECT COALESCE(json_group_array(json_object('id', rtc.id, 'requirement_key', r.requirement_key,
+			'file_path', tf.file_path, 'test_name', tc.test_name)), '[]')
+		FROM requirement_test_coverage rtc
+		JOIN requirements r ON rtc.requirement_id = r.id
+		JOIN test_cases tc ON rtc.test_case_id = tc.id
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE r.project_id = ? AND rtc.source = 'import'`+unpruned("rtc."), &links, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load test links: %w", err)
+	}
+	for _, link := range links {
+		key := link.RequirementKey + " → " + link.FilePath + "::" + link.TestName
+		if testLinks[key] || !requirementKeys[link.RequirementKey] {
+			continue
+		}
+		if session.prune == PruneDelete {
+			_, err = tx.Exec("DELETE FROM requirement_test_coverage WHERE id = ?", link.ID)
+			record("test_link", key, "deleted", "")
+		} else {
+			_, err = tx.Exec("UPDATE requirement_test_coverage SET pruned_at = ? WHERE id = ?", now, link.ID)
+			record("test_link", key, "marked", "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to prune test link %s: %w", key, err)
+		}
+	}
+
+	// Requirements, deepest first so a parent is only deleted once it has no children left
+	var requirements []struct {
+		ID     string `json:"id"`
+		Key    string `json:"key"`
+		Pruned bool   `json:"pruned"`
+	}
+	err = queryRowsJSON(tx, `
+		SELECT COALESCE(json_group_array(json_object('id', id, 'key', requirement_key,
+			'pruned', json(CASE WHEN pruned_at IS NULL THEN 'false' ELSE 'true' END))), '[]')
+		FROM requirements WHERE project_id = ? AND source = 'import'`+unpruned(""), &requirements, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load requirements: %w", err)
+	}
+	pending := make(map[string]string) // id -> key
+	marked := make(map[string]bool)
+	for _, req := range requirements {
+		if !requirementKeys[req.Key] {
+			pending[req.ID] = req.Key
+			marked[req.ID] = req.Pruned
+		}
+	}
+
+	if session.prune == PruneDelete {
+		for progress := true; progress; {
+			progress = false
+			for _, id := range sortedKeys(pending) {
+				var children int
+				if err := tx.QueryRow("SELECT COUNT(*) FROM requirements WHERE parent_requirement_id = ?", id).Scan(&children); err != nil {
+					return err
+				}
+				if children > 0 {
+					continue
+				}
+				if err := imp.cleanupRequirementData(tx, id); err != nil {
+					return err
+				}
+				if _, err := tx.Exec("DELETE FROM requirements WHERE id = ?", id); err != nil {
+					return fmt.Errorf("failed to delete requirement %s: %w", pending[id], err)
+				}
+				record("requirement", pending[id], "deleted", "")
+				delete(pending, id)
+				progress = true
+			}
+		}
+	}
+
+	// Whatever is left either is being marked or still has children created by hand
+	for _, id := range sortedKeys(pending) {
+		if marked[id] {
+			continue
+		}
+		reason := ""
+		if session.prune == PruneDelete {
+			reason = "has children that are not pruned"
+		}
+		if err := markRequirementPruned(tx, id, session.report.ImportID, now); err != nil {
+			return fmt.Errorf("failed to mark requirement %s: %w", pending[id], err)
+		}
+		record("requirement", pending[id], "marked", reason)
+	}
+
+	// API endpoints
+	endpointKeys := make(map[string]bool)
+	for _, endpoint := range rtmData.APIEndpoints {
+		endpointKeys[endpoint.Method+" "+endpoint.Path] = true
+	}
+	var endpoints []struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	err = queryRowsJSON(tx, `
+		SELECT COALESCE(json_group_array(json_object('id', id, 'method', method, 'path', path)), '[]')
+		FROM api_endpoints WHERE project_id = ? AND source = 'import'`+unpruned(""), &endpoints, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load API endpoints: %w", err)
+	}
+	for _, endpoint := range endpoints {
+		key := endpoint.Method + " " + endpoint.Path
+		if endpointKeys[key] {
+			continue
+		}
+		if session.prune == PruneDelete {
+			_, err = tx.Exec("DELETE FROM api_endpoints WHERE id = ?", endpoint.ID)
+			record("api_endpoint", key, "deleted", "")
+		} else {
+			_, err = tx.Exec("UPDATE api_endpoints SET pruned_at = ? WHERE id = ?", now, endpoint.ID)
+			record("api_endpoint", key, "marked", "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to prune API endpoint %s: %w", key, err)
+		}
+	}
+
+	// Components last: one still holding requirements is only marked
+	componentKeys := make(map[string]bool)
+	for _, component := range rtmData.SystemComponents {
+		componentKeys[component.ID] = true
+	}
+	var components []struct {
+		ID           string `json:"id"`
+		Key          string `json:"key"`
+		Requirements int    `json:"requirements"`
+		Pruned       bool   `json:"pruned"`
+	}
+	err = queryRowsJSON(tx, `
+		SELECT COALESCE(json_group_array(json_object('id', c.id, 'key', c.component_key,
+			'requirements', (SELECT COUNT(*) FROM requirements r WHERE r.component_id = c.id),
+			'pruned', json(CASE WHEN c.pruned_at IS NULL THEN 'false' ELSE 'true' END))), '[]')
+		FROM system_components c WHERE c.project_id = ? AND c.source = 'import'`+unpruned("c."), &components, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load components: %w", err)
+	}
+	for _, component := range components {
+		if componentKeys[component.Key] {
+			continue
+		}
+		if session.prune == PruneDelete && component.Requirements == 0 {
+			_, err = tx.Exec("DELETE FROM system_components WHERE id = ?", component.ID)
+			record("component", component.Key, "deleted", "")
+		} else if !component.Pruned {
+			reason := ""
+			if session.prune == PruneDelete {
+				reason = "still has requirements"
+			}
+			_, err = tx.Exec("UPDATE system_components SET pruned_at = ? WHERE id = ?", now, component.ID)
+			record("component", component.Key, "marked", reason)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to prune component %s: %w", component.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// markRequirementPruned deprecates a requirement and records it in the change
+// history as an importer change, so a later merge import treats the status as
+// the importer's and not as a human edit
+func markRequirementPruned(tx database.Tx, requirementID, importID, now string) error {
+	_, err := tx.Exec(`UPDATE requirements SET pruned_at = ?, status = 'deprecated', last_import_id = ?,
+		updated_at = datetime('now') WHERE id = ?`, now, importID, requirementID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO requirement_changes (requirement_id, change_type, new_values, changed_by, change_reason, created_at)
+			  SELECT id, 'pruned', json_object('requirement_key', requirement_key, 'title', title,
+				'description', COALESCE(description, ''), 'category', category, 'priority', priority,
+				'status', status, 'acceptance_criteria', json(COALESCE(acceptance_criteria, '[]'))),
+				?, 'absent from import', ?
+			  FROM requirements WHERE id = ?`
+	_, err = tx.Exec(query, changedByImporter, now, requirementID)
+	return err
+}
@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// ImportReport describes the outcome of an import. Warnings and errors are
+// located by their JSON path in the file, like validation issues.
+type ImportReport struct {
+	ProjectKey string                            `json:"project_key"`
+	Filename   string                            `json:"filename,omitempty"`
+	Mode       Mode                              `json:"mode"`
+	Success    bool                              `json:"success"`
+	ImportID   string                            `json:"import_id,omitempty"` // row in the imports table
+	Counts     map[string]*database.ImportCounts `json:"counts,omitempty"`    // by entity: component, requirement, ...
+	DurationMs int64                             `json:"duration_ms"`
+	Warnings   []ValidationIssue                 `json:"warnings,omitempty"`
+	Errors     []ValidationIssue                 `json:"errors,omitempty"`
+	Conflicts  []Conflict                        `json:"conflicts,omitempty"`
+	Pruned     []PrunedRecord                    `json:"pruned,omitempty"`
+}
+
+// Unresolved returns the conflicts that kept the database value by default
+func (r *ImportReport) Unresolved() []Conflict {
+	var unresolved []Conflict
+	for _, c := range r.Conflicts {
+		if c.Resolution == "" {
+			unresolved = append(unresolved, c)
+		}
+	}
+	return unresolved
+}
+
+// Entities lists the entity kinds with counts, in a stable order
+func (r *ImportReport) Entities() []string {
+	order := map[string]int{"component": 0, "requirement": 1, "api_endpoint": 2, "test_link": 3}
+	entities := make([]string, 0, len(r.Counts))
+	for entity := range r.Counts {
+		entities = append(entities, entity)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		oi, okI := order[entities[i]]
+		oj, okJ := order[entities[j]]
+		if okI != okJ {
+			return okI
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return entities[i] < entities[j]
+	})
+	return entities
+}
+
+// count records one created, updated or pruned entity in the report
+func (session *importSession) count(entity, action string) {
+	if session.report.Counts == nil {
+		session.report.Counts = make(map[string]*database.ImportCounts)
+	}
+	c, ok := session.report.Counts[entity]
+	if !ok {
+		c = &database.ImportCounts{}
+		session.report.Counts[entity] = c
+	}
+	switch action {
+	case "created":
+		c.Created++
+	case "updated":
+		c.Updated++
+	case "pruned":
+		c.Pruned++
+	}
+}
+
+// fail records the error that stopped the import at path and returns it
+func (session *importSession) fail(path string, err error) error {
+	session.report.Errors = append(session.report.Errors, ValidationIssue{Path: path, Message: err.Error()})
+	return err
+}
+
+// knownTestTypes are the test_coverage keys the importer understands
+var knownTestTypes = []string{"backend", "frontend", "unit_tests", "integration_tests", "e2e_tests"}
+
+// fileWarnings finds things in an RTM file that import without error but not
+// the way the author probably meant. doc is the generically decoded file.
+func fileWarnings(doc interface{}, rtmData *models.RTMData) []ValidationIssue {
+	var warnings []ValidationIssue
+
+	// test_coverage keys other than the known ones are silently dropped by the decoder
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedKeys(v) {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				if coverage, ok := v[key].(map[string]interface{}); ok && key == "test_coverage" {
+					for _, testType := range sortedKeys(coverage) {
+						if !containsString(knownTestTypes, testType) {
+							warnings = append(warnings, ValidationIssue{
+								Path: childPath + "." + testType,
+								Message: fmt.Sprintf("unknown test type %q; its tests were not imported (expected one of %s)",
+									testType, strings.Join(knownTestTypes, ", ")),
+							})
+						}
+					}
+					continue
+				}
+				walk(v[key], childPath)
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(doc, "")
+
+	// Children in the flat format always use their top-level ancestor's component
+	var walkChildren func(req *models.Requirement, path, componentKey string)
+	walkChildren = func(req *models.Requirement, path, componentKey string) {
+		for i := range req.Children {
+			child := &req.Children[i]
+			childPath := fmt.Sprintf("%s.children[%d]", path, i)
+			if child.ComponentID != "" && child.ComponentID != componentKey {
+				warnings = append(warnings, ValidationIssue{
+					Path:    childPath + ".component_id",
+					Message: fmt.Sprintf("component %q ignored; stored under the parent's component %q", child.ComponentID, componentKey),
+				})
+			}
+			walkChildren(child, childPath, componentKey)
+		}
+	}
+	for i := range rtmData.Requirements {
+		req := &rtmData.Requirements[i]
+		walkChildren(req, fmt.Sprintf("requirements[%d]", i), req.ComponentID)
+	}
+
+	return warnings
+}
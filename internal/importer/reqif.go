@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peshwar9/tracevibe/internal/reqif"
+)
+
+// parseReqIF builds RTM data from a ReqIF document. The project key, if
+// given, overrides the document's REPOSITORY-ID.
+func parseReqIF(filePath, projectKey string) (*parsedFile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ReqIF file: %w", err)
+	}
+	defer file.Close()
+
+	result, err := reqif.Import(file)
+	if err != nil {
+		return nil, err
+	}
+	if projectKey != "" {
+		result.RTMData.Project.ID = projectKey
+	}
+
+	parsed := &parsedFile{data: result.RTMData, locations: make(map[string]string)}
+	for path, id := range result.Objects {
+		parsed.locations[path] = "SPEC-OBJECT " + id
+	}
+	for _, warning := range result.Warnings {
+		parsed.warnings = append(parsed.warnings, ValidationIssue{Path: warning.Identifier, Message: warning.Message})
+	}
+	return parsed, nil
+}
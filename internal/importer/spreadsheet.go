@@ -0,0 +1,423 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/xlsx"
+	"gopkg.in/yaml.v3"
+)
+
+// ColumnMapping describes how a CSV or XLSX backlog maps onto requirements.
+// It is usually loaded from a YAML or JSON file; anything left out falls back
+// to DefaultColumnMapping.
+type ColumnMapping struct {
+	Sheet             string  `yaml:"sheet,omitempty" json:"sheet,omitempty"`                           // XLSX only; default is the first sheet
+	HeaderRow         int     `yaml:"header_row,omitempty" json:"header_row,omitempty"`                 // 1-based; default 1
+	Delimiter         string  `yaml:"delimiter,omitempty" json:"delimiter,omitempty"`                   // CSV only; default ","
+	CriteriaSeparator string  `yaml:"criteria_separator,omitempty" json:"criteria_separator,omitempty"` // splits acceptance criteria; default newline
+	Columns           Columns `yaml:"columns" json:"columns"`
+	// Types maps cell values such as "Epic" or "Story" to scope, user_story or tech_spec
+	Types map[string]string `yaml:"types,omitempty" json:"types,omitempty"`
+	// Project and Components supply what a backlog has no columns for. Components
+	// not listed are created with their key as name.
+	Project    models.Project           `yaml:"project,omitempty" json:"project,omitempty"`
+	Components []models.SystemComponent `yaml:"components,omitempty" json:"components,omitempty"`
+
+	explicit map[string]bool // columns named in the mapping file, which must exist
+}
+
+// Columns holds the header of the column for each requirement field
+type Columns struct {
+	Key                string `yaml:"key,omitempty" json:"key,omitempty"`
+	Type               string `yaml:"type,omitempty" json:"type,omitempty"`
+	ParentKey          string `yaml:"parent_key,omitempty" json:"parent_key,omitempty"`
+	Component          string `yaml:"component,omitempty" json:"component,omitempty"`
+	Title              string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description        string `yaml:"description,omitempty" json:"description,omitempty"`
+	Priority           string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Status             string `yaml:"status,omitempty" json:"status,omitempty"`
+	Category           string `yaml:"category,omitempty" json:"category,omitempty"`
+	AcceptanceCriteria string `yaml:"acceptance_criteria,omitempty" json:"acceptance_criteria,omitempty"`
+}
+
+// spreadsheetFields lists the mapped fields in column order, with the headers
+// accepted when the mapping does not name the column
+var spreadsheetFields = []struct {
+	name     string
+	required bool
+	column   func(*Columns) *string
+	aliases  []string
+}{
+	{"key", true, func(c *Columns) *string { return &c.Key }, []string{"Key", "ID", "Requirement Key"}},
+	{"type", false, func(c *Columns) *string { return &c.Type }, []string{"Type", "Requirement Type", "Issue Type"}},
+	{"parent_key", false, func(c *Columns) *string { return &c.ParentKey }, []string{"Parent", "Parent Key", "Parent ID"}},
+	{"component", false, func(c *Columns) *string { return &c.Component }, []string{"Component", "Component ID"}},
+	{"title", true, func(c *Columns) *string { return &c.Title }, []string{"Title", "Name", "Summary"}},
+	{"description", false, func(c *Columns) *string { return &c.Description }, []string{"Description"}},
+	{"priority", false, func(c *Columns) *string { return &c.Priority }, []string{"Priority"}},
+	{"status", false, func(c *Columns) *string { return &c.Status }, []string{"Status"}},
+	{"category", false, func(c *Columns) *string { return &c.Category }, []string{"Category"}},
+	{"acceptance_criteria", false, func(c *Columns) *string { return &c.AcceptanceCriteria }, []string{"Acceptance Criteria", "Criteria"}},
+}
+
+// jsonFields maps the RTM field in a validation path to the mapped field
+var jsonFields = map[string]string{
+	"id": "key", "type": "type", "component_id": "component", "name": "title", "description": "description",
+	"priority": "priority", "status": "status", "category": "category", "acceptance_criteria": "acceptance_criteria",
+}
+
+// DefaultColumnMapping expects a header row with columns named Key, Type,
+// Parent, Component, Title, Description, Priority, Status, Category and
+// Acceptance Criteria (case and spacing are ignored)
+func DefaultColumnMapping() *ColumnMapping {
+	mapping := &ColumnMapping{}
+	mapping.setDefaults()
+	return mapping
+}
+
+// LoadColumnMapping reads a column mapping from a YAML or JSON file
+func LoadColumnMapping(filePath string) (*ColumnMapping, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column mapping: %w", err)
+	}
+	return ParseColumnMapping(data)
+}
+
+// ParseColumnMapping decodes a YAML or JSON column mapping
+func ParseColumnMapping(data []byte) (*ColumnMapping, error) {
+	var mapping ColumnMapping
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&mapping); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse column mapping: %w", err)
+	}
+	for name, value := range mapping.Types {
+		if !isOneOf(value, validReqTypes) {
+			return nil, fmt.Errorf("invalid column mapping: type %q maps to %q (expected one of scope, user_story, tech_spec)", name, value)
+		}
+	}
+	if utf8.RuneCountInString(mapping.Delimiter) > 1 {
+		return nil, fmt.Errorf("invalid column mapping: delimiter must be a single character")
+	}
+	mapping.setDefaults()
+	return &mapping, nil
+}
+
+func (m *ColumnMapping) setDefaults() {
+	m.explicit = make(map[string]bool)
+	for _, field := range spreadsheetFields {
+		if *field.column(&m.Columns) != "" {
+			m.explicit[field.name] = true
+		}
+	}
+	if m.HeaderRow <= 0 {
+		m.HeaderRow = 1
+	}
+	if m.Delimiter == "" {
+		m.Delimiter = ","
+	}
+	if m.CriteriaSeparator == "" {
+		m.CriteriaSeparator = "\n"
+	}
+}
+
+// spreadsheetRow is one backlog item read from a spreadsheet
+type spreadsheetRow struct {
+	number int
+	values map[string]string // mapped field -> cell value
+}
+
+// isSpreadsheet reports whether a file is imported through a column mapping
+func isSpreadsheet(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+// parseSpreadsheet builds RTM data from a CSV or XLSX backlog. Every row is a
+// requirement; the parent key column rebuilds the scope -> user story -> tech
+// spec tree, and rows without a type get one from their depth in it.
+func parseSpreadsheet(filePath, projectKey string, mapping *ColumnMapping) (*parsedFile, error) {
+	if mapping == nil {
+		mapping = DefaultColumnMapping()
+	}
+	if projectKey == "" {
+		return nil, fmt.Errorf("a project key is required to import a spreadsheet")
+	}
+
+	var rows []xlsx.Row
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		rows, err = readCSV(filePath, mapping.Delimiter)
+	case ".xlsx":
+		rows, err = xlsx.ReadSheet(filePath, mapping.Sheet)
+	default:
+		err = fmt.Errorf("unsupported spreadsheet format: %s (use .csv or .xlsx)", filepath.Ext(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Locate the mapped columns in the header row
+	var header []string
+	var data []xlsx.Row
+	for i, row := range rows {
+		if row.Number == mapping.HeaderRow {
+			header = row.Cells
+			data = rows[i+1:]
+			break
+		}
+	}
+	if header == nil {
+		return nil, fmt.Errorf("header row %d is empty or missing", mapping.HeaderRow)
+	}
+
+	file := &parsedFile{locations: make(map[string]string), headers: make(map[string]string)}
+	columns := make(map[string]int) // mapped field -> column index
+	for _, field := range spreadsheetFields {
+		names := field.aliases
+		if mapping.explicit[field.name] {
+			names = []string{*field.column(&mapping.Columns)}
+		}
+		for col, cell := range header {
+			if containsHeader(names, cell) {
+				columns[field.name] = col
+				file.headers[field.name] = strings.TrimSpace(cell)
+				break
+			}
+		}
+		if _, found := columns[field.name]; !found && (field.required || mapping.explicit[field.name]) {
+			file.issues = append(file.issues, ValidationIssue{
+				Path:    rowLabel(mapping.HeaderRow),
+				Message: fmt.Sprintf("no %q column for %s", names[0], field.name),
+			})
+		}
+	}
+	if len(file.issues) > 0 {
+		return nil, &ValidationError{Issues: file.issues}
+	}
+
+	// Read the rows, keyed by requirement key
+	var items []*spreadsheetRow
+	byKey := make(map[string]*spreadsheetRow)
+	for _, row := range data {
+		item := &spreadsheetRow{number: row.Number, values: make(map[string]string)}
+		for field, col := range columns {
+			if col < len(row.Cells) {
+				item.values[field] = strings.TrimSpace(row.Cells[col])
+			}
+		}
+		key := item.values["key"]
+		if key == "" {
+			file.issues = append(file.issues, ValidationIssue{Path: file.cellPath(rowLabel(row.Number), "key"), Message: "missing mandatory field"})
+			continue
+		}
+		items = append(items, item)
+		if _, exists := byKey[key]; !exists {
+			byKey[key] = item
+		}
+	}
+
+	children := make(map[string][]*spreadsheetRow)
+	var roots []*spreadsheetRow
+	for _, item := range items {
+		parent := item.values["parent_key"]
+		switch {
+		case parent == "":
+			roots = append(roots, item)
+		case byKey[parent] == nil:
+			file.issues = append(file.issues, ValidationIssue{
+				Path:    file.cellPath(rowLabel(item.number), "parent_key"),
+				Message: fmt.Sprintf("unknown parent key %q", parent),
+			})
+		default:
+			children[parent] = append(children[parent], item)
+		}
+	}
+
+	rtmData := &models.RTMData{Project: mapping.Project}
+	rtmData.Project.ID = projectKey
+
+	// Build the tree from the top-level rows down, remembering the path of each row
+	placed := make(map[*spreadsheetRow]bool)
+	var build func(item *spreadsheetRow, path string, depth int, componentKey string) models.Requirement
+	build = func(item *spreadsheetRow, path string, depth int, componentKey string) models.Requirement {
+		placed[item] = true
+		file.locations[path] = rowLabel(item.number)
+
+		req := models.Requirement{
+			ID:                 item.values["key"],
+			ComponentID:        item.values["component"],
+			RequirementType:    mapping.requirementType(item.values["type"], depth),
+			Title:              item.values["title"],
+			Description:        item.values["description"],
+			Category:           item.values["category"],
+			Priority:           item.values["priority"],
+			Status:             item.values["status"],
+			AcceptanceCriteria: splitCriteria(item.values["acceptance_criteria"], mapping.CriteriaSeparator),
+		}
+		if depth > 0 && req.ComponentID != "" && req.ComponentID != componentKey {
+			file.warnings = append(file.warnings, ValidationIssue{
+				Path:    file.cellPath(rowLabel(item.number), "component"),
+				Message: fmt.Sprintf("component %q ignored; stored under the parent's component %q", req.ComponentID, componentKey),
+			})
+		}
+		if depth == 0 {
+			componentKey = req.ComponentID
+		}
+		// A key listed twice is reported by validation; only the first gets the children
+		if byKey[req.ID] == item {
+			for i, child := range children[req.ID] {
+				if !placed[child] {
+					req.Children = append(req.Children, build(child, fmt.Sprintf("%s.children[%d]", path, i), depth+1, componentKey))
+				}
+			}
+		}
+		return req
+	}
+	for i, item := range roots {
+		rtmData.Requirements = append(rtmData.Requirements, build(item, fmt.Sprintf("requirements[%d]", i), 0, ""))
+	}
+	for _, item := range items {
+		if !placed[item] && inParentCycle(item, byKey) {
+			file.issues = append(file.issues, ValidationIssue{
+				Path:    file.cellPath(rowLabel(item.number), "parent_key"),
+				Message: "parent keys form a cycle; the row never reaches a top-level requirement",
+			})
+		}
+	}
+
+	// Components: those in the mapping, then any other the rows reference
+	rtmData.SystemComponents = append(rtmData.SystemComponents, mapping.Components...)
+	known := make(map[string]bool)
+	for _, component := range mapping.Components {
+		known[component.ID] = true
+	}
+	for _, req := range rtmData.Requirements {
+		if req.ComponentID != "" && !known[req.ComponentID] {
+			known[req.ComponentID] = true
+			rtmData.SystemComponents = append(rtmData.SystemComponents, models.SystemComponent{
+				ID:            req.ComponentID,
+				Name:          req.ComponentID,
+				ComponentType: "component",
+			})
+		}
+	}
+
+	file.data = rtmData
+	return file, nil
+}
+
+// requirementType maps a type cell to SCOPE, USER_STORY or TECH_SPEC. An empty
+// cell takes the type of the row's depth; an unknown value is kept as is so
+// validation reports it.
+func (m *ColumnMapping) requirementType(value string, depth int) string {
+	for name, mapped := range m.Types {
+		if strings.EqualFold(strings.TrimSpace(name), value) {
+			value = mapped
+			break
+		}
+	}
+	if value == "" && depth < len(validReqTypes) {
+		value = validReqTypes[depth]
+	}
+	if isOneOf(value, validReqTypes) {
+		return strings.ToUpper(normalizeEnum(value))
+	}
+	return value
+}
+
+// inParentCycle reports whether following parent keys from item leads back to
+// it. Rows below an unknown parent are not in a cycle; the parent is reported.
+func inParentCycle(item *spreadsheetRow, byKey map[string]*spreadsheetRow) bool {
+	current := item
+	for range byKey {
+		current = byKey[current.values["parent_key"]]
+		if current == nil {
+			return false
+		}
+		if current == item {
+			return true
+		}
+	}
+	return false
+}
+
+// cellPath locates a field as "row N, <column header>", or "<location>
+// (<field>)" when there is no column for it
+func (f *parsedFile) cellPath(location, field string) string {
+	if header, ok := f.headers[field]; ok {
+		return fmt.Sprintf("%s, %s", location, header)
+	}
+	if field != "" {
+		return fmt.Sprintf("%s (%s)", location, field)
+	}
+	return location
+}
+
+func rowLabel(row int) string {
+	return fmt.Sprintf("row %d", row)
+}
+
+func readCSV(filePath, delimiter string) ([]xlsx.Row, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	// Spreadsheet applications often start UTF-8 exports with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	var rows []xlsx.Row
+	for i, record := range records {
+		empty := true
+		for _, cell := range record {
+			if strings.TrimSpace(cell) != "" {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			rows = append(rows, xlsx.Row{Number: i + 1, Cells: record})
+		}
+	}
+	return rows, nil
+}
+
+func containsHeader(names []string, header string) bool {
+	squash := strings.NewReplacer(" ", "", "_", "", "-", "")
+	header = squash.Replace(strings.ToLower(strings.TrimSpace(header)))
+	for _, name := range names {
+		if squash.Replace(strings.ToLower(name)) == header {
+			return true
+		}
+	}
+	return false
+}
+
+func splitCriteria(value, separator string) []string {
+	var criteria []string
+	for _, c := range strings.Split(value, separator) {
+		if c = strings.TrimSpace(c); c != "" {
+			criteria = append(criteria, c)
+		}
+	}
+	return criteria
+}
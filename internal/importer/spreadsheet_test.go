@@ -0,0 +1,295 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+	"github.com/peshwar9/tracevibe/internal/xlsx"
+)
+
+func TestParseColumnMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		want    ColumnMapping
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: ColumnMapping{HeaderRow: 1, Delimiter: ",", CriteriaSeparator: "\n", explicit: map[string]bool{}},
+		},
+		{
+			name: "yaml",
+			mapping: `sheet: Backlog
+header_row: 3
+delimiter: ";"
+criteria_separator: "|"
+columns:
+  key: Issue key
+  parent_key: Epic Link
+types:
+  Epic: scope
+  Story: user_story
+`,
+			want: ColumnMapping{
+				Sheet: "Backlog", HeaderRow: 3, Delimiter: ";", CriteriaSeparator: "|",
+				Columns:  Columns{Key: "Issue key", ParentKey: "Epic Link"},
+				Types:    map[string]string{"Epic": "scope", "Story": "user_story"},
+				explicit: map[string]bool{"key": true, "parent_key": true},
+			},
+		},
+		{
+			name:    "json",
+			mapping: `{"columns": {"title": "Summary"}, "types": {"Sub-task": "tech-spec"}}`,
+			want: ColumnMapping{
+				HeaderRow: 1, Delimiter: ",", CriteriaSeparator: "\n",
+				Columns:  Columns{Title: "Summary"},
+				Types:    map[string]string{"Sub-task": "tech-spec"},
+				explicit: map[string]bool{"title": true},
+			},
+		},
+		{name: "unknown field", mapping: "colums: {}\n", wantErr: "field colums not found"},
+		{name: "unknown type", mapping: "types: {Bug: defect}\n", wantErr: `type "Bug" maps to "defect"`},
+		{name: "long delimiter", mapping: "delimiter: ';;'\n", wantErr: "delimiter must be a single character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseColumnMapping([]byte(tt.mapping))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseColumnMapping() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseColumnMapping() =\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirementType(t *testing.T) {
+	mapping := &ColumnMapping{Types: map[string]string{"Epic": "scope", " Story ": "user_story", "Sub-task": "tech-spec"}}
+	tests := []struct {
+		value string
+		depth int
+		want  string
+	}{
+		{"", 0, "SCOPE"},
+		{"", 1, "USER_STORY"},
+		{"", 2, "TECH_SPEC"},
+		{"", 3, ""},
+		{"epic", 2, "SCOPE"},
+		{"Story", 0, "USER_STORY"},
+		{"Sub-task", 0, "TECH_SPEC"},
+		{"user story", 0, "USER_STORY"},
+		{"Bug", 1, "Bug"},
+	}
+	for _, tt := range tests {
+		if got := mapping.requirementType(tt.value, tt.depth); got != tt.want {
+			t.Errorf("requirementType(%q, %d) = %q, want %q", tt.value, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestContainsHeader(t *testing.T) {
+	tests := []struct {
+		names  []string
+		header string
+		want   bool
+	}{
+		{[]string{"Acceptance Criteria"}, "acceptance_criteria", true},
+		{[]string{"Acceptance Criteria"}, " ACCEPTANCE-CRITERIA ", true},
+		{[]string{"Parent", "Parent Key"}, "ParentKey", true},
+		{[]string{"Key"}, "Key2", false},
+		{[]string{"Key"}, "", false},
+	}
+	for _, tt := range tests {
+		if got := containsHeader(tt.names, tt.header); got != tt.want {
+			t.Errorf("containsHeader(%q, %q) = %v, want %v", tt.names, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpreadsheet(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string // .csv content, or rows of an .xlsx sheet separated by newlines and "|"
+		mapping    string
+		want       *models.RTMData
+		wantIssues []string
+		wantWarn   []string
+	}{
+		{
+			name: "csv with default columns",
+			file: "\ufeffKey,Type,Parent,Component,Title,Priority,Acceptance Criteria\n" +
+				"SCOPE-1,,,auth,Authentication,high,\n" +
+				"US-1,,SCOPE-1,,Sign in,,\"valid user signs in\n\nlocked user is refused\"\n" +
+				",,,,,,\n" +
+				"TS-1,,US-1,billing,Password hashing,,\n",
+			want: &models.RTMData{
+				Project: models.Project{ID: "app"},
+				SystemComponents: []models.SystemComponent{
+					{ID: "auth", Name: "auth", ComponentType: "component"},
+				},
+				Requirements: []models.Requirement{{
+					ID: "SCOPE-1", ComponentID: "auth", RequirementType: "SCOPE", Title: "Authentication", Priority: "high",
+					Children: []models.Requirement{{
+						ID: "US-1", RequirementType: "USER_STORY", Title: "Sign in",
+						AcceptanceCriteria: []string{"valid user signs in", "locked user is refused"},
+						Children: []models.Requirement{{
+							ID: "TS-1", ComponentID: "billing", RequirementType: "TECH_SPEC", Title: "Password hashing",
+						}},
+					}},
+				}},
+			},
+			wantWarn: []string{`row 5, Component: component "billing" ignored; stored under the parent's component "auth"`},
+		},
+		{
+			name: "xlsx with a mapping",
+			file: "Backlog export|\n" +
+				"Issue key|Issue Type|Epic Link|Summary|Criteria\n" +
+				"E-1|Epic||Payments|\n" +
+				"S-1|Story|E-1|Pay by card|charged once; receipt sent",
+			mapping: `header_row: 2
+criteria_separator: ";"
+columns:
+  key: Issue key
+  parent_key: Epic Link
+types:
+  Epic: scope
+  Story: user_story
+project:
+  name: Shop
+components:
+  - id: pay
+    name: Payments service
+`,
+			want: &models.RTMData{
+				Project:          models.Project{ID: "app", Name: "Shop"},
+				SystemComponents: []models.SystemComponent{{ID: "pay", Name: "Payments service"}},
+				Requirements: []models.Requirement{{
+					ID: "E-1", RequirementType: "SCOPE", Title: "Payments",
+					Children: []models.Requirement{{
+						ID: "S-1", RequirementType: "USER_STORY", Title: "Pay by card",
+						AcceptanceCriteria: []string{"charged once", "receipt sent"},
+					}},
+				}},
+			},
+		},
+		{
+			name:    "semicolon delimiter",
+			file:    "ID;Name;Category\nR-1;Export; reports \n",
+			mapping: "delimiter: ';'\n",
+			want:    &models.RTMData{Project: models.Project{ID: "app"}, Requirements: []models.Requirement{{ID: "R-1", RequirementType: "SCOPE", Title: "Export", Category: "reports"}}},
+		},
+		{
+			name:       "missing columns",
+			file:       "Summary,Status\nx,y\n",
+			mapping:    "columns: {component: Team}\n",
+			wantIssues: []string{`row 1: no "Key" column for key`, `row 1: no "Team" column for component`},
+		},
+		{
+			name: "bad rows",
+			file: "Key,Title,Parent\n" +
+				",No key,\n" +
+				"A,Orphan,Z\n" +
+				"B,Loop one,C\n" +
+				"C,Loop two,B\n",
+			wantIssues: []string{
+				"row 2, Key: missing mandatory field",
+				`row 3, Parent: unknown parent key "Z"`,
+				"row 4, Parent: parent keys form a cycle; the row never reaches a top-level requirement",
+				"row 5, Parent: parent keys form a cycle; the row never reaches a top-level requirement",
+			},
+		},
+		{
+			name:       "no header",
+			file:       "Key,Title\n",
+			mapping:    "header_row: 4\n",
+			wantIssues: []string{"header row 4 is empty or missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mapping *ColumnMapping
+			if tt.mapping != "" {
+				var err error
+				if mapping, err = ParseColumnMapping([]byte(tt.mapping)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			path := writeBacklog(t, tt.file)
+
+			got, err := parseSpreadsheet(path, "app", mapping)
+			var issues []string
+			var validationErr *ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				issues = issueStrings(validationErr.Issues)
+			case err != nil:
+				issues = []string{err.Error()}
+			default:
+				issues = issueStrings(got.issues)
+			}
+			if !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(issues, "\n"), strings.Join(tt.wantIssues, "\n"))
+			}
+			if tt.want == nil {
+				return
+			}
+
+			if warnings := issueStrings(got.warnings); !reflect.DeepEqual(warnings, tt.wantWarn) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarn)
+			}
+			if !reflect.DeepEqual(got.data, tt.want) {
+				t.Errorf("data =\n%+v\nwant\n%+v", got.data, tt.want)
+			}
+		})
+	}
+}
+
+func issueStrings(issues []ValidationIssue) []string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return lines
+}
+
+// writeBacklog writes a CSV backlog, or an XLSX one when the rows are
+// separated by "|"
+func writeBacklog(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if !strings.Contains(content, "|") {
+		path := filepath.Join(dir, "backlog.csv")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(content, "\n") {
+		rows = append(rows, strings.Split(line, "|"))
+	}
+	path := filepath.Join(dir, "backlog.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := xlsx.WriteSheet(f, "Backlog", rows); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
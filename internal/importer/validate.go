@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Allowed values for requirement priority and status. Comparison ignores case
+// and treats spaces and dashes as underscores, so "IN_PROGRESS", "in-progress"
+// and "In Progress" are all accepted.
+var (
+	validPriorities = []string{"low", "medium", "high", "critical"}
+	validStatuses   = []string{"not_started", "planned", "in_progress", "implemented", "completed", "done", "tested", "blocked", "deprecated"}
+	validReqTypes   = []string{"scope", "user_story", "tech_spec"}
+	validMethods    = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+)
+
+// ValidationIssue is a single problem found in an RTM file, located by its JSON path
+type ValidationIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// ValidationError is returned when an RTM file fails validation; nothing has
+// been written to the database when it is returned
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, "  - "+issue.String())
+	}
+	return fmt.Sprintf("RTM file has %d validation error(s):\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// Validate checks an RTM structure for problems that would make the import fail
+// partway or store inconsistent data. Every problem is reported, not just the first.
+func Validate(rtmData *models.RTMData) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(path, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Project
+	if rtmData.Project.ID == "" {
+		add("project.id", "missing mandatory field (set it in the file or pass --project)")
+	}
+	if rtmData.Project.Name == "" {
+		add("project.name", "missing mandatory field")
+	}
+
+	// Components
+	components := make(map[string]bool)
+	for i, component := range rtmData.SystemComponents {
+		path := fmt.Sprintf("components[%d]", i)
+		if component.ID == "" {
+			add(path+".id", "missing mandatory field")
+		} else if components[component.ID] {
+			add(path+".id", "duplicate component id %q", component.ID)
+		}
+		components[component.ID] = true
+		if component.Name == "" {
+			add(path+".name", "missing mandatory field")
+		}
+		if component.ComponentType == "" {
+			add(path+".type", "missing mandatory field")
+		}
+	}
+
+	// Requirement hierarchy of the flat format
+	for i := range rtmData.Requirements {
+		req := &rtmData.Requirements[i]
+		path := fmt.Sprintf("requirements[%d]", i)
+		if req.RequirementType != "" && normalizeEnum(req.RequirementType) != "scope" {
+			add(path+".type", "orphan %s: only SCOPE requirements may appear at the top level", req.RequirementType)
+		}
+		validateChildren(req, path, add)
+	}
+
+	// Scopes must carry the nested arrays the guidelines mark as mandatory
+	for i, scope := range rtmData.Scopes {
+		if scope.UserStories == nil {
+			add(fmt.Sprintf("scopes[%d].user_stories", i), "missing mandatory field")
+		}
+		for j, story := range scope.UserStories {
+			if story.TechSpecs == nil {
+				add(fmt.Sprintf("scopes[%d].user_stories[%d].tech_specs", i, j), "missing mandatory field")
+			}
+		}
+	}
+
+	// Fields common to every requirement, in either format
+	keys := make(map[string]string) // requirement key -> path of first occurrence
+	for _, req := range flattenRequirements(rtmData) {
+		if req.Key == "" {
+			add(req.Path+".id", "missing mandatory field")
+		} else if first, exists := keys[req.Key]; exists {
+			add(req.Path+".id", "duplicate requirement key %q (first defined at %s)", req.Key, first)
+		} else {
+			keys[req.Key] = req.Path
+		}
+		if req.Title == "" {
+			add(req.Path+".name", "missing mandatory field")
+		}
+		if req.Type == "" {
+			add(req.Path+".type", "missing mandatory field")
+		} else if !isOneOf(req.Type, validReqTypes) {
+			add(req.Path+".type", "invalid requirement type %q (expected one of SCOPE, USER_STORY, TECH_SPEC)", req.Type)
+		}
+		if req.ParentKey == "" {
+			if req.ComponentKey == "" {
+				add(req.Path+".component_id", "missing mandatory field")
+			} else if !components[req.ComponentKey] {
+				add(req.Path+".component_id", "unknown component id %q", req.ComponentKey)
+			}
+		}
+		if req.Priority != "" && !isOneOf(req.Priority, validPriorities) {
+			add(req.Path+".priority", "invalid priority %q (expected one of %s)", req.Priority, strings.Join(validPriorities, ", "))
+		}
+		if req.Status != "" && !isOneOf(req.Status, validStatuses) {
+			add(req.Path+".status", "invalid status %q (expected one of %s)", req.Status, strings.Join(validStatuses, ", "))
+		}
+		for i, file := range implementationRows(req.Implementation) {
+			if file.FilePath == "" {
+				add(fmt.Sprintf("%s.implementation.files[%d].path", req.Path, i), "missing mandatory field")
+			}
+		}
+		for _, link := range testLinkRows(req.Tests) {
+			if link.FilePath == "" {
+				add(req.Path+".test_coverage", "test function %q has no file", link.TestName)
+			}
+		}
+	}
+
+	// API endpoints
+	for i, endpoint := range rtmData.APIEndpoints {
+		path := fmt.Sprintf("api_endpoints[%d]", i)
+		if endpoint.Method == "" {
+			add(path+".method", "missing mandatory field")
+		} else if !isOneOf(strings.ToUpper(endpoint.Method), validMethods) {
+			add(path+".method", "invalid HTTP method %q", endpoint.Method)
+		}
+		if endpoint.Path == "" {
+			add(path+".path", "missing mandatory field")
+		}
+	}
+
+	return issues
+}
+
+// validateChildren checks that each child in the flat format sits directly
+// below the level it belongs to: scope -> user story -> tech spec
+func validateChildren(req *models.Requirement, path string, add func(path, format string, args ...interface{})) {
+	expected := ""
+	switch normalizeEnum(req.RequirementType) {
+	case "scope":
+		expected = "user_story"
+	case "user_story":
+		expected = "tech_spec"
+	case "tech_spec":
+		if len(req.Children) > 0 {
+			add(path+".children", "tech specs cannot have children")
+		}
+		return
+	}
+
+	for i := range req.Children {
+		child := &req.Children[i]
+		childPath := fmt.Sprintf("%s.children[%d]", path, i)
+		if expected != "" && child.RequirementType != "" && normalizeEnum(child.RequirementType) != expected {
+			add(childPath+".type", "orphan %s: expected %s under %s %q",
+				child.RequirementType, strings.ToUpper(expected), strings.ToUpper(req.RequirementType), req.ID)
+		}
+		validateChildren(child, childPath, add)
+	}
+}
+
+// mergeIssues appends the issues of b that do not repeat a path already reported in a
+func mergeIssues(a, b []ValidationIssue) []ValidationIssue {
+	seen := make(map[string]bool, len(a))
+	for _, issue := range a {
+		seen[issue.Path] = true
+	}
+	merged := append([]ValidationIssue(nil), a...)
+	for _, issue := range b {
+		if !seen[issue.Path] {
+			merged = append(merged, issue)
+		}
+	}
+	return merged
+}
+
+func normalizeEnum(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(value)
+}
+
+func isOneOf(value string, allowed []string) bool {
+	normalized := normalizeEnum(value)
+	for _, a := range allowed {
+		if normalized == normalizeEnum(a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+// Package logging sets up the process-wide leveled logger. Packages log
+// through log/slog; only the command layer decides the level.
+package logging
+
+import (
+	"log/slog"
+	"os"
+)
+
+// Setup installs a text logger writing to stderr. Debug messages are shown
+// only when verbose is set.
+func Setup(verbose bool) {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+}
@@ -16,6 +16,7 @@ type RTMData struct {
 
 // RTMMetadata represents the metadata wrapper in the JSON
 type RTMMetadata struct {
+	SchemaVersion string `json:"schema_version,omitempty" yaml:"schema_version,omitempty"` // see internal/schema
 	GeneratedAt string  `json:"generated_at" yaml:"generated_at"`
 	GeneratedBy string  `json:"generated_by" yaml:"generated_by"`
 	Project     Project `json:"project" yaml:"project"`
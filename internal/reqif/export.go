@@ -0,0 +1,425 @@
+package reqif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// node is one requirement of either RTM format with its hierarchy resolved
+type node struct {
+	req          models.Requirement // Children is not used
+	componentKey string
+	children     []*node
+	id           string // SPEC-OBJECT identifier
+}
+
+// testCase is one test function linked to requirements
+type testCase struct {
+	file, function, testType string
+	id                       string
+	requirements             []string // SPEC-OBJECT identifiers
+}
+
+// exporter assigns identifiers and collects the parts of the document
+type exporter struct {
+	now     string
+	used    map[string]bool
+	enumIDs map[string]string // enumeration|value -> ENUM-VALUE identifier
+	content content
+}
+
+// Export writes rtmData as a ReqIF document. Implementation file mappings
+// have no ReqIF counterpart and are left out.
+func Export(w io.Writer, rtmData *models.RTMData, now time.Time) error {
+	e := &exporter{now: now.UTC().Format(time.RFC3339), used: make(map[string]bool), enumIDs: make(map[string]string)}
+	roots := requirementTree(rtmData)
+
+	project := rtmData.Project
+	if project.ID == "" {
+		project = rtmData.Metadata.Project
+	}
+	doc := document{
+		Xmlns: Namespace,
+		Header: header{
+			Identifier:   e.identifier("HDR", project.ID),
+			CreationTime: e.now,
+			RepositoryID: project.ID,
+			ToolID:       "TraceVibe",
+			Version:      "1.0",
+			SourceToolID: "TraceVibe",
+			Title:        project.Name,
+		},
+	}
+
+	e.addTypes(roots)
+
+	// Components, each with a specification holding its requirement tree
+	componentIDs := make(map[string]string) // component key -> SPEC-OBJECT identifier
+	for _, component := range rtmData.SystemComponents {
+		id := e.identifier("COMP", component.ID)
+		componentIDs[component.ID] = id
+		e.content.SpecObjects = append(e.content.SpecObjects, specObject{
+			identifiable: e.identifiable(id, component.Name),
+			Type:         "SOT-COMPONENT",
+			Values: stringValues(
+				str("AD-COMP-KEY", component.ID),
+				str("AD-COMP-NAME", component.Name),
+				str("AD-COMP-TEXT", component.Description),
+				str("AD-COMP-TYPE", component.ComponentType),
+				str("AD-COMP-TECHNOLOGY", component.Technology),
+				str("AD-COMP-PATH", component.Path),
+				str("AD-COMP-TAGS", strings.Join(component.Tags, ", ")),
+			),
+		})
+	}
+
+	// Requirements, depth first so the document reads like the tree
+	var tests []*testCase
+	testIndex := make(map[string]*testCase)
+	var addRequirement func(n *node)
+	addRequirement = func(n *node) {
+		n.id = e.identifier("REQ", n.req.ID)
+		values := stringValues(
+			str("AD-REQ-KEY", n.req.ID),
+			str("AD-REQ-NAME", n.req.Title),
+			str("AD-REQ-TEXT", n.req.Description),
+			str("AD-REQ-TYPE", n.req.RequirementType),
+			str("AD-REQ-CATEGORY", n.req.Category),
+			str("AD-REQ-CRITERIA", strings.Join(n.req.AcceptanceCriteria, "\n")),
+			str("AD-REQ-COMPONENT", n.componentKey),
+		)
+		values.Enumerations = enums(
+			e.enum("AD-REQ-PRIORITY", "PRIORITY", n.req.Priority),
+			e.enum("AD-REQ-STATUS", "STATUS", n.req.Status),
+		)
+		e.content.SpecObjects = append(e.content.SpecObjects, specObject{
+			identifiable: e.identifiable(n.id, n.req.Title),
+			Type:         "SOT-REQUIREMENT",
+			Values:       values,
+		})
+
+		for _, link := range testLinks(n.req.Tests) {
+			key := link.testType + "|" + link.file + "|" + link.function
+			tc, ok := testIndex[key]
+			if !ok {
+				tc = link
+				testIndex[key] = tc
+				tests = append(tests, tc)
+			}
+			tc.requirements = append(tc.requirements, n.id)
+		}
+		for _, child := range n.children {
+			addRequirement(child)
+		}
+	}
+	for _, root := range roots {
+		addRequirement(root)
+	}
+
+	for _, tc := range tests {
+		tc.id = e.identifier("TEST", tc.file+"-"+tc.function)
+		e.content.SpecObjects = append(e.content.SpecObjects, specObject{
+			identifiable: e.identifiable(tc.id, tc.function),
+			Type:         "SOT-TEST",
+			Values: stringValues(
+				str("AD-TEST-NAME", tc.function),
+				str("AD-TEST-FILE", tc.file),
+				str("AD-TEST-TYPE", tc.testType),
+			),
+		})
+	}
+
+	// Trace relations
+	var relate func(n *node)
+	relate = func(n *node) {
+		for _, child := range n.children {
+			e.addRelation("SRT-REFINES", child.id, n.id)
+			relate(child)
+		}
+	}
+	for _, root := range roots {
+		if componentID, ok := componentIDs[root.componentKey]; ok {
+			e.addRelation("SRT-ALLOCATED", root.id, componentID)
+		}
+		relate(root)
+	}
+	for _, tc := range tests {
+		for _, reqID := range tc.requirements {
+			e.addRelation("SRT-VERIFIES", tc.id, reqID)
+		}
+	}
+
+	// One specification per component
+	var hierarchy func(n *node) specHierarchy
+	hierarchy = func(n *node) specHierarchy {
+		h := specHierarchy{identifiable: e.identifiable(e.identifier("SH", n.req.ID), ""), Object: n.id}
+		for _, child := range n.children {
+			h.Children = h.Children.add(hierarchy(child))
+		}
+		return h
+	}
+	for _, component := range rtmData.SystemComponents {
+		spec := specification{
+			identifiable: e.identifiable(e.identifier("SPEC", component.ID), component.Name),
+			Type:         "ST-COMPONENT",
+		}
+		for _, root := range roots {
+			if root.componentKey == component.ID {
+				spec.Children = spec.Children.add(hierarchy(root))
+			}
+		}
+		e.content.Specifications = append(e.content.Specifications, spec)
+	}
+
+	doc.Content = e.content
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode ReqIF: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// addTypes declares the datatypes and spec types. The priority and status
+// enumerations list the values that occur in the data.
+func (e *exporter) addTypes(roots []*node) {
+	priorities := make(map[string]bool)
+	statuses := make(map[string]bool)
+	var collect func(n *node)
+	collect = func(n *node) {
+		if n.req.Priority != "" {
+			priorities[n.req.Priority] = true
+		}
+		if n.req.Status != "" {
+			statuses[n.req.Status] = true
+		}
+		for _, child := range n.children {
+			collect(child)
+		}
+	}
+	for _, root := range roots {
+		collect(root)
+	}
+
+	e.content.Datatypes = datatypes{
+		Strings: []datatypeString{{identifiable: e.identifiable("DT-STRING", "String"), MaxLength: 65535}},
+		Enumerations: []datatypeEnumeration{
+			e.enumeration("PRIORITY", AttrPriority, priorities),
+			e.enumeration("STATUS", AttrStatus, statuses),
+		},
+	}
+
+	no := false
+	stringAttr := func(id, name string) attributeDefinition {
+		return attributeDefinition{identifiable: e.identifiable(id, name), StringType: "DT-STRING"}
+	}
+	enumAttr := func(id, name, datatype string) attributeDefinition {
+		return attributeDefinition{identifiable: e.identifiable(id, name), MultiValued: &no, EnumType: datatype}
+	}
+
+	e.content.SpecTypes = specTypes{
+		ObjectTypes: []specType{
+			{
+				identifiable: e.identifiable("SOT-REQUIREMENT", TypeRequirement),
+				Attributes: &attributeDefinitions{
+					Strings: []attributeDefinition{
+						stringAttr("AD-REQ-KEY", AttrKey),
+						stringAttr("AD-REQ-NAME", AttrName),
+						stringAttr("AD-REQ-TEXT", AttrText),
+						stringAttr("AD-REQ-TYPE", AttrType),
+						stringAttr("AD-REQ-CATEGORY", AttrCategory),
+						stringAttr("AD-REQ-CRITERIA", AttrAcceptanceCriteria),
+						stringAttr("AD-REQ-COMPONENT", AttrComponent),
+					},
+					Enumerations: []attributeDefinition{
+						enumAttr("AD-REQ-PRIORITY", AttrPriority, "DT-PRIORITY"),
+						enumAttr("AD-REQ-STATUS", AttrStatus, "DT-STATUS"),
+					},
+				},
+			},
+			{
+				identifiable: e.identifiable("SOT-COMPONENT", TypeComponent),
+				Attributes: &attributeDefinitions{
+					Strings: []attributeDefinition{
+						stringAttr("AD-COMP-KEY", AttrKey),
+						stringAttr("AD-COMP-NAME", AttrName),
+						stringAttr("AD-COMP-TEXT", AttrText),
+						stringAttr("AD-COMP-TYPE", AttrComponentType),
+						stringAttr("AD-COMP-TECHNOLOGY", AttrTechnology),
+						stringAttr("AD-COMP-PATH", AttrPath),
+						stringAttr("AD-COMP-TAGS", AttrTags),
+					},
+				},
+			},
+			{
+				identifiable: e.identifiable("SOT-TEST", TypeTest),
+				Attributes: &attributeDefinitions{
+					Strings: []attributeDefinition{
+						stringAttr("AD-TEST-NAME", AttrName),
+						stringAttr("AD-TEST-FILE", AttrFile),
+						stringAttr("AD-TEST-TYPE", AttrTestType),
+					},
+				},
+			},
+		},
+		RelationTypes: []specType{
+			{identifiable: e.identifiable("SRT-REFINES", RelationRefines)},
+			{identifiable: e.identifiable("SRT-ALLOCATED", RelationAllocated)},
+			{identifiable: e.identifiable("SRT-VERIFIES", RelationVerifies)},
+		},
+		SpecificationTypes: []specType{
+			{identifiable: e.identifiable("ST-COMPONENT", TypeComponent)},
+		},
+	}
+}
+
+func (e *exporter) enumeration(name, longName string, values map[string]bool) datatypeEnumeration {
+	sorted := make([]string, 0, len(values))
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+
+	dt := datatypeEnumeration{identifiable: e.identifiable("DT-"+name, longName)}
+	for i, value := range sorted {
+		id := e.identifier("EV-"+name, value)
+		e.enumIDs[name+"|"+value] = id
+		dt.Values = append(dt.Values, enumValue{
+			identifiable: e.identifiable(id, value),
+			Embedded:     embeddedValue{Key: i, OtherContent: value},
+		})
+	}
+	return dt
+}
+
+func (e *exporter) addRelation(relationType, source, target string) {
+	e.content.SpecRelations = append(e.content.SpecRelations, specRelation{
+		identifiable: e.identifiable(e.identifier("REL", source+"-"+target), ""),
+		Source:       source,
+		Target:       target,
+		Type:         relationType,
+	})
+}
+
+// identifier returns a document-unique identifier for a key
+func (e *exporter) identifier(prefix, key string) string {
+	base := identifier(prefix, key)
+	id := base
+	for i := 2; e.used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	e.used[id] = true
+	return id
+}
+
+func (e *exporter) identifiable(id, longName string) identifiable {
+	return identifiable{Identifier: id, LongName: longName, LastChange: e.now}
+}
+
+func str(definition, value string) valueString {
+	return valueString{Definition: definition, Value: value}
+}
+
+// stringValues keeps the non-empty string values
+func stringValues(values ...valueString) attributeValues {
+	var v attributeValues
+	for _, value := range values {
+		if value.Value != "" {
+			v.Strings = append(v.Strings, value)
+		}
+	}
+	return v
+}
+
+func (e *exporter) enum(definition, enumeration, value string) valueEnumeration {
+	if value == "" {
+		return valueEnumeration{}
+	}
+	return valueEnumeration{Definition: definition, Values: []string{e.enumIDs[enumeration+"|"+value]}}
+}
+
+func enums(values ...valueEnumeration) []valueEnumeration {
+	var v []valueEnumeration
+	for _, value := range values {
+		if value.Definition != "" {
+			v = append(v, value)
+		}
+	}
+	return v
+}
+
+// requirementTree resolves both RTM requirement formats into one tree.
+// Children in the flat format use their top-level ancestor's component.
+func requirementTree(rtmData *models.RTMData) []*node {
+	var roots []*node
+
+	var walk func(req models.Requirement, componentKey string) *node
+	walk = func(req models.Requirement, componentKey string) *node {
+		n := &node{req: req, componentKey: componentKey}
+		for _, child := range req.Children {
+			n.children = append(n.children, walk(child, componentKey))
+		}
+		n.req.Children = nil
+		return n
+	}
+	for _, req := range rtmData.Requirements {
+		roots = append(roots, walk(req, req.ComponentID))
+	}
+
+	for _, scope := range rtmData.Scopes {
+		scopeNode := &node{componentKey: scope.ComponentID, req: models.Requirement{
+			ID: scope.ID, RequirementType: "SCOPE", Title: scope.Name, Description: scope.Description,
+			Category: "scope", Priority: scope.Priority, Status: scope.Status,
+		}}
+		for _, story := range scope.UserStories {
+			storyNode := &node{componentKey: scope.ComponentID, req: models.Requirement{
+				ID: story.ID, RequirementType: "USER_STORY", Title: story.Name, Description: story.Description,
+				Category: "user_story", Priority: story.Priority, Status: story.Status,
+			}}
+			for _, spec := range story.TechSpecs {
+				storyNode.children = append(storyNode.children, &node{componentKey: scope.ComponentID, req: models.Requirement{
+					ID: spec.ID, RequirementType: "TECH_SPEC", Title: spec.Name, Description: spec.Description,
+					Category: "tech_spec", Priority: spec.Priority, Status: spec.Status,
+					AcceptanceCriteria: spec.AcceptanceCriteria, Tests: spec.TestCoverage,
+				}})
+			}
+			scopeNode.children = append(scopeNode.children, storyNode)
+		}
+		roots = append(roots, scopeNode)
+	}
+
+	return roots
+}
+
+// testLinks lists the test functions of a requirement's coverage, typed by
+// the test_coverage key they were listed under
+func testLinks(tests *models.TestCoverage) []*testCase {
+	if tests == nil {
+		return nil
+	}
+	var links []*testCase
+	add := func(testType string, files []models.TestFile) {
+		for _, file := range files {
+			for _, function := range file.Functions {
+				links = append(links, &testCase{file: file.File, function: function, testType: testType})
+			}
+		}
+	}
+	add("backend", tests.Backend)
+	add("frontend", tests.Frontend)
+	add("unit_tests", tests.UnitTests)
+	add("integration_tests", tests.IntegrationTests)
+	add("e2e_tests", tests.E2ETests)
+	return links
+}
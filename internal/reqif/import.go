@@ -0,0 +1,380 @@
+package reqif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// Result is a ReqIF document converted to RTM data
+type Result struct {
+	RTMData *models.RTMData
+	// Objects maps the JSON path of each requirement in RTMData to the
+	// identifier of the SPEC-OBJECT it came from
+	Objects map[string]string
+	// Warnings are parts of the document that were skipped
+	Warnings []Issue
+}
+
+// Issue is a problem with one element of a ReqIF document
+type Issue struct {
+	Identifier string
+	Message    string
+}
+
+// Attribute LONG-NAMEs accepted for each field, normalized. The first is the
+// one Export writes; the rest are common in files from other tools.
+var fieldNames = map[string][]string{
+	"key":        {AttrKey, "ID", "Key", "Requirement Key", "Object Identifier"},
+	"name":       {AttrName, "Name", "Title", "Summary", "ReqIF.ChapterName"},
+	"text":       {AttrText, "Description", "Text", "Object Text"},
+	"type":       {AttrType, "Requirement Type"},
+	"category":   {AttrCategory},
+	"priority":   {AttrPriority},
+	"status":     {AttrStatus},
+	"criteria":   {AttrAcceptanceCriteria, "Criteria"},
+	"component":  {AttrComponent},
+	"compType":   {AttrComponentType},
+	"technology": {AttrTechnology},
+	"path":       {AttrPath},
+	"tags":       {AttrTags},
+	"file":       {AttrFile},
+	"testType":   {AttrTestType},
+}
+
+// object is a SPEC-OBJECT with its attribute values resolved by LONG-NAME
+type object struct {
+	id     string
+	kind   string // TypeRequirement, TypeComponent or TypeTest
+	values map[string]string
+}
+
+func (o *object) get(field string) string {
+	for _, name := range fieldNames[field] {
+		if value, ok := o.values[normalizeName(name)]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// Import converts a ReqIF document to RTM data. The requirement hierarchy is
+// read from the SPECIFICATIONs, falling back to "Refines" relations for
+// objects outside them; requirements without a type get one from their
+// depth. Any object whose type is not a component or a test is a requirement.
+func Import(r io.Reader) (*Result, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse ReqIF: %w", err)
+	}
+	c := &doc.Content
+	result := &Result{Objects: make(map[string]string)}
+
+	// Resolve identifiers to names
+	attrNames := make(map[string]string) // ATTRIBUTE-DEFINITION identifier -> normalized LONG-NAME
+	objectTypes := make(map[string]string)
+	for _, t := range c.SpecTypes.ObjectTypes {
+		objectTypes[t.Identifier] = objectKind(t.LongName)
+		if t.Attributes == nil {
+			continue
+		}
+		for _, defs := range [][]attributeDefinition{t.Attributes.Strings, t.Attributes.XHTML, t.Attributes.Enumerations} {
+			for _, def := range defs {
+				attrNames[def.Identifier] = normalizeName(def.LongName)
+			}
+		}
+	}
+	relationTypes := make(map[string]string)
+	for _, t := range c.SpecTypes.RelationTypes {
+		relationTypes[t.Identifier] = normalizeName(t.LongName)
+	}
+	enumValues := make(map[string]string)
+	for _, dt := range c.Datatypes.Enumerations {
+		for _, v := range dt.Values {
+			value := v.LongName
+			if value == "" {
+				value = v.Embedded.OtherContent
+			}
+			enumValues[v.Identifier] = value
+		}
+	}
+
+	// Objects, in document order
+	objects := make(map[string]*object)
+	var order []*object
+	for _, so := range c.SpecObjects {
+		o := &object{id: so.Identifier, kind: objectTypes[so.Type], values: make(map[string]string)}
+		if o.kind == "" {
+			o.kind = TypeRequirement
+		}
+		for _, v := range so.Values.Strings {
+			o.values[attrNames[v.Definition]] = v.Value
+		}
+		for _, v := range so.Values.XHTML {
+			o.values[attrNames[v.Definition]] = xhtmlText(v.Value.Inner)
+		}
+		for _, v := range so.Values.Enumerations {
+			var names []string
+			for _, ref := range v.Values {
+				names = append(names, enumValues[ref])
+			}
+			o.values[attrNames[v.Definition]] = strings.Join(names, ", ")
+		}
+		if so.LongName != "" && o.get("name") == "" {
+			o.values[normalizeName(AttrName)] = so.LongName
+		}
+		objects[o.id] = o
+		order = append(order, o)
+	}
+
+	// Hierarchy: specifications first, then Refines relations
+	parents := make(map[*object]*object)
+	placed := make(map[*object]bool)
+	var specOrder []*object
+	var walk func(h specHierarchy, parent *object)
+	walk = func(h specHierarchy, parent *object) {
+		o := objects[h.Object]
+		if o == nil {
+			result.Warnings = append(result.Warnings, Issue{h.Identifier, fmt.Sprintf("refers to unknown SPEC-OBJECT %q", h.Object)})
+			return
+		}
+		if o.kind != TypeRequirement {
+			parent = nil // a heading for a component or test, not a requirement
+		} else if !placed[o] {
+			placed[o] = true
+			specOrder = append(specOrder, o)
+			if parent != nil {
+				parents[o] = parent
+			}
+			parent = o
+		}
+		for _, child := range h.Children.items() {
+			walk(child, parent)
+		}
+	}
+	for _, spec := range c.Specifications {
+		for _, h := range spec.Children.items() {
+			walk(h, nil)
+		}
+	}
+
+	allocated := make(map[*object]*object) // requirement -> component
+	verifiedBy := make(map[*object][]*object)
+	for _, rel := range c.SpecRelations {
+		source, target := objects[rel.Source], objects[rel.Target]
+		if source == nil || target == nil {
+			result.Warnings = append(result.Warnings, Issue{rel.Identifier, "relation between unknown SPEC-OBJECTs skipped"})
+			continue
+		}
+		switch relationTypes[rel.Type] {
+		case normalizeName(RelationRefines), "parent", "childof":
+			if source.kind == TypeRequirement && target.kind == TypeRequirement && !placed[source] {
+				if _, ok := parents[source]; !ok {
+					parents[source] = target
+				}
+			}
+		case normalizeName(RelationAllocated):
+			if target.kind == TypeComponent {
+				allocated[source] = target
+			}
+		case normalizeName(RelationVerifies):
+			if source.kind == TypeTest {
+				verifiedBy[target] = append(verifiedBy[target], source)
+			}
+		}
+	}
+
+	// Requirements in specification order, then any left outside them
+	var requirements []*object
+	seen := make(map[*object]bool)
+	for _, o := range append(specOrder, order...) {
+		if o.kind == TypeRequirement && !seen[o] {
+			seen[o] = true
+			requirements = append(requirements, o)
+		}
+	}
+	children := make(map[*object][]*object)
+	var roots []*object
+	for _, o := range requirements {
+		if parent, ok := parents[o]; ok && !createsCycle(o, parents) {
+			children[parent] = append(children[parent], o)
+		} else {
+			roots = append(roots, o)
+		}
+	}
+
+	rtmData := &models.RTMData{}
+	rtmData.Project.ID = doc.Header.RepositoryID
+	rtmData.Project.Name = doc.Header.Title
+
+	for _, o := range order {
+		if o.kind != TypeComponent {
+			continue
+		}
+		key := o.get("key")
+		if key == "" {
+			key = o.id
+		}
+		rtmData.SystemComponents = append(rtmData.SystemComponents, models.SystemComponent{
+			ID:            key,
+			Name:          o.get("name"),
+			ComponentType: o.get("compType"),
+			Technology:    o.get("technology"),
+			Description:   o.get("text"),
+			Path:          o.get("path"),
+			Tags:          splitList(o.get("tags")),
+		})
+	}
+
+	var build func(o *object, path string, depth int) models.Requirement
+	build = func(o *object, path string, depth int) models.Requirement {
+		result.Objects[path] = o.id
+		req := models.Requirement{
+			ID:                 o.get("key"),
+			ComponentID:        o.get("component"),
+			RequirementType:    o.get("type"),
+			Title:              o.get("name"),
+			Description:        o.get("text"),
+			Category:           o.get("category"),
+			Priority:           o.get("priority"),
+			Status:             o.get("status"),
+			AcceptanceCriteria: splitLines(o.get("criteria")),
+		}
+		if req.ID == "" {
+			req.ID = o.id
+		}
+		if req.RequirementType == "" && depth < len(depthTypes) {
+			req.RequirementType = depthTypes[depth]
+		}
+		if component, ok := allocated[o]; ok && req.ComponentID == "" {
+			req.ComponentID = component.get("key")
+			if req.ComponentID == "" {
+				req.ComponentID = component.id
+			}
+		}
+		for _, test := range verifiedBy[o] {
+			addTest(&req, test, &result.Warnings)
+		}
+		for i, child := range children[o] {
+			req.Children = append(req.Children, build(child, fmt.Sprintf("%s.children[%d]", path, i), depth+1))
+		}
+		return req
+	}
+	for i, o := range roots {
+		rtmData.Requirements = append(rtmData.Requirements, build(o, fmt.Sprintf("requirements[%d]", i), 0))
+	}
+
+	// Documents from other tools name components in an attribute rather than
+	// as objects of their own
+	known := make(map[string]bool)
+	for _, component := range rtmData.SystemComponents {
+		known[component.ID] = true
+	}
+	for _, req := range rtmData.Requirements {
+		if id := req.ComponentID; id != "" && !known[id] {
+			known[id] = true
+			rtmData.SystemComponents = append(rtmData.SystemComponents, models.SystemComponent{ID: id, Name: id, ComponentType: "component"})
+		}
+	}
+
+	result.RTMData = rtmData
+	return result, nil
+}
+
+var depthTypes = []string{"SCOPE", "USER_STORY", "TECH_SPEC"}
+
+// objectKind classifies a SPEC-OBJECT-TYPE by its LONG-NAME
+func objectKind(longName string) string {
+	name := normalizeName(longName)
+	switch {
+	case strings.Contains(name, "component"):
+		return TypeComponent
+	case strings.Contains(name, "test"):
+		return TypeTest
+	}
+	return TypeRequirement
+}
+
+// createsCycle reports whether following parents from o leads back to o
+func createsCycle(o *object, parents map[*object]*object) bool {
+	current := o
+	for range parents {
+		next, ok := parents[current]
+		if !ok {
+			return false
+		}
+		if next == o {
+			return true
+		}
+		current = next
+	}
+	return false
+}
+
+func addTest(req *models.Requirement, test *object, warnings *[]Issue) {
+	file, function := test.get("file"), test.get("name")
+	if file == "" || function == "" {
+		*warnings = append(*warnings, Issue{test.id, "test without a file or function name skipped"})
+		return
+	}
+	if req.Tests == nil {
+		req.Tests = &models.TestCoverage{}
+	}
+	var files *[]models.TestFile
+	switch test.get("testType") {
+	case "backend":
+		files = &req.Tests.Backend
+	case "frontend":
+		files = &req.Tests.Frontend
+	case "integration_tests":
+		files = &req.Tests.IntegrationTests
+	case "e2e_tests":
+		files = &req.Tests.E2ETests
+	default:
+		files = &req.Tests.UnitTests
+	}
+	for i := range *files {
+		if (*files)[i].File == file {
+			(*files)[i].Functions = append((*files)[i].Functions, function)
+			return
+		}
+	}
+	*files = append(*files, models.TestFile{File: file, Functions: []string{function}})
+}
+
+var (
+	xhtmlBreak = regexp.MustCompile(`(?i)<(?:\w+:)?br\s*/?>|</(?:\w+:)?(?:p|div|li|h[1-6])>`)
+	xhtmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// xhtmlText flattens an XHTML attribute value to text, one line per block
+func xhtmlText(inner string) string {
+	text := xhtmlTag.ReplaceAllString(xhtmlBreak.ReplaceAllString(inner, "\n"), "")
+	return strings.Join(splitLines(html.UnescapeString(text)), "\n")
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func splitLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
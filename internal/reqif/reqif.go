@@ -0,0 +1,215 @@
+// Package reqif converts RTM data to and from ReqIF, the OMG Requirements
+// Interchange Format read by tools such as DOORS and Polarion.
+//
+// Components, requirements and tests become SPEC-OBJECTs. The requirement
+// hierarchy is written twice, as a SPECIFICATION per component and as
+// "Refines" SPEC-RELATIONs, so tools that only understand one of them still
+// see it. Tests point at the requirements they cover with "Verifies"
+// relations. Requirement keys are kept in the ReqIF.ForeignID attribute, which
+// is what survives a round trip through another tool.
+package reqif
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+)
+
+// Namespace is the ReqIF 1.0/1.1/1.2 XML namespace
+const Namespace = "http://www.omg.org/spec/ReqIF/20110401/reqif.xsd"
+
+// Names of the spec types and attributes TraceVibe writes. Imports match
+// them by LONG-NAME, case-insensitively, so files edited elsewhere still load.
+const (
+	TypeRequirement = "Requirement"
+	TypeComponent   = "Component"
+	TypeTest        = "Test Case"
+
+	RelationRefines   = "Refines"
+	RelationAllocated = "Allocated To"
+	RelationVerifies  = "Verifies"
+
+	AttrKey                = "ReqIF.ForeignID"
+	AttrName               = "ReqIF.Name"
+	AttrText               = "ReqIF.Text"
+	AttrType               = "Type"
+	AttrCategory           = "Category"
+	AttrPriority           = "Priority"
+	AttrStatus             = "Status"
+	AttrAcceptanceCriteria = "Acceptance Criteria"
+	AttrComponent          = "Component"
+	AttrComponentType      = "Component Type"
+	AttrTechnology         = "Technology"
+	AttrPath               = "Path"
+	AttrTags               = "Tags"
+	AttrFile               = "File"
+	AttrTestType           = "Test Type"
+)
+
+type document struct {
+	XMLName xml.Name `xml:"REQ-IF"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Header  header   `xml:"THE-HEADER>REQ-IF-HEADER"`
+	Content content  `xml:"CORE-CONTENT>REQ-IF-CONTENT"`
+}
+
+type header struct {
+	Identifier   string `xml:"IDENTIFIER,attr"`
+	CreationTime string `xml:"CREATION-TIME"`
+	RepositoryID string `xml:"REPOSITORY-ID,omitempty"`
+	ToolID       string `xml:"REQ-IF-TOOL-ID"`
+	Version      string `xml:"REQ-IF-VERSION"`
+	SourceToolID string `xml:"SOURCE-TOOL-ID"`
+	Title        string `xml:"TITLE"`
+}
+
+type content struct {
+	Datatypes      datatypes       `xml:"DATATYPES"`
+	SpecTypes      specTypes       `xml:"SPEC-TYPES"`
+	SpecObjects    []specObject    `xml:"SPEC-OBJECTS>SPEC-OBJECT"`
+	SpecRelations  []specRelation  `xml:"SPEC-RELATIONS>SPEC-RELATION"`
+	Specifications []specification `xml:"SPECIFICATIONS>SPECIFICATION"`
+}
+
+// identifiable holds the attributes every ReqIF element with an identity has
+type identifiable struct {
+	Identifier string `xml:"IDENTIFIER,attr"`
+	LongName   string `xml:"LONG-NAME,attr,omitempty"`
+	LastChange string `xml:"LAST-CHANGE,attr"`
+}
+
+type datatypes struct {
+	Strings      []datatypeString      `xml:"DATATYPE-DEFINITION-STRING"`
+	XHTML        []identifiable        `xml:"DATATYPE-DEFINITION-XHTML"`
+	Enumerations []datatypeEnumeration `xml:"DATATYPE-DEFINITION-ENUMERATION"`
+}
+
+type datatypeString struct {
+	identifiable
+	MaxLength int `xml:"MAX-LENGTH,attr"`
+}
+
+type datatypeEnumeration struct {
+	identifiable
+	Values []enumValue `xml:"SPECIFIED-VALUES>ENUM-VALUE"`
+}
+
+type enumValue struct {
+	identifiable
+	Embedded embeddedValue `xml:"PROPERTIES>EMBEDDED-VALUE"`
+}
+
+type embeddedValue struct {
+	Key          int    `xml:"KEY,attr"`
+	OtherContent string `xml:"OTHER-CONTENT,attr"`
+}
+
+type specTypes struct {
+	ObjectTypes        []specType `xml:"SPEC-OBJECT-TYPE"`
+	RelationTypes      []specType `xml:"SPEC-RELATION-TYPE"`
+	SpecificationTypes []specType `xml:"SPECIFICATION-TYPE"`
+}
+
+type specType struct {
+	identifiable
+	Attributes *attributeDefinitions `xml:"SPEC-ATTRIBUTES,omitempty"`
+}
+
+type attributeDefinitions struct {
+	Strings      []attributeDefinition `xml:"ATTRIBUTE-DEFINITION-STRING"`
+	XHTML        []attributeDefinition `xml:"ATTRIBUTE-DEFINITION-XHTML"`
+	Enumerations []attributeDefinition `xml:"ATTRIBUTE-DEFINITION-ENUMERATION"`
+}
+
+// attributeDefinition covers the STRING, XHTML and ENUMERATION kinds; only
+// the datatype reference element differs between them
+type attributeDefinition struct {
+	identifiable
+	MultiValued *bool  `xml:"MULTI-VALUED,attr,omitempty"` // enumerations only
+	StringType  string `xml:"TYPE>DATATYPE-DEFINITION-STRING-REF,omitempty"`
+	XHTMLType   string `xml:"TYPE>DATATYPE-DEFINITION-XHTML-REF,omitempty"`
+	EnumType    string `xml:"TYPE>DATATYPE-DEFINITION-ENUMERATION-REF,omitempty"`
+}
+
+type specObject struct {
+	identifiable
+	Values attributeValues `xml:"VALUES"`
+	Type   string          `xml:"TYPE>SPEC-OBJECT-TYPE-REF"`
+}
+
+type attributeValues struct {
+	Strings      []valueString      `xml:"ATTRIBUTE-VALUE-STRING"`
+	XHTML        []valueXHTML       `xml:"ATTRIBUTE-VALUE-XHTML"`
+	Enumerations []valueEnumeration `xml:"ATTRIBUTE-VALUE-ENUMERATION"`
+}
+
+type valueString struct {
+	Value      string `xml:"THE-VALUE,attr"`
+	Definition string `xml:"DEFINITION>ATTRIBUTE-DEFINITION-STRING-REF"`
+}
+
+type valueXHTML struct {
+	Definition string `xml:"DEFINITION>ATTRIBUTE-DEFINITION-XHTML-REF"`
+	Value      struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"THE-VALUE"`
+}
+
+type valueEnumeration struct {
+	Definition string   `xml:"DEFINITION>ATTRIBUTE-DEFINITION-ENUMERATION-REF"`
+	Values     []string `xml:"VALUES>ENUM-VALUE-REF"`
+}
+
+type specRelation struct {
+	identifiable
+	Target string `xml:"TARGET>SPEC-OBJECT-REF"`
+	Source string `xml:"SOURCE>SPEC-OBJECT-REF"`
+	Type   string `xml:"TYPE>SPEC-RELATION-TYPE-REF"`
+}
+
+type specification struct {
+	identifiable
+	Children *hierarchyChildren `xml:"CHILDREN,omitempty"`
+	Type     string             `xml:"TYPE>SPECIFICATION-TYPE-REF"`
+}
+
+type specHierarchy struct {
+	identifiable
+	Children *hierarchyChildren `xml:"CHILDREN,omitempty"`
+	Object   string             `xml:"OBJECT>SPEC-OBJECT-REF"`
+}
+
+// hierarchyChildren is a pointer in its parents because encoding/xml writes
+// an empty CHILDREN element for a nil "CHILDREN>SPEC-HIERARCHY" slice, and
+// CHILDREN may not be empty
+type hierarchyChildren struct {
+	Items []specHierarchy `xml:"SPEC-HIERARCHY"`
+}
+
+func (c *hierarchyChildren) add(h specHierarchy) *hierarchyChildren {
+	if c == nil {
+		c = &hierarchyChildren{}
+	}
+	c.Items = append(c.Items, h)
+	return c
+}
+
+func (c *hierarchyChildren) items() []specHierarchy {
+	if c == nil {
+		return nil
+	}
+	return c.Items
+}
+
+var invalidIDChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// identifier turns a key into an xsd:ID, which must start with a letter or
+// underscore and may not contain spaces or colons
+func identifier(prefix, key string) string {
+	return prefix + "-" + strings.Trim(invalidIDChars.ReplaceAllString(key, "_"), "_")
+}
+
+// normalizeName compares LONG-NAMEs ignoring case, spaces and punctuation
+func normalizeName(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "", ".", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
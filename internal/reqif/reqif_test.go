@@ -0,0 +1,275 @@
+package reqif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *models.RTMData
+		want *models.RTMData // nil when the import gives back the input
+	}{
+		{
+			name: "requirements",
+			in: &models.RTMData{
+				Project: models.Project{ID: "shop", Name: "Shop & Co"},
+				SystemComponents: []models.SystemComponent{{
+					ID: "pay", Name: "Payments", ComponentType: "service", Technology: "Go",
+					Description: "Takes <card> payments", Path: "services/pay", Tags: []string{"core", "pci"},
+				}},
+				Requirements: []models.Requirement{{
+					ID: "PAY-1", ComponentID: "pay", RequirementType: "SCOPE", Title: "Payments", Category: "scope", Priority: "high", Status: "active",
+					Children: []models.Requirement{{
+						ID: "PAY-1.1", ComponentID: "pay", RequirementType: "USER_STORY", Title: "Pay by card", Category: "user_story", Priority: "low",
+						AcceptanceCriteria: []string{"charged once", "receipt sent"},
+						Tests: &models.TestCoverage{
+							UnitTests: []models.TestFile{{File: "pay/card_test.go", Functions: []string{"TestCharge", "TestReceipt"}}},
+							E2ETests:  []models.TestFile{{File: "e2e/pay.spec.ts", Functions: []string{"pays by card"}}},
+						},
+						Children: []models.Requirement{{ID: "PAY-1.1.1", ComponentID: "pay", RequirementType: "TECH_SPEC", Title: "Idempotency keys"}},
+					}},
+				}, {
+					ID: "PAY-2", ComponentID: "pay", RequirementType: "SCOPE", Title: "Refunds", Status: "active",
+					Tests: &models.TestCoverage{UnitTests: []models.TestFile{{File: "pay/card_test.go", Functions: []string{"TestCharge"}}}},
+				}},
+			},
+		},
+		{
+			name: "children take the component of their top-level requirement",
+			in: &models.RTMData{
+				Project: models.Project{ID: "app"},
+				Requirements: []models.Requirement{{
+					ID: "A", ComponentID: "api", RequirementType: "SCOPE", Title: "API",
+					Children: []models.Requirement{{ID: "B", ComponentID: "web", RequirementType: "USER_STORY", Title: "Story"}},
+				}},
+			},
+			want: &models.RTMData{
+				Project:          models.Project{ID: "app"},
+				SystemComponents: []models.SystemComponent{{ID: "api", Name: "api", ComponentType: "component"}},
+				Requirements: []models.Requirement{{
+					ID: "A", ComponentID: "api", RequirementType: "SCOPE", Title: "API",
+					Children: []models.Requirement{{ID: "B", ComponentID: "api", RequirementType: "USER_STORY", Title: "Story"}},
+				}},
+			},
+		},
+		{
+			name: "keys that are not identifiers",
+			in: &models.RTMData{
+				Project: models.Project{ID: "my project"},
+				Requirements: []models.Requirement{
+					{ID: "REQ 1", RequirementType: "SCOPE", Title: "One"},
+					{ID: "REQ:1", RequirementType: "SCOPE", Title: "Colon"},
+					{ID: "ümlaut", RequirementType: "SCOPE", Title: "Unicode"},
+				},
+			},
+		},
+		{
+			name: "scopes",
+			in: &models.RTMData{
+				Metadata:         models.RTMMetadata{Project: models.Project{ID: "legacy", Name: "Legacy"}},
+				SystemComponents: []models.SystemComponent{{ID: "core", Name: "Core", ComponentType: "library"}},
+				Scopes: []models.Scope{{
+					ID: "S-1", ComponentID: "core", Name: "Scope", Priority: "high",
+					UserStories: []models.UserStory{{
+						ID: "U-1", Name: "Story", Status: "done",
+						TechSpecs: []models.TechSpec{{
+							ID: "T-1", Name: "Spec", AcceptanceCriteria: []string{"works"},
+							TestCoverage: &models.TestCoverage{Backend: []models.TestFile{{File: "core_test.go", Functions: []string{"TestCore"}}}},
+						}},
+					}},
+				}},
+			},
+			want: &models.RTMData{
+				Project:          models.Project{ID: "legacy", Name: "Legacy"},
+				SystemComponents: []models.SystemComponent{{ID: "core", Name: "Core", ComponentType: "library"}},
+				Requirements: []models.Requirement{{
+					ID: "S-1", ComponentID: "core", RequirementType: "SCOPE", Title: "Scope", Category: "scope", Priority: "high",
+					Children: []models.Requirement{{
+						ID: "U-1", ComponentID: "core", RequirementType: "USER_STORY", Title: "Story", Category: "user_story", Status: "done",
+						Children: []models.Requirement{{
+							ID: "T-1", ComponentID: "core", RequirementType: "TECH_SPEC", Title: "Spec", Category: "tech_spec",
+							AcceptanceCriteria: []string{"works"},
+							Tests:              &models.TestCoverage{Backend: []models.TestFile{{File: "core_test.go", Functions: []string{"TestCore"}}}},
+						}},
+					}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc strings.Builder
+			if err := Export(&doc, tt.in, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Import(strings.NewReader(doc.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Warnings) > 0 {
+				t.Errorf("warnings: %+v", result.Warnings)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.in
+			}
+			if !reflect.DeepEqual(result.RTMData, want) {
+				t.Errorf("round trip =\n%+v\nwant\n%+v\ndocument:\n%s", result.RTMData, want, doc.String())
+			}
+		})
+	}
+}
+
+// foreignDocument is a ReqIF document in the style of other tools: XHTML
+// text, enumeration values named by LONG-NAME, hierarchy from relations only
+const foreignDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<REQ-IF xmlns="http://www.omg.org/spec/ReqIF/20110401/reqif.xsd" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <THE-HEADER><REQ-IF-HEADER IDENTIFIER="h"><REPOSITORY-ID>doors</REPOSITORY-ID><TITLE>Module 1</TITLE></REQ-IF-HEADER></THE-HEADER>
+  <CORE-CONTENT><REQ-IF-CONTENT>
+    <DATATYPES>
+      <DATATYPE-DEFINITION-ENUMERATION IDENTIFIER="dt-prio">
+        <SPECIFIED-VALUES>
+          <ENUM-VALUE IDENTIFIER="p1" LONG-NAME="Must"><PROPERTIES><EMBEDDED-VALUE KEY="1" OTHER-CONTENT="1"/></PROPERTIES></ENUM-VALUE>
+          <ENUM-VALUE IDENTIFIER="p2"><PROPERTIES><EMBEDDED-VALUE KEY="2" OTHER-CONTENT="Should"/></PROPERTIES></ENUM-VALUE>
+        </SPECIFIED-VALUES>
+      </DATATYPE-DEFINITION-ENUMERATION>
+    </DATATYPES>
+    <SPEC-TYPES>
+      <SPEC-OBJECT-TYPE IDENTIFIER="sot" LONG-NAME="System Requirement">
+        <SPEC-ATTRIBUTES>
+          <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="ad-id" LONG-NAME="Object Identifier"/>
+          <ATTRIBUTE-DEFINITION-XHTML IDENTIFIER="ad-text" LONG-NAME="Object Text"/>
+          <ATTRIBUTE-DEFINITION-ENUMERATION IDENTIFIER="ad-prio" LONG-NAME="priority"/>
+          <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="ad-comp" LONG-NAME="component"/>
+        </SPEC-ATTRIBUTES>
+      </SPEC-OBJECT-TYPE>
+      <SPEC-RELATION-TYPE IDENTIFIER="srt-parent" LONG-NAME="Child Of"/>
+    </SPEC-TYPES>
+    <SPEC-OBJECTS>
+      <SPEC-OBJECT IDENTIFIER="o1" LONG-NAME="Braking">
+        <VALUES>
+          <ATTRIBUTE-VALUE-STRING THE-VALUE="SYS-1"><DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>ad-id</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION></ATTRIBUTE-VALUE-STRING>
+          <ATTRIBUTE-VALUE-XHTML><DEFINITION><ATTRIBUTE-DEFINITION-XHTML-REF>ad-text</ATTRIBUTE-DEFINITION-XHTML-REF></DEFINITION>
+            <THE-VALUE><xhtml:div><xhtml:p>The car &amp; trailer</xhtml:p><xhtml:p>stop within <xhtml:b>40 m</xhtml:b></xhtml:p></xhtml:div></THE-VALUE></ATTRIBUTE-VALUE-XHTML>
+          <ATTRIBUTE-VALUE-ENUMERATION><DEFINITION><ATTRIBUTE-DEFINITION-ENUMERATION-REF>ad-prio</ATTRIBUTE-DEFINITION-ENUMERATION-REF></DEFINITION>
+            <VALUES><ENUM-VALUE-REF>p1</ENUM-VALUE-REF></VALUES></ATTRIBUTE-VALUE-ENUMERATION>
+          <ATTRIBUTE-VALUE-STRING THE-VALUE="chassis"><DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>ad-comp</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION></ATTRIBUTE-VALUE-STRING>
+        </VALUES>
+        <TYPE><SPEC-OBJECT-TYPE-REF>sot</SPEC-OBJECT-TYPE-REF></TYPE>
+      </SPEC-OBJECT>
+      <SPEC-OBJECT IDENTIFIER="o2" LONG-NAME="ABS">
+        <VALUES>
+          <ATTRIBUTE-VALUE-ENUMERATION><DEFINITION><ATTRIBUTE-DEFINITION-ENUMERATION-REF>ad-prio</ATTRIBUTE-DEFINITION-ENUMERATION-REF></DEFINITION>
+            <VALUES><ENUM-VALUE-REF>p2</ENUM-VALUE-REF></VALUES></ATTRIBUTE-VALUE-ENUMERATION>
+        </VALUES>
+        <TYPE><SPEC-OBJECT-TYPE-REF>sot</SPEC-OBJECT-TYPE-REF></TYPE>
+      </SPEC-OBJECT>
+      <SPEC-OBJECT IDENTIFIER="o3" LONG-NAME="Loop A"><TYPE><SPEC-OBJECT-TYPE-REF>sot</SPEC-OBJECT-TYPE-REF></TYPE></SPEC-OBJECT>
+      <SPEC-OBJECT IDENTIFIER="o4" LONG-NAME="Loop B"><TYPE><SPEC-OBJECT-TYPE-REF>sot</SPEC-OBJECT-TYPE-REF></TYPE></SPEC-OBJECT>
+    </SPEC-OBJECTS>
+    <SPEC-RELATIONS>
+      <SPEC-RELATION IDENTIFIER="r1"><SOURCE><SPEC-OBJECT-REF>o2</SPEC-OBJECT-REF></SOURCE><TARGET><SPEC-OBJECT-REF>o1</SPEC-OBJECT-REF></TARGET><TYPE><SPEC-RELATION-TYPE-REF>srt-parent</SPEC-RELATION-TYPE-REF></TYPE></SPEC-RELATION>
+      <SPEC-RELATION IDENTIFIER="r2"><SOURCE><SPEC-OBJECT-REF>o3</SPEC-OBJECT-REF></SOURCE><TARGET><SPEC-OBJECT-REF>o4</SPEC-OBJECT-REF></TARGET><TYPE><SPEC-RELATION-TYPE-REF>srt-parent</SPEC-RELATION-TYPE-REF></TYPE></SPEC-RELATION>
+      <SPEC-RELATION IDENTIFIER="r3"><SOURCE><SPEC-OBJECT-REF>o4</SPEC-OBJECT-REF></SOURCE><TARGET><SPEC-OBJECT-REF>o3</SPEC-OBJECT-REF></TARGET><TYPE><SPEC-RELATION-TYPE-REF>srt-parent</SPEC-RELATION-TYPE-REF></TYPE></SPEC-RELATION>
+      <SPEC-RELATION IDENTIFIER="r4"><SOURCE><SPEC-OBJECT-REF>o9</SPEC-OBJECT-REF></SOURCE><TARGET><SPEC-OBJECT-REF>o1</SPEC-OBJECT-REF></TARGET><TYPE><SPEC-RELATION-TYPE-REF>srt-parent</SPEC-RELATION-TYPE-REF></TYPE></SPEC-RELATION>
+    </SPEC-RELATIONS>
+    <SPECIFICATIONS>
+      <SPECIFICATION IDENTIFIER="s1"><CHILDREN><SPEC-HIERARCHY IDENTIFIER="sh1"><OBJECT><SPEC-OBJECT-REF>missing</SPEC-OBJECT-REF></OBJECT></SPEC-HIERARCHY></CHILDREN></SPECIFICATION>
+    </SPECIFICATIONS>
+  </REQ-IF-CONTENT></CORE-CONTENT>
+</REQ-IF>`
+
+func TestImport(t *testing.T) {
+	result, err := Import(strings.NewReader(foreignDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.RTMData{
+		Project:          models.Project{ID: "doors", Name: "Module 1"},
+		SystemComponents: []models.SystemComponent{{ID: "chassis", Name: "chassis", ComponentType: "component"}},
+		Requirements: []models.Requirement{
+			{
+				ID: "SYS-1", ComponentID: "chassis", RequirementType: "SCOPE", Title: "Braking",
+				Description: "The car & trailer\nstop within 40 m", Priority: "Must",
+				Children: []models.Requirement{{ID: "o2", RequirementType: "USER_STORY", Title: "ABS", Priority: "Should"}},
+			},
+			// Parents that form a cycle are ignored
+			{ID: "o3", RequirementType: "SCOPE", Title: "Loop A"},
+			{ID: "o4", RequirementType: "SCOPE", Title: "Loop B"},
+		},
+	}
+	if !reflect.DeepEqual(result.RTMData, want) {
+		t.Errorf("Import() =\n%+v\nwant\n%+v", result.RTMData, want)
+	}
+	wantObjects := map[string]string{"requirements[0]": "o1", "requirements[0].children[0]": "o2", "requirements[1]": "o3", "requirements[2]": "o4"}
+	if !reflect.DeepEqual(result.Objects, wantObjects) {
+		t.Errorf("objects = %v, want %v", result.Objects, wantObjects)
+	}
+	wantWarnings := []Issue{
+		{"sh1", `refers to unknown SPEC-OBJECT "missing"`},
+		{"r4", "relation between unknown SPEC-OBJECTs skipped"},
+	}
+	if !reflect.DeepEqual(result.Warnings, wantWarnings) {
+		t.Errorf("warnings = %+v, want %+v", result.Warnings, wantWarnings)
+	}
+
+	if _, err := Import(strings.NewReader("<REQ-IF><THE-HEADER>")); err == nil || !strings.Contains(err.Error(), "failed to parse ReqIF") {
+		t.Errorf("truncated document: error = %v", err)
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		prefix, key string
+		want        string
+	}{
+		{"REQ", "AUTH-1.2", "REQ-AUTH-1.2"},
+		{"REQ", "my key: 1", "REQ-my_key_1"},
+		{"REQ", " --x-- ", "REQ---x--"},
+		{"COMP", "über/app", "COMP-ber_app"},
+		{"HDR", "", "HDR-"},
+	}
+	for _, tt := range tests {
+		if got := identifier(tt.prefix, tt.key); got != tt.want {
+			t.Errorf("identifier(%q, %q) = %q, want %q", tt.prefix, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"ReqIF.ForeignID":      "reqifforeignid",
+		" Acceptance Criteria": "acceptancecriteria",
+		"acceptance_criteria":  "acceptancecriteria",
+		"Test-Type":            "testtype",
+	} {
+		if got := normalizeName(name); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestXHTMLText(t *testing.T) {
+	tests := []struct {
+		inner string
+		want  string
+	}{
+		{"plain", "plain"},
+		{`<xhtml:div><xhtml:p>one</xhtml:p><xhtml:p>two</xhtml:p></xhtml:div>`, "one\ntwo"},
+		{`a<br/>b<BR>c<xhtml:br />d`, "a\nb\nc\nd"},
+		{`<ul><li>x &lt; y</li><li> </li><li>&quot;z&quot;</li></ul>`, "x < y\n\"z\""},
+		{`<p><b>bold</b> and <i>italic</i></p>`, "bold and italic"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := xhtmlText(tt.inner); got != tt.want {
+			t.Errorf("xhtmlText(%q) = %q, want %q", tt.inner, got, tt.want)
+		}
+	}
+}
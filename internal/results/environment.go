@@ -0,0 +1,33 @@
+package results
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// GitCommit returns the commit checked out in dir, or "" when dir is not in a
+// git repository
+func GitCommit(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Environment describes where tests run, e.g. "build-host linux/amd64", with
+// a "ci" prefix under CI services that set CI=true
+func Environment() string {
+	environment := runtime.GOOS + "/" + runtime.GOARCH
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		environment = hostname + " " + environment
+	}
+	if ci := os.Getenv("CI"); ci == "true" || ci == "1" {
+		environment = "ci " + environment
+	}
+	return environment
+}
@@ -0,0 +1,78 @@
+package results
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GoTestRunPattern returns the -run pattern that selects exactly the given
+// tests, e.g. ^(TestA|TestB)$. Subtests select their top-level test.
+func GoTestRunPattern(testNames []string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range testNames {
+		name, _, _ = strings.Cut(name, "/")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "^(" + strings.Join(names, "|") + ")$"
+}
+
+// JestNamePattern returns the jest -t pattern that selects the given tests.
+// Jest matches it against the full name of a test, its describe blocks and
+// title joined by spaces, so a name selects every test whose full name ends
+// with it.
+func JestNamePattern(testNames []string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range testNames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "(^| )(" + strings.Join(names, "|") + ")$"
+}
+
+var pythonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PytestKeywordExpression returns the pytest -k expression that selects the
+// given tests, e.g. "test_a or (TestB and test_c)" for test_a and
+// TestB::test_c. Parameters ("test_x[1]") are dropped, so every case of a
+// parametrized test runs. pytest matches keywords as substrings and may run a
+// few more tests than asked for. When a name cannot be written as a keyword
+// the expression is empty and the whole file should run.
+func PytestKeywordExpression(testNames []string) string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, name := range testNames {
+		name, _, _ = strings.Cut(strings.TrimSpace(name), "[")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		parts := strings.Split(name, "::")
+		for _, part := range parts {
+			if !pythonIdentifier.MatchString(part) {
+				return ""
+			}
+		}
+		term := strings.Join(parts, " and ")
+		if len(parts) > 1 {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " or ")
+}
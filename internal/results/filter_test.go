@@ -0,0 +1,97 @@
+package results
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGoTestRunPattern(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{""}, ""},
+		{[]string{"TestA"}, "^(TestA)$"},
+		{[]string{"TestA", "TestB", "TestA"}, "^(TestA|TestB)$"},
+		{[]string{"TestA/case_1", "TestA/case_2"}, "^(TestA)$"},
+		{[]string{"Test_x.y"}, `^(Test_x\.y)$`},
+	}
+	for _, tt := range tests {
+		if got := GoTestRunPattern(tt.names); got != tt.want {
+			t.Errorf("GoTestRunPattern(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+
+	pattern := regexp.MustCompile(GoTestRunPattern([]string{"TestLogin"}))
+	for name, want := range map[string]bool{"TestLogin": true, "TestLoginFails": false, "XTestLogin": false} {
+		if pattern.MatchString(name) != want {
+			t.Errorf("-run pattern matches %q: %v, want %v", name, !want, want)
+		}
+	}
+}
+
+func TestJestNamePattern(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    string
+		matches []string
+		misses  []string
+	}{
+		{names: nil, want: ""},
+		{names: []string{"  "}, want: ""},
+		{
+			names:   []string{"renders the form", "renders the form"},
+			want:    "(^| )(renders the form)$",
+			matches: []string{"renders the form", "Login renders the form"},
+			misses:  []string{"Login rerenders the form", "renders the form twice"},
+		},
+		{
+			names:   []string{"adds (1 + 2)", "logs out"},
+			want:    `(^| )(adds \(1 \+ 2\)|logs out)$`,
+			matches: []string{"Calc adds (1 + 2)", "logs out"},
+			misses:  []string{"adds 1 + 2"},
+		},
+	}
+	for _, tt := range tests {
+		got := JestNamePattern(tt.names)
+		if got != tt.want {
+			t.Errorf("JestNamePattern(%q) = %q, want %q", tt.names, got, tt.want)
+			continue
+		}
+		if got == "" {
+			continue
+		}
+		pattern := regexp.MustCompile(got)
+		for _, name := range tt.matches {
+			if !pattern.MatchString(name) {
+				t.Errorf("%q does not match %q", got, name)
+			}
+		}
+		for _, name := range tt.misses {
+			if pattern.MatchString(name) {
+				t.Errorf("%q matches %q", got, name)
+			}
+		}
+	}
+}
+
+func TestPytestKeywordExpression(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"test_a"}, "test_a"},
+		{[]string{"test_a", "TestB::test_c", "test_a"}, "test_a or (TestB and test_c)"},
+		{[]string{"test_x[1]", "test_x[2]"}, "test_x"},
+		{[]string{"test_a", "test with spaces"}, ""},
+		{[]string{"test-dash"}, ""},
+		{[]string{"TestB::"}, ""},
+	}
+	for _, tt := range tests {
+		if got := PytestKeywordExpression(tt.names); got != tt.want {
+			t.Errorf("PytestKeywordExpression(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// goTestEvent is one line of go test -json output; see 'go doc test2json'
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // seconds
+	Output  string
+}
+
+// ParseGoTestJSON reads the event stream of go test -json. It returns the
+// outcome of every test, with its package as the class name, and the output
+// as go test -v would have printed it, including lines that are not events
+// such as build errors.
+func ParseGoTestJSON(r io.Reader) ([]Outcome, string, error) {
+	var outcomes []Outcome
+	var text strings.Builder
+	logs := make(map[string][]string) // package and test -> output lines
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		event, ok := parseGoTestEvent(line)
+		text.WriteString(goTestText(line, event, ok))
+		if !ok {
+			continue
+		}
+
+		key := event.Package + " " + event.Test
+		switch event.Action {
+		case "output", "build-output":
+			output := strings.TrimSpace(event.Output)
+			if event.Test != "" && output != "" && !strings.HasPrefix(output, "=== ") && !strings.HasPrefix(output, "--- ") {
+				logs[key] = append(logs[key], output)
+			}
+		case "pass", "fail", "skip":
+			if event.Test == "" {
+				continue
+			}
+			outcome := Outcome{
+				Name:      event.Test,
+				ClassName: event.Package,
+				Status:    goStatus(strings.ToUpper(event.Action)),
+				Duration:  time.Duration(event.Elapsed * float64(time.Second)),
+			}
+			if outcome.Status != database.TestPassed {
+				outcome.Message = truncateMessage(strings.Join(logs[key], "\n"))
+			}
+			delete(logs, key)
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	return outcomes, text.String(), scanner.Err()
+}
+
+func parseGoTestEvent(line string) (goTestEvent, bool) {
+	var event goTestEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+		return event, false
+	}
+	return event, true
+}
+
+// goTestText is what a line of go test -json output reads as in go test -v
+// output: the output of an event, nothing for other events, and the line
+// itself when it is not an event
+func goTestText(line string, event goTestEvent, ok bool) string {
+	switch {
+	case !ok:
+		return line + "\n"
+	case event.Action == "output" || event.Action == "build-output":
+		return event.Output
+	}
+	return ""
+}
+
+// GoTestTextWriter turns the go test -json event stream written to it into
+// go test -v output as it arrives, e.g. to stream a running test
+type GoTestTextWriter struct {
+	w       io.Writer
+	partial []byte
+}
+
+// NewGoTestTextWriter returns a GoTestTextWriter writing to w
+func NewGoTestTextWriter(w io.Writer) *GoTestTextWriter {
+	return &GoTestTextWriter{w: w}
+}
+
+func (g *GoTestTextWriter) Write(p []byte) (int, error) {
+	g.partial = append(g.partial, p...)
+	for {
+		i := bytes.IndexByte(g.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(g.partial[:i])
+		g.partial = g.partial[i+1:]
+		event, ok := parseGoTestEvent(line)
+		if _, err := io.WriteString(g.w, goTestText(line, event, ok)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes a last line that did not end in a newline
+func (g *GoTestTextWriter) Flush() error {
+	if len(g.partial) == 0 {
+		return nil
+	}
+	line := string(g.partial)
+	g.partial = nil
+	event, ok := parseGoTestEvent(line)
+	_, err := io.WriteString(g.w, goTestText(line, event, ok))
+	return err
+}
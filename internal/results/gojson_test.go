@@ -0,0 +1,101 @@
+package results
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+const goTestJSON = `{"Action":"start","Package":"example.com/app/auth"}
+{"Action":"run","Package":"example.com/app/auth","Test":"TestLogin"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestLogin","Output":"=== RUN   TestLogin\n"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestLogin","Output":"--- PASS: TestLogin (0.02s)\n"}
+{"Action":"pass","Package":"example.com/app/auth","Test":"TestLogin","Elapsed":0.02}
+{"Action":"run","Package":"example.com/app/auth","Test":"TestLogout"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestLogout","Output":"=== RUN   TestLogout\n"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestLogout","Output":"    auth_test.go:20: want 200, got 500\n"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestLogout","Output":"--- FAIL: TestLogout (0.10s)\n"}
+{"Action":"fail","Package":"example.com/app/auth","Test":"TestLogout","Elapsed":0.1}
+{"Action":"run","Package":"example.com/app/auth","Test":"TestSSO"}
+{"Action":"output","Package":"example.com/app/auth","Test":"TestSSO","Output":"    sso_test.go:5: needs network\n"}
+{"Action":"skip","Package":"example.com/app/auth","Test":"TestSSO"}
+{"Action":"output","Package":"example.com/app/auth","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/app/auth","Elapsed":0.2}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []Outcome
+		wantText string
+	}{
+		{
+			name:  "events",
+			input: goTestJSON,
+			want: []Outcome{
+				{Name: "TestLogin", ClassName: "example.com/app/auth", Status: database.TestPassed, Duration: 20 * time.Millisecond},
+				{Name: "TestLogout", ClassName: "example.com/app/auth", Status: database.TestFailed, Duration: 100 * time.Millisecond,
+					Message: "auth_test.go:20: want 200, got 500"},
+				{Name: "TestSSO", ClassName: "example.com/app/auth", Status: database.TestSkipped, Message: "sso_test.go:5: needs network"},
+			},
+			wantText: "=== RUN   TestLogin\n--- PASS: TestLogin (0.02s)\n=== RUN   TestLogout\n    auth_test.go:20: want 200, got 500\n" +
+				"--- FAIL: TestLogout (0.10s)\n    sso_test.go:5: needs network\nFAIL\n",
+		},
+		{
+			name: "build errors",
+			input: `# example.com/app/auth
+auth.go:3:1: syntax error
+{"Action":"build-output","ImportPath":"example.com/app/auth","Output":"more\n"}
+{"Action":"fail","Package":"example.com/app/auth"}
+`,
+			wantText: "# example.com/app/auth\nauth.go:3:1: syntax error\nmore\n",
+		},
+		{
+			name:     "not json",
+			input:    "{not json\n",
+			wantText: "{not json\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, text, err := ParseGoTestJSON(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outcomes =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestGoTestTextWriter(t *testing.T) {
+	_, want, _ := ParseGoTestJSON(strings.NewReader(goTestJSON + "trailing line"))
+
+	// Split the stream at awkward places, including inside a line
+	for _, size := range []int{1, 7, 64, len(goTestJSON)} {
+		var out strings.Builder
+		w := NewGoTestTextWriter(&out)
+		input := goTestJSON + "trailing line"
+		for len(input) > 0 {
+			n := min(size, len(input))
+			if _, err := w.Write([]byte(input[:n])); err != nil {
+				t.Fatal(err)
+			}
+			input = input[n:]
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("writes of %d bytes: got %q, want %q", size, out.String(), want)
+		}
+	}
+}
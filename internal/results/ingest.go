@@ -0,0 +1,164 @@
+package results
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// Summary reports what an ingest recorded
+type Summary struct {
+	RunID     string    `json:"run_id"`
+	Source    string    `json:"source"`
+	Total     int       `json:"total"`
+	Matched   int       `json:"matched"`
+	Passed    int       `json:"passed"`
+	Failed    int       `json:"failed"`
+	Errors    int       `json:"errors"`
+	Skipped   int       `json:"skipped"`
+	Unmatched []Outcome `json:"unmatched,omitempty"`
+}
+
+// Ingest matches test outcomes to the test cases of a project and records
+// them as a test run; run carries the source, git commit and environment.
+// Outcomes that match no test case, or several equally well, are listed in
+// the summary but not stored. Subtests ("TestA/case_1") are only recorded
+// when they are test cases of their own; otherwise their parent's outcome
+// stands for them.
+func Ingest(db *database.DB, projectKey string, run database.TestRun, outcomes []Outcome) (*Summary, error) {
+	project, err := db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project '%s' not found", projectKey)
+	}
+	testCases, err := db.ListTestCases(project.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{Source: run.Source}
+	var records []database.TestCaseResult
+	for _, outcome := range outcomes {
+		tc, ok := Match(testCases, outcome)
+		if !ok {
+			if i := strings.Index(outcome.Name, "/"); i > 0 {
+				parent := outcome
+				parent.Name = outcome.Name[:i]
+				if _, ok := Match(testCases, parent); ok {
+					continue
+				}
+			}
+			summary.Total++
+			summary.Unmatched = append(summary.Unmatched, outcome)
+			continue
+		}
+		summary.Total++
+		summary.Matched++
+		records = append(records, outcome.result(tc.ID))
+	}
+
+	run.ProjectID = project.ID
+	for _, record := range records {
+		run.DurationMs += record.DurationMs
+	}
+	if err := db.RecordTestRun(&run, records); err != nil {
+		return nil, err
+	}
+	summary.RunID = run.ID
+	summary.Passed, summary.Failed, summary.Errors, summary.Skipped = run.Passed, run.Failed, run.Errors, run.Skipped
+	return summary, nil
+}
+
+// Match finds the test case an outcome belongs to. Names are compared
+// exactly, without pytest parameters ("test_x[1]"), and against the last
+// part of Jest's "describe title" and pytest's "Class::test" names. When
+// several test cases share the name, the file, class name or suite of the
+// outcome must point at the test file: a path for Jest and pytest, a Go
+// package import path for go-junit-report, or a dotted Python module.
+func Match(testCases []database.TestCaseRef, outcome Outcome) (database.TestCaseRef, bool) {
+	var byName []database.TestCaseRef
+	for _, tc := range testCases {
+		if nameMatches(outcome.Name, tc.TestName) {
+			byName = append(byName, tc)
+		}
+	}
+	if len(byName) == 0 {
+		return database.TestCaseRef{}, false
+	}
+
+	hints := pathHints(outcome)
+	var byPath []database.TestCaseRef
+	for _, tc := range byName {
+		if pathMatches(tc.FilePath, hints) {
+			byPath = append(byPath, tc)
+		}
+	}
+	switch {
+	case len(byPath) == 1:
+		return byPath[0], true
+	case len(byPath) == 0 && len(byName) == 1:
+		return byName[0], true
+	}
+	return database.TestCaseRef{}, false
+}
+
+func nameMatches(reported, testName string) bool {
+	if reported == testName {
+		return true
+	}
+	if i := strings.Index(reported, "["); i > 0 && reported[:i] == testName {
+		return true
+	}
+	return strings.HasSuffix(reported, " "+testName) || strings.HasSuffix(testName, "::"+reported)
+}
+
+// pathHints lists where an outcome says its test lives, as slash-separated
+// paths
+func pathHints(outcome Outcome) []string {
+	var hints []string
+	for _, value := range []string{outcome.File, outcome.ClassName, outcome.Suite} {
+		value = strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(value), `\`, "/"), "./")
+		if value == "" {
+			continue
+		}
+		hints = append(hints, value)
+		if !strings.Contains(value, "/") && strings.Contains(value, ".") {
+			// Python module or class: tests.test_auth.TestLogin
+			hints = append(hints, strings.ReplaceAll(value, ".", "/"))
+		}
+	}
+	return hints
+}
+
+// pathMatches reports whether a test file is named by one of the hints: the
+// file itself, its module path without extension, or its directory as the
+// tail of a Go package path
+func pathMatches(filePath string, hints []string) bool {
+	filePath = strings.TrimPrefix(filePath, "./")
+	module := strings.TrimSuffix(filePath, path.Ext(filePath))
+	dir := path.Dir(filePath)
+	for _, hint := range hints {
+		if hasPathSuffix(hint, filePath) || hasPathSuffix(filePath, hint) || containsPath(hint, module) {
+			return true
+		}
+		if dir != "." && hasPathSuffix(hint, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathSuffix reports whether p ends with suffix at a path boundary
+func hasPathSuffix(p, suffix string) bool {
+	return p == suffix || strings.HasSuffix(p, "/"+suffix)
+}
+
+// containsPath reports whether sub appears in p between path boundaries, as
+// a module does in the path of one of its classes
+func containsPath(p, sub string) bool {
+	return strings.Contains("/"+p+"/", "/"+sub+"/")
+}
@@ -0,0 +1,65 @@
+package results
+
+import (
+	"testing"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []database.TestCaseRef{
+		{ID: "go-login", FilePath: "internal/auth/login_test.go", TestName: "TestLogin"},
+		{ID: "go-login-api", FilePath: "internal/api/login_test.go", TestName: "TestLogin"},
+		{ID: "go-logout", FilePath: "internal/auth/logout_test.go", TestName: "TestLogout"},
+		{ID: "jest", FilePath: "web/src/login.test.ts", TestName: "renders the form"},
+		{ID: "pytest", FilePath: "tests/test_auth.py", TestName: "test_login"},
+		{ID: "pytest-class", FilePath: "tests/test_users.py", TestName: "TestUsers::test_create"},
+	}
+	tests := []struct {
+		name    string
+		outcome Outcome
+		want    string // ID of the matched test case; "" for none
+	}{
+		{"unique name", Outcome{Name: "TestLogout"}, "go-logout"},
+		{"ambiguous name", Outcome{Name: "TestLogin"}, ""},
+		{"go package", Outcome{Name: "TestLogin", ClassName: "example.com/app/internal/api"}, "go-login-api"},
+		{"go package other", Outcome{Name: "TestLogin", ClassName: "example.com/app/internal/auth"}, "go-login"},
+		{"file", Outcome{Name: "TestLogin", File: "./internal/auth/login_test.go"}, "go-login"},
+		{"unknown package", Outcome{Name: "TestLogin", ClassName: "example.com/app/internal/billing"}, ""},
+		{"jest full name", Outcome{Name: "Login renders the form", File: "web/src/login.test.ts"}, "jest"},
+		{"pytest parameters", Outcome{Name: "test_login[admin]", ClassName: "tests.test_auth"}, "pytest"},
+		{"pytest class", Outcome{Name: "test_create", ClassName: "tests.test_users.TestUsers"}, "pytest-class"},
+		{"windows path", Outcome{Name: "TestLogin", File: `internal\api\login_test.go`}, "go-login-api"},
+		{"no such test", Outcome{Name: "TestSignup"}, ""},
+		{"prefix is not a match", Outcome{Name: "TestLog"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, ok := Match(testCases, tt.outcome)
+			if ok != (tt.want != "") || tc.ID != tt.want {
+				t.Errorf("Match(%+v) = %q, %v; want %q", tt.outcome, tc.ID, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathMatches(t *testing.T) {
+	tests := []struct {
+		file  string
+		hints []string
+		want  bool
+	}{
+		{"pkg/a_test.go", []string{"pkg/a_test.go"}, true},
+		{"pkg/a_test.go", []string{"/abs/repo/pkg/a_test.go"}, true},
+		{"pkg/a_test.go", []string{"example.com/m/pkg"}, true},
+		{"pkg/a_test.go", []string{"example.com/m/otherpkg"}, false},
+		{"a_test.go", []string{"example.com/m"}, false},
+		{"tests/test_a.py", []string{"tests/test_a/TestA"}, true},
+		{"tests/test_a.py", []string{"tests/test_ab"}, false},
+	}
+	for _, tt := range tests {
+		if got := pathMatches(tt.file, tt.hints); got != tt.want {
+			t.Errorf("pathMatches(%q, %q) = %v, want %v", tt.file, tt.hints, got, tt.want)
+		}
+	}
+}
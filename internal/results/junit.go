@@ -0,0 +1,151 @@
+// Package results reads test reports and records the outcome of each test
+// case against the test_cases of a project.
+package results
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// maxMessageLength caps stored failure messages; stack traces and captured
+// output can be very long
+const maxMessageLength = 4000
+
+// Outcome is the result of one test case in a test report
+type Outcome struct {
+	Name      string        `json:"name"`
+	ClassName string        `json:"classname,omitempty"`
+	File      string        `json:"file,omitempty"`
+	Suite     string        `json:"suite,omitempty"`
+	Status    string        `json:"status"` // database.TestPassed, TestFailed, TestError or TestSkipped
+	Duration  time.Duration `json:"duration"`
+	Message   string        `json:"message,omitempty"`
+}
+
+// result is the outcome as the result of a test case
+func (o Outcome) result(testCaseID string) database.TestCaseResult {
+	return database.TestCaseResult{
+		TestCaseID: testCaseID,
+		Status:     o.Status,
+		DurationMs: o.Duration.Milliseconds(),
+		Message:    o.Message,
+	}
+}
+
+// JUnit XML as written by go-junit-report, jest-junit, pytest --junitxml and
+// most other xUnit-style reporters. Suites may nest and the root may be either
+// <testsuites> or a single <testsuite>.
+type junitSuite struct {
+	Name      string       `xml:"name,attr"`
+	File      string       `xml:"file,attr"`
+	Suites    []junitSuite `xml:"testsuite"`
+	TestCases []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Time      string        `xml:"time,attr"`
+	Failures  []junitDetail `xml:"failure"`
+	Errors    []junitDetail `xml:"error"`
+	Skipped   *junitDetail  `xml:"skipped"`
+}
+
+type junitDetail struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnit reads the test cases of a JUnit XML report
+func ParseJUnit(r io.Reader) ([]Outcome, error) {
+	var root struct {
+		XMLName xml.Name
+		junitSuite
+	}
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit XML: %w", err)
+	}
+	switch root.XMLName.Local {
+	case "testsuites", "testsuite":
+	default:
+		return nil, fmt.Errorf("not a JUnit report: root element is <%s>, expected <testsuites> or <testsuite>", root.XMLName.Local)
+	}
+
+	var outcomes []Outcome
+	var walk func(suite junitSuite, file string)
+	walk = func(suite junitSuite, file string) {
+		if suite.File != "" {
+			file = suite.File
+		}
+		for _, tc := range suite.TestCases {
+			outcome := Outcome{
+				Name:      strings.TrimSpace(tc.Name),
+				ClassName: strings.TrimSpace(tc.ClassName),
+				File:      tc.File,
+				Suite:     suite.Name,
+				Status:    database.TestPassed,
+			}
+			if outcome.File == "" {
+				outcome.File = file
+			}
+			if seconds, err := strconv.ParseFloat(strings.TrimSpace(tc.Time), 64); err == nil {
+				outcome.Duration = time.Duration(seconds * float64(time.Second))
+			}
+			switch {
+			case len(tc.Errors) > 0:
+				outcome.Status = database.TestError
+				outcome.Message = detailMessage(tc.Errors)
+			case len(tc.Failures) > 0:
+				outcome.Status = database.TestFailed
+				outcome.Message = detailMessage(tc.Failures)
+			case tc.Skipped != nil:
+				outcome.Status = database.TestSkipped
+				outcome.Message = detailMessage([]junitDetail{*tc.Skipped})
+			}
+			outcomes = append(outcomes, outcome)
+		}
+		for _, child := range suite.Suites {
+			walk(child, file)
+		}
+	}
+	walk(root.junitSuite, "")
+	return outcomes, nil
+}
+
+// detailMessage joins the message attributes and bodies of failure, error or
+// skipped elements
+func detailMessage(details []junitDetail) string {
+	var parts []string
+	for _, detail := range details {
+		message := strings.TrimSpace(detail.Message)
+		text := strings.TrimSpace(detail.Text)
+		switch {
+		case message == "":
+			message = text
+		case text != "" && !strings.Contains(text, message):
+			message += "\n" + text
+		case text != "":
+			message = text
+		}
+		if message != "" {
+			parts = append(parts, message)
+		}
+	}
+	return truncateMessage(strings.Join(parts, "\n\n"))
+}
+
+// truncateMessage cuts a message down to maxMessageLength bytes
+func truncateMessage(message string) string {
+	if len(message) > maxMessageLength {
+		message = strings.ToValidUTF8(message[:maxMessageLength], "") + "\n… (truncated)"
+	}
+	return message
+}
@@ -0,0 +1,120 @@
+package results
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestParseJUnit(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		want    []Outcome
+		wantErr string
+	}{
+		{
+			name: "go-junit-report",
+			report: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="example.com/app/auth" tests="3">
+    <testcase classname="example.com/app/auth" name="TestLogin" time="0.120"></testcase>
+    <testcase classname="example.com/app/auth" name="TestLogout" time="0.010">
+      <failure message="Failed" type="">auth_test.go:20: want 200, got 500</failure>
+    </testcase>
+    <testcase classname="example.com/app/auth" name="TestSSO" time="0.000">
+      <skipped message="needs network"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+			want: []Outcome{
+				{Name: "TestLogin", ClassName: "example.com/app/auth", Suite: "example.com/app/auth", Status: database.TestPassed, Duration: 120 * time.Millisecond},
+				{Name: "TestLogout", ClassName: "example.com/app/auth", Suite: "example.com/app/auth", Status: database.TestFailed, Duration: 10 * time.Millisecond,
+					Message: "Failed\nauth_test.go:20: want 200, got 500"},
+				{Name: "TestSSO", ClassName: "example.com/app/auth", Suite: "example.com/app/auth", Status: database.TestSkipped, Message: "needs network"},
+			},
+		},
+		{
+			name: "single suite with file and nested suites",
+			report: `<testsuite name="root" file="web/login.test.ts">
+  <testcase name="Login renders" classname="Login renders"/>
+  <testsuite name="inner">
+    <testcase name="Login errors" file="web/errors.test.ts">
+      <error message="TypeError: x is undefined">TypeError: x is undefined
+    at errors.test.ts:3</error>
+    </testcase>
+  </testsuite>
+</testsuite>`,
+			want: []Outcome{
+				{Name: "Login renders", ClassName: "Login renders", File: "web/login.test.ts", Suite: "root", Status: database.TestPassed},
+				{Name: "Login errors", File: "web/errors.test.ts", Suite: "inner", Status: database.TestError,
+					Message: "TypeError: x is undefined\n    at errors.test.ts:3"},
+			},
+		},
+		{
+			name:   "pytest",
+			report: `<testsuites><testsuite name="pytest"><testcase classname="tests.test_auth.TestLogin" name="test_ok[1]" time="1.5"/></testsuite></testsuites>`,
+			want: []Outcome{
+				{Name: "test_ok[1]", ClassName: "tests.test_auth.TestLogin", Suite: "pytest", Status: database.TestPassed, Duration: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name:   "empty",
+			report: `<testsuites></testsuites>`,
+		},
+		{
+			name:    "not junit",
+			report:  `<html></html>`,
+			wantErr: "root element is <html>",
+		},
+		{
+			name:    "not xml",
+			report:  `PASS`,
+			wantErr: "failed to parse JUnit XML",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJUnit(strings.NewReader(tt.report))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseJUnit() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJUnit() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetailMessage(t *testing.T) {
+	tests := []struct {
+		details []junitDetail
+		want    string
+	}{
+		{[]junitDetail{{Message: "boom"}}, "boom"},
+		{[]junitDetail{{Text: " trace "}}, "trace"},
+		{[]junitDetail{{Message: "boom", Text: "boom at x.go:1"}}, "boom at x.go:1"},
+		{[]junitDetail{{Message: "boom", Text: "x.go:1"}}, "boom\nx.go:1"},
+		{[]junitDetail{{Message: "a"}, {Message: "b"}}, "a\n\nb"},
+		{[]junitDetail{{}}, ""},
+	}
+	for _, tt := range tests {
+		if got := detailMessage(tt.details); got != tt.want {
+			t.Errorf("detailMessage(%+v) = %q, want %q", tt.details, got, tt.want)
+		}
+	}
+
+	long := detailMessage([]junitDetail{{Message: strings.Repeat("é", maxMessageLength)}})
+	if !strings.HasSuffix(long, "… (truncated)") || len(long) > maxMessageLength+len("\n… (truncated)") {
+		t.Errorf("long message not truncated: %d bytes", len(long))
+	}
+}
@@ -0,0 +1,159 @@
+package results
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+var (
+	// go test -v: "--- FAIL: TestLogin (0.02s)", indented for subtests
+	goResultLine = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([0-9.]+)s\)`)
+	goRunLine    = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+	// pytest -v: "tests/test_auth.py::TestLogin::test_ok PASSED   [ 50%]"
+	pytestResultLine = regexp.MustCompile(`^(\S+?\.py)::(\S+)\s+(PASSED|FAILED|SKIPPED|ERROR|XFAIL|XPASS)\b`)
+	// jest --verbose: "    ✓ registers a user (5 ms)"
+	jestResultLine = regexp.MustCompile(`^\s+(✓|√|✕|×|○|✎)\s+(?:skipped |todo )?(.+?)(?:\s+\((\d+) ms\))?$`)
+)
+
+// ParseOutput reads the per-test results printed by go test -v, pytest -v and
+// jest --verbose. Failure and skip messages are only collected for Go tests;
+// the others print them in a separate section.
+func ParseOutput(output string) []Outcome {
+	var outcomes []Outcome
+	logs := make(map[string][]string) // Go test name -> output since its === RUN
+	current := ""
+	var reported *Outcome // failed or skipped Go test whose indented output may follow
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if m := goResultLine.FindStringSubmatch(line); m != nil {
+			outcome := Outcome{Name: m[2], Status: goStatus(m[1]), Duration: seconds(m[3])}
+			if outcome.Status != database.TestPassed {
+				outcome.Message = strings.Join(logs[outcome.Name], "\n")
+			}
+			delete(logs, outcome.Name)
+			outcomes = append(outcomes, outcome)
+			reported = nil
+			if outcome.Status != database.TestPassed {
+				reported = &outcomes[len(outcomes)-1]
+			}
+			continue
+		}
+		if m := goRunLine.FindStringSubmatch(line); m != nil {
+			current, reported = m[2], nil
+			continue
+		}
+		if m := pytestResultLine.FindStringSubmatch(line); m != nil {
+			parts := strings.Split(m[2], "::")
+			outcomes = append(outcomes, Outcome{
+				Name:      parts[len(parts)-1],
+				ClassName: strings.Join(parts[:len(parts)-1], "::"),
+				File:      m[1],
+				Status:    pytestStatus(m[3]),
+			})
+			reported = nil
+			continue
+		}
+		if m := jestResultLine.FindStringSubmatch(line); m != nil {
+			outcome := Outcome{Name: m[2], Status: jestStatus(m[1])}
+			if m[3] != "" {
+				ms, _ := strconv.Atoi(m[3])
+				outcome.Duration = time.Duration(ms) * time.Millisecond
+			}
+			outcomes = append(outcomes, outcome)
+			reported = nil
+			continue
+		}
+
+		switch {
+		case reported != nil && strings.HasPrefix(line, "    "):
+			reported.Message = strings.TrimSpace(reported.Message + "\n" + strings.TrimSpace(line))
+		case current != "" && strings.TrimSpace(line) != "":
+			logs[current] = append(logs[current], strings.TrimSpace(line))
+		}
+	}
+
+	for i := range outcomes {
+		outcomes[i].Message = truncateMessage(outcomes[i].Message)
+	}
+	return outcomes
+}
+
+// Attribute turns the outcomes a test command printed into results for the
+// test cases it ran. When the command failed without reporting any test,
+// e.g. because the tests did not compile, every test case is recorded as an
+// error with the failure message; otherwise test cases the output does not
+// mention are left out.
+func Attribute(testCases []database.TestCaseRef, outcomes []Outcome, failure string) []database.TestCaseResult {
+	var results []database.TestCaseResult
+	recorded := make(map[string]bool)
+	for _, outcome := range outcomes {
+		tc, ok := Match(testCases, outcome)
+		if !ok || recorded[tc.ID] {
+			continue
+		}
+		recorded[tc.ID] = true
+		results = append(results, outcome.result(tc.ID))
+	}
+	if failure == "" || len(outcomes) > 0 {
+		return results
+	}
+	for _, tc := range testCases {
+		if !recorded[tc.ID] {
+			results = append(results, database.TestCaseResult{
+				TestCaseID: tc.ID,
+				Status:     database.TestError,
+				Message:    failure,
+			})
+		}
+	}
+	return results
+}
+
+func goStatus(status string) string {
+	switch status {
+	case "FAIL":
+		return database.TestFailed
+	case "SKIP":
+		return database.TestSkipped
+	}
+	return database.TestPassed
+}
+
+func pytestStatus(status string) string {
+	switch status {
+	case "FAILED", "XPASS":
+		return database.TestFailed
+	case "ERROR":
+		return database.TestError
+	case "SKIPPED", "XFAIL":
+		return database.TestSkipped
+	}
+	return database.TestPassed
+}
+
+func jestStatus(symbol string) string {
+	switch symbol {
+	case "✕", "×":
+		return database.TestFailed
+	case "○", "✎":
+		return database.TestSkipped
+	}
+	return database.TestPassed
+}
+
+func seconds(value string) time.Duration {
+	s, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,126 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Outcome
+	}{
+		{
+			name: "go test -v",
+			output: `=== RUN   TestLogin
+--- PASS: TestLogin (0.02s)
+=== RUN   TestLogout
+    auth_test.go:20: want 200, got 500
+--- FAIL: TestLogout (0.10s)
+=== RUN   TestTable
+=== RUN   TestTable/empty_input
+--- FAIL: TestTable (0.00s)
+    --- FAIL: TestTable/empty_input (0.00s)
+        table_test.go:9: boom
+=== RUN   TestSSO
+    sso_test.go:5: needs network
+--- SKIP: TestSSO (0.00s)
+FAIL
+`,
+			want: []Outcome{
+				{Name: "TestLogin", Status: database.TestPassed, Duration: 20 * time.Millisecond},
+				{Name: "TestLogout", Status: database.TestFailed, Duration: 100 * time.Millisecond, Message: "auth_test.go:20: want 200, got 500"},
+				{Name: "TestTable", Status: database.TestFailed},
+				{Name: "TestTable/empty_input", Status: database.TestFailed, Message: "table_test.go:9: boom"},
+				{Name: "TestSSO", Status: database.TestSkipped, Message: "sso_test.go:5: needs network"},
+			},
+		},
+		{
+			name: "pytest -v",
+			output: `tests/test_auth.py::test_login PASSED                     [ 25%]
+tests/test_auth.py::TestLogout::test_expired FAILED       [ 50%]
+tests/test_auth.py::test_sso SKIPPED (needs network)     [ 75%]
+tests/test_auth.py::test_broken ERROR                    [100%]
+tests/test_auth.py::test_known_bug XFAIL                 [100%]
+`,
+			want: []Outcome{
+				{Name: "test_login", File: "tests/test_auth.py", Status: database.TestPassed},
+				{Name: "test_expired", ClassName: "TestLogout", File: "tests/test_auth.py", Status: database.TestFailed},
+				{Name: "test_sso", File: "tests/test_auth.py", Status: database.TestSkipped},
+				{Name: "test_broken", File: "tests/test_auth.py", Status: database.TestError},
+				{Name: "test_known_bug", File: "tests/test_auth.py", Status: database.TestSkipped},
+			},
+		},
+		{
+			name: "jest --verbose",
+			output: ` PASS  src/login.test.ts
+  Login
+    ✓ renders the form (5 ms)
+    ✕ rejects bad passwords (12 ms)
+    ○ skipped remembers the user
+    √ logs out
+`,
+			want: []Outcome{
+				{Name: "renders the form", Status: database.TestPassed, Duration: 5 * time.Millisecond},
+				{Name: "rejects bad passwords", Status: database.TestFailed, Duration: 12 * time.Millisecond},
+				{Name: "remembers the user", Status: database.TestSkipped},
+				{Name: "logs out", Status: database.TestPassed},
+			},
+		},
+		{
+			name:   "no tests",
+			output: "go: build failed\nFAIL\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseOutput(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOutput() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	testCases := []database.TestCaseRef{
+		{ID: "a", FilePath: "a_test.go", TestName: "TestA"},
+		{ID: "b", FilePath: "a_test.go", TestName: "TestB"},
+	}
+	tests := []struct {
+		name     string
+		outcomes []Outcome
+		failure  string
+		want     []database.TestCaseResult
+	}{
+		{
+			name:     "reported",
+			outcomes: []Outcome{{Name: "TestA", Status: database.TestPassed, Duration: time.Second}, {Name: "TestA", Status: database.TestFailed}, {Name: "TestC", Status: database.TestFailed}},
+			want:     []database.TestCaseResult{{TestCaseID: "a", Status: database.TestPassed, DurationMs: 1000}},
+		},
+		{
+			name:    "failed without outcomes",
+			failure: "build failed",
+			want: []database.TestCaseResult{
+				{TestCaseID: "a", Status: database.TestError, Message: "build failed"},
+				{TestCaseID: "b", Status: database.TestError, Message: "build failed"},
+			},
+		},
+		{
+			name:     "failed with outcomes",
+			outcomes: []Outcome{{Name: "TestB", Status: database.TestFailed, Message: "boom"}},
+			failure:  "exit status 1",
+			want:     []database.TestCaseResult{{TestCaseID: "b", Status: database.TestFailed, Message: "boom"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Attribute(testCases, tt.outcomes, tt.failure); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Attribute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package results
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// TAP test points: "ok 1 - registers a user", "not ok 2 login # SKIP no db",
+// indented for subtests
+var tapTestLine = regexp.MustCompile(`^(\s*)(ok|not ok)\b\s*(?:\d+\b)?\s*(?:-\s*)?(.*?)(?:\s+#\s*(?i:(skip|todo))\S*\b\s*(.*))?$`)
+
+// ParseTAP reads the test points of Test Anything Protocol output, as written
+// by node --test, mocha --reporter tap, prove and other TAP producers.
+// Skipped and TODO tests are recorded as skipped. The diagnostics indented
+// below a failed test point, such as a YAML block, become its message.
+func ParseTAP(output string) []Outcome {
+	var outcomes []Outcome
+	var reported *Outcome // failed test whose diagnostics may follow
+	indent := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if m := tapTestLine.FindStringSubmatch(line); m != nil {
+			outcome := Outcome{Name: strings.TrimSpace(m[3]), Status: database.TestPassed}
+			switch {
+			case m[4] != "":
+				outcome.Status = database.TestSkipped
+				outcome.Message = strings.TrimSpace(m[5])
+			case m[2] == "not ok":
+				outcome.Status = database.TestFailed
+			}
+			reported = nil
+			if outcome.Name == "" {
+				continue
+			}
+			outcomes = append(outcomes, outcome)
+			if outcome.Status == database.TestFailed {
+				reported, indent = &outcomes[len(outcomes)-1], m[1]
+			}
+			continue
+		}
+
+		if reported == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || trimmed == "---" || trimmed == "...":
+		case strings.HasPrefix(line, indent+" "):
+			reported.Message = strings.TrimSpace(reported.Message + "\n" + trimmed)
+		default:
+			reported = nil
+		}
+	}
+
+	for i := range outcomes {
+		outcomes[i].Message = truncateMessage(outcomes[i].Message)
+	}
+	return outcomes
+}
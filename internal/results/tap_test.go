@@ -0,0 +1,85 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestParseTAP(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Outcome
+	}{
+		{
+			name: "node --test",
+			output: `TAP version 13
+# Subtest: registers a user
+ok 1 - registers a user
+  ---
+  duration_ms: 1.2
+  ...
+not ok 2 - rejects a duplicate email
+  ---
+  error: 'expected 409, got 201'
+  location: 'users.test.js:12:3'
+  ...
+ok 3 - sends a welcome mail # SKIP no smtp server
+ok 4 - deletes a user # TODO not implemented
+1..4
+`,
+			want: []Outcome{
+				{Name: "registers a user", Status: database.TestPassed},
+				{Name: "rejects a duplicate email", Status: database.TestFailed,
+					Message: "error: 'expected 409, got 201'\nlocation: 'users.test.js:12:3'"},
+				{Name: "sends a welcome mail", Status: database.TestSkipped, Message: "no smtp server"},
+				{Name: "deletes a user", Status: database.TestSkipped, Message: "not implemented"},
+			},
+		},
+		{
+			name: "subtests",
+			output: `    not ok 1 - inner fails
+      boom
+    1..1
+not ok 1 - outer
+ok 2 login works
+`,
+			want: []Outcome{
+				{Name: "inner fails", Status: database.TestFailed, Message: "boom"},
+				{Name: "outer", Status: database.TestFailed},
+				{Name: "login works", Status: database.TestPassed},
+			},
+		},
+		{
+			name:   "skip directive without a reason",
+			output: "ok 1 - flaky #skip\nnot ok 2 - later # Todo\n",
+			want: []Outcome{
+				{Name: "flaky", Status: database.TestSkipped},
+				{Name: "later", Status: database.TestSkipped},
+			},
+		},
+		{
+			name:   "unnamed test points",
+			output: "ok 1\nnot ok 2\n  diagnostics of an unnamed test\nok 3 - named\n",
+			want:   []Outcome{{Name: "named", Status: database.TestPassed}},
+		},
+		{
+			name:   "diagnostics end at a less indented line",
+			output: "not ok 1 - fails\n  first\n# comment\n  not a diagnostic\n",
+			want:   []Outcome{{Name: "fails", Status: database.TestFailed, Message: "first"}},
+		},
+		{
+			name:   "not tap",
+			output: "okay\nnothing to see\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTAP(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTAP() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
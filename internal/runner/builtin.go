@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/results"
+)
+
+// Builtin returns the built-in runners: go test for _test.go files, Jest for
+// .test/.spec JavaScript and TypeScript files and pytest for Python tests
+func Builtin() []TestRunner {
+	return []TestRunner{goRunner{}, jestRunner{}, pytestRunner{}}
+}
+
+// builtinRunner returns the built-in runner with the given name, or nil
+func builtinRunner(name string) TestRunner {
+	for _, runner := range Builtin() {
+		if runner.Name() == name {
+			return runner
+		}
+	}
+	return nil
+}
+
+// goRunner runs the package of a _test.go file with go test -json, selecting
+// the tests with -run
+type goRunner struct{}
+
+func (goRunner) Name() string { return "go" }
+
+func (goRunner) Matches(file string) bool {
+	return strings.HasSuffix(file, "_test.go")
+}
+
+func (goRunner) Command(spec Spec) (*Command, error) {
+	args := []string{"go", "test", "-json"}
+	if pattern := results.GoTestRunPattern(spec.Tests); pattern != "" {
+		args = append(args, "-run", pattern)
+	}
+	args = append(args, goPackage(spec.File))
+	return &Command{Args: args, Dir: baseDir(spec), Parser: ParserGoJSON}, nil
+}
+
+// jestRunner runs a test file with npx jest from the nearest directory with a
+// package.json, selecting the tests with -t
+type jestRunner struct{}
+
+func (jestRunner) Name() string { return "jest" }
+
+func (jestRunner) Matches(file string) bool {
+	for _, suffix := range []string{".test.js", ".spec.js", ".test.ts", ".spec.ts"} {
+		if strings.HasSuffix(file, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (jestRunner) Command(spec Spec) (*Command, error) {
+	dir := packageJSONDir(spec)
+	file := filepath.Join(baseDir(spec), spec.File)
+	if rel, err := filepath.Rel(dir, file); err == nil {
+		file = rel
+	}
+	args := []string{"npx", "jest", file, "--verbose"}
+	if pattern := results.JestNamePattern(spec.Tests); pattern != "" {
+		args = append(args, "-t", pattern)
+	}
+	return &Command{Args: args, Dir: dir, Parser: ParserOutput}, nil
+}
+
+// pytestRunner runs a test file with python -m pytest, selecting the tests
+// with -k
+type pytestRunner struct{}
+
+func (pytestRunner) Name() string { return "pytest" }
+
+func (pytestRunner) Matches(file string) bool {
+	name := path.Base(filepath.ToSlash(file))
+	if matched, _ := path.Match("test_*.py", name); matched {
+		return true
+	}
+	return strings.HasSuffix(name, ".test.py") || strings.HasSuffix(name, "_test.py")
+}
+
+func (pytestRunner) Command(spec Spec) (*Command, error) {
+	args := []string{"python", "-m", "pytest", "-v"}
+	if expression := results.PytestKeywordExpression(spec.Tests); expression != "" {
+		args = append(args, "-k", expression)
+	}
+	args = append(args, spec.File)
+	return &Command{Args: args, Dir: baseDir(spec), Parser: ParserOutput}, nil
+}
+
+func baseDir(spec Spec) string {
+	if spec.BaseDir == "" {
+		return "."
+	}
+	return spec.BaseDir
+}
+
+// goPackage is the package pattern of a Go test file, e.g. ./internal/auth
+func goPackage(file string) string {
+	dir := filepath.ToSlash(filepath.Dir(file))
+	if dir == "." {
+		return dir
+	}
+	return "./" + dir
+}
+
+// packageJSONDir finds the directory of the package.json closest to the test
+// file, looking no higher than the project checkout
+func packageJSONDir(spec Spec) string {
+	root := filepath.Clean(baseDir(spec))
+	dir := filepath.Dir(filepath.Join(root, spec.File))
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return root
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,362 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/peshwar9/tracevibe/internal/results"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the runner configuration file looked for in a project checkout
+const ConfigFile = ".tracevibe.yaml"
+
+// Config is the test runner configuration of a project:
+//
+//	runners:
+//	  - name: vitest
+//	    match: ["web/**/*.test.ts"]
+//	    dir: web
+//	    command: npx vitest run {{.File}} --reporter=junit --outputFile=junit.xml {{with .JestNamePattern}}--testNamePattern={{.}}{{end}}
+//	    parser: junit
+//	    report: junit.xml
+//	    timeout: 5m
+//	  - name: go
+//	    env: {GOFLAGS: -tags=integration}
+//
+// Configured runners are tried in order before the built-in ones. A runner
+// without a command adjusts the built-in runner of the same name.
+type Config struct {
+	Runners []RunnerConfig `yaml:"runners" json:"runners"`
+}
+
+// RunnerConfig configures one runner. Command and Report are Go templates
+// executed with the test file and tests to run; see CommandData. Command is
+// split into arguments like a shell would, without expansions, and each
+// argument must be a complete template: write optional flags as one argument,
+// e.g. {{with .GoRunPattern}}-run={{.}}{{end}}. Arguments that a template
+// leaves empty are dropped.
+type RunnerConfig struct {
+	Name    string            `yaml:"name" json:"name"`
+	Match   []string          `yaml:"match,omitempty" json:"match,omitempty"` // globs of test files relative to the project; "**" matches any number of directories, and patterns without "/" match the file name
+	Command string            `yaml:"command,omitempty" json:"command,omitempty"`
+	Dir     string            `yaml:"dir,omitempty" json:"dir,omitempty"` // working directory relative to the project
+	Env     map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Timeout string            `yaml:"timeout,omitempty" json:"timeout,omitempty"` // e.g. 90s or 10m
+	Parser  string            `yaml:"parser,omitempty" json:"parser,omitempty"`   // output (default), go-json, junit or tap
+	Report  string            `yaml:"report,omitempty" json:"report,omitempty"`   // JUnit report the command writes, relative to dir
+}
+
+// CommandData is what Command and Report templates are executed with
+type CommandData struct {
+	File             string   // test file relative to the working directory
+	Path             string   // test file relative to the project
+	Package          string   // directory of File as a Go package pattern, e.g. ./internal/auth
+	Dir              string   // working directory
+	Tests            []string // names of the tests to run; empty for the whole file
+	GoRunPattern     string   // go test -run pattern selecting Tests
+	JestNamePattern  string   // jest and vitest -t pattern selecting Tests
+	PytestExpression string   // pytest -k expression selecting Tests
+}
+
+// ParseConfig reads a runner configuration in YAML or JSON and checks it
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse runner configuration: %w", err)
+	}
+	if _, err := config.Registry(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// LoadConfig reads the ConfigFile in a project checkout; it returns nil when
+// there is none
+func LoadConfig(dir string) (*Config, error) {
+	if dir == "" {
+		dir = "."
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+	}
+	return config, nil
+}
+
+// Registry returns the configured runners followed by the built-in ones; a
+// nil configuration gives the built-in runners alone
+func (c *Config) Registry() (*Registry, error) {
+	registry := NewRegistry()
+	if c != nil {
+		for i, rc := range c.Runners {
+			runner, err := newConfiguredRunner(rc)
+			if err != nil {
+				return nil, fmt.Errorf("runner %d (%s): %w", i+1, rc.Name, err)
+			}
+			registry.Register(runner)
+		}
+	}
+	for _, runner := range Builtin() {
+		registry.Register(runner)
+	}
+	return registry, nil
+}
+
+// configuredRunner is a runner from a project's configuration
+type configuredRunner struct {
+	config  RunnerConfig
+	builtin TestRunner // adjusted built-in runner when there is no command
+	args    []string   // unexecuted argument templates
+	command []*template.Template
+	report  *template.Template
+	env     []string
+	timeout time.Duration
+}
+
+func newConfiguredRunner(rc RunnerConfig) (*configuredRunner, error) {
+	if rc.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	r := &configuredRunner{config: rc}
+
+	if rc.Command == "" {
+		if r.builtin = builtinRunner(rc.Name); r.builtin == nil {
+			return nil, fmt.Errorf("command is required: '%s' is not a built-in runner (go, jest, pytest)", rc.Name)
+		}
+		if rc.Parser != "" || rc.Report != "" {
+			return nil, errors.New("parser and report need a command")
+		}
+	} else {
+		if len(rc.Match) == 0 {
+			return nil, errors.New("match is required")
+		}
+		args, err := splitCommand(rc.Command)
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range args {
+			tmpl, err := template.New(rc.Name).Funcs(templateFuncs).Parse(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid command argument %q (each argument must be a complete template): %w", arg, err)
+			}
+			r.args = append(r.args, arg)
+			r.command = append(r.command, tmpl)
+		}
+		if len(r.command) == 0 {
+			return nil, errors.New("command is empty")
+		}
+	}
+
+	for _, pattern := range rc.Match {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid match pattern '%s': %w", pattern, err)
+		}
+	}
+
+	switch rc.Parser {
+	case "", ParserOutput, ParserGoJSON, ParserTAP:
+	case ParserJUnit:
+		if rc.Report == "" {
+			return nil, errors.New("the junit parser needs the report the command writes")
+		}
+		tmpl, err := template.New(rc.Name).Funcs(templateFuncs).Parse(rc.Report)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report: %w", err)
+		}
+		r.report = tmpl
+	default:
+		return nil, fmt.Errorf("unknown parser '%s' (expected output, go-json, junit or tap)", rc.Parser)
+	}
+
+	if rc.Timeout != "" {
+		timeout, err := time.ParseDuration(rc.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout '%s'", rc.Timeout)
+		}
+		r.timeout = timeout
+	}
+
+	keys := make([]string, 0, len(rc.Env))
+	for key := range rc.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid environment variable name '%s'", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.env = append(r.env, key+"="+rc.Env[key])
+	}
+	return r, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+func (r *configuredRunner) Name() string { return r.config.Name }
+
+func (r *configuredRunner) Matches(file string) bool {
+	if len(r.config.Match) == 0 {
+		return r.builtin.Matches(file)
+	}
+	file = strings.TrimPrefix(filepath.ToSlash(file), "./")
+	for _, pattern := range r.config.Match {
+		if matchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *configuredRunner) Command(spec Spec) (*Command, error) {
+	if r.builtin != nil {
+		cmd, err := r.builtin.Command(spec)
+		if err != nil {
+			return nil, err
+		}
+		if r.config.Dir != "" {
+			cmd.Dir = filepath.Join(baseDir(spec), r.config.Dir)
+		}
+		cmd.Env = append(cmd.Env, r.env...)
+		cmd.Timeout = r.timeout
+		return cmd, nil
+	}
+
+	dir := filepath.Join(baseDir(spec), r.config.Dir)
+	file := filepath.Join(baseDir(spec), spec.File)
+	if rel, err := filepath.Rel(dir, file); err == nil {
+		file = rel
+	}
+	data := CommandData{
+		File:             filepath.ToSlash(file),
+		Path:             filepath.ToSlash(spec.File),
+		Package:          goPackage(file),
+		Dir:              dir,
+		Tests:            spec.Tests,
+		GoRunPattern:     results.GoTestRunPattern(spec.Tests),
+		JestNamePattern:  results.JestNamePattern(spec.Tests),
+		PytestExpression: results.PytestKeywordExpression(spec.Tests),
+	}
+
+	cmd := &Command{Dir: dir, Env: r.env, Timeout: r.timeout, Parser: r.config.Parser}
+	if cmd.Parser == "" {
+		cmd.Parser = ParserOutput
+	}
+	for i, tmpl := range r.command {
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, data); err != nil {
+			return nil, fmt.Errorf("runner %s: %w", r.config.Name, err)
+		}
+		if arg.Len() == 0 && strings.Contains(r.args[i], "{{") {
+			continue
+		}
+		cmd.Args = append(cmd.Args, arg.String())
+	}
+	if r.report != nil {
+		var report strings.Builder
+		if err := r.report.Execute(&report, data); err != nil {
+			return nil, fmt.Errorf("runner %s: %w", r.config.Name, err)
+		}
+		cmd.Report = filepath.Join(dir, report.String())
+	}
+	return cmd, nil
+}
+
+// matchGlob reports whether a slash-separated file path matches a pattern in
+// which "**" matches any number of directories; patterns without "/" match
+// the file name alone
+func matchGlob(pattern, file string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(file))
+		return matched
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(pattern[1:], file[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(file) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], file[0]); !matched {
+			return false
+		}
+		pattern, file = pattern[1:], file[1:]
+	}
+	return len(file) == 0
+}
+
+// splitCommand splits a command template into arguments at unquoted white
+// space. Single and double quotes group words without any escapes, and
+// template actions ({{...}}) are kept whole.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case strings.HasPrefix(command[i:], "{{"):
+			end := strings.Index(command[i:], "}}")
+			if end < 0 {
+				return nil, errors.New("invalid command: unterminated {{")
+			}
+			arg.WriteString(command[i : i+end+2])
+			inArg = true
+			i += end + 1
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("invalid command: unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,236 @@
+package runner
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr string
+	}{
+		{command: "", want: nil},
+		{command: "go test ./...", want: []string{"go", "test", "./..."}},
+		{command: "  npx\tvitest\n run  ", want: []string{"npx", "vitest", "run"}},
+		{command: `pytest -k 'a or b' "x y"z`, want: []string{"pytest", "-k", "a or b", "x yz"}},
+		{command: `echo "it's" '"quoted"' ''`, want: []string{"echo", "it's", `"quoted"`, ""}},
+		{command: `echo \n`, want: []string{"echo", `\n`}},
+		{
+			command: "go test {{with .GoRunPattern}}-run={{.}}{{end}} {{.Package}}",
+			want:    []string{"go", "test", "{{with .GoRunPattern}}-run={{.}}{{end}}", "{{.Package}}"},
+		},
+		{command: `x {{join .Tests " "}}`, want: []string{"x", `{{join .Tests " "}}`}},
+		{command: "x '{{.File}}'", want: []string{"x", "{{.File}}"}},
+		{command: "x {{.File", wantErr: "unterminated {{"},
+		{command: `x "y`, wantErr: "unterminated quote"},
+		{command: "x 'y", wantErr: "unterminated quote"},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("splitCommand(%q) error = %v, want %q", tt.command, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitCommand(%q): %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.test.ts", "login.test.ts", true},
+		{"*.test.ts", "web/src/login.test.ts", true},
+		{"*.test.ts", "web/src/login.ts", false},
+		{"web/*.test.ts", "web/login.test.ts", true},
+		{"web/*.test.ts", "web/src/login.test.ts", false},
+		{"web/**/*.test.ts", "web/login.test.ts", true},
+		{"web/**/*.test.ts", "web/src/auth/login.test.ts", true},
+		{"web/**/*.test.ts", "api/login.test.ts", false},
+		{"**/test_*.py", "test_a.py", true},
+		{"**/test_*.py", "tests/unit/test_a.py", true},
+		{"tests/**", "tests/unit/test_a.py", true},
+		{"tests/**", "src/a.py", false},
+		{"a/**/b/*.go", "a/x/y/b/c.go", true},
+		{"a/**/b/*.go", "a/x/y/c.go", false},
+		{"src/[ab].go", "src/a.go", true},
+		{"src/?.go", "src/ab.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "empty", config: ""},
+		{name: "json", config: `{"runners": [{"name": "go", "timeout": "10m"}]}`},
+		{
+			name: "full",
+			config: `runners:
+  - name: vitest
+    match: ["web/**/*.test.ts"]
+    dir: web
+    command: npx vitest run {{.File}} {{with .JestNamePattern}}--testNamePattern={{.}}{{end}}
+    parser: junit
+    report: junit.xml
+    timeout: 5m
+  - name: go
+    env: {GOFLAGS: -tags=integration}
+`,
+		},
+		{name: "unknown field", config: "runners:\n  - name: go\n    cmd: go test\n", wantErr: "field cmd not found"},
+		{name: "no name", config: "runners:\n  - command: make test\n    match: ['*']\n", wantErr: "name is required"},
+		{name: "not built in", config: "runners:\n  - name: cargo\n", wantErr: "'cargo' is not a built-in runner"},
+		{name: "parser without command", config: "runners:\n  - name: go\n    parser: junit\n", wantErr: "parser and report need a command"},
+		{name: "no match", config: "runners:\n  - name: make\n    command: make test\n", wantErr: "match is required"},
+		{name: "empty command", config: "runners:\n  - name: make\n    command: ' '\n    match: ['*']\n", wantErr: "command is empty"},
+		{name: "unterminated quote", config: "runners:\n  - name: make\n    command: make 'test\n    match: ['*']\n", wantErr: "unterminated quote"},
+		{
+			name:    "split template",
+			config:  "runners:\n  - name: go\n    command: go test {{if .Tests}}-run {{.GoRunPattern}}{{end}}\n    match: ['*_test.go']\n",
+			wantErr: "each argument must be a complete template",
+		},
+		{name: "bad match", config: "runners:\n  - name: go\n    match: ['[']\n", wantErr: "invalid match pattern '['"},
+		{name: "junit without report", config: "runners:\n  - name: make\n    command: make test\n    match: ['*']\n    parser: junit\n", wantErr: "needs the report"},
+		{name: "unknown parser", config: "runners:\n  - name: make\n    command: make test\n    match: ['*']\n    parser: xml\n", wantErr: "unknown parser 'xml'"},
+		{name: "bad timeout", config: "runners:\n  - name: go\n    timeout: soon\n", wantErr: "invalid timeout 'soon'"},
+		{name: "negative timeout", config: "runners:\n  - name: go\n    timeout: -1s\n", wantErr: "invalid timeout"},
+		{name: "bad env", config: "runners:\n  - name: go\n    env: {'A=B': x}\n", wantErr: "invalid environment variable name 'A=B'"},
+		{name: "runner number", config: "runners:\n  - name: go\n  - name: jest\n    timeout: x\n", wantErr: "runner 2 (jest)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.config))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfiguredRunner(t *testing.T) {
+	config, err := ParseConfig([]byte(`runners:
+  - name: vitest
+    match: ["web/**/*.test.ts"]
+    dir: web
+    command: npx vitest run {{.File}} --reporter=junit {{with .JestNamePattern}}--testNamePattern={{.}}{{end}}
+    parser: junit
+    report: "{{.Package}}/junit.xml"
+    timeout: 5m
+  - name: go
+    dir: svc
+    env: {GOFLAGS: -tags=integration, CGO_ENABLED: "0"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := config.Registry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		spec   Spec
+		runner string
+		want   *Command
+	}{
+		{
+			name:   "command",
+			spec:   Spec{File: "web/src/login.test.ts", BaseDir: "/repo", Tests: []string{"renders"}},
+			runner: "vitest",
+			want: &Command{
+				Args:    []string{"npx", "vitest", "run", "src/login.test.ts", "--reporter=junit", "--testNamePattern=(^| )(renders)$"},
+				Dir:     "/repo/web",
+				Timeout: 5 * time.Minute,
+				Parser:  ParserJUnit,
+				Report:  filepath.Join("/repo/web", "src/junit.xml"),
+			},
+		},
+		{
+			name:   "empty template argument",
+			spec:   Spec{File: "web/login.test.ts", BaseDir: "/repo"},
+			runner: "vitest",
+			want: &Command{
+				Args:    []string{"npx", "vitest", "run", "login.test.ts", "--reporter=junit"},
+				Dir:     "/repo/web",
+				Timeout: 5 * time.Minute,
+				Parser:  ParserJUnit,
+				Report:  filepath.Join("/repo/web", "junit.xml"),
+			},
+		},
+		{
+			name:   "built-in adjustment",
+			spec:   Spec{File: "internal/auth/login_test.go", BaseDir: "/repo", Tests: []string{"TestLogin"}},
+			runner: "go",
+			want: &Command{
+				Args:   []string{"go", "test", "-json", "-run", "^(TestLogin)$", "./internal/auth"},
+				Dir:    "/repo/svc",
+				Env:    []string{"CGO_ENABLED=0", "GOFLAGS=-tags=integration"},
+				Parser: ParserGoJSON,
+			},
+		},
+		{
+			name:   "built-in fallback",
+			spec:   Spec{File: "api/login.test.ts", BaseDir: "/repo"},
+			runner: "jest",
+		},
+		{
+			name:   "no runner",
+			spec:   Spec{File: "README.md"},
+			runner: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := registry.Find(tt.spec.File)
+			if runner == nil {
+				if tt.runner != "" {
+					t.Fatalf("no runner for %s, want %s", tt.spec.File, tt.runner)
+				}
+				return
+			}
+			if runner.Name() != tt.runner {
+				t.Fatalf("runner for %s = %s, want %s", tt.spec.File, runner.Name(), tt.runner)
+			}
+			if tt.want == nil {
+				return
+			}
+			got, err := runner.Command(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Command() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
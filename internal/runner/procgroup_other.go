@@ -0,0 +1,9 @@
+//go:build !unix
+
+package runner
+
+import "os/exec"
+
+// setProcessGroup leaves the command as it is; cancelling it kills only the
+// command itself
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/results"
+)
+
+// Result is what a test command did
+type Result struct {
+	Passed   bool   // the command exited successfully
+	Output   string // what it printed; go test -json events are shown as go test -v output
+	Outcomes []results.Outcome
+}
+
+// String describes the command for output, e.g. "Command: go test ./x"
+func (c *Command) String() string {
+	return fmt.Sprintf("Command: %s\nWorking Dir: %s\n", strings.Join(c.Args, " "), c.Dir)
+}
+
+// Run runs the command in the sandbox and reads the outcome of each test it
+// reports with its parser, writing the output to out as it is produced. A
+// command that fails after its parser found failed tests is a test failure;
+// any other failure, such as a build error, a command that reports no tests or
+// running out of time, is returned as an error together with the result so
+// far.
+func (c *Command) Run(ctx context.Context, sandbox *Sandbox, out io.Writer) (*Result, error) {
+	if c.Report != "" {
+		// A report left over from an earlier run must not pass for this one
+		os.Remove(c.Report)
+	}
+
+	// Go's event stream becomes the usual -v output again for display
+	var output bytes.Buffer
+	var text *results.GoTestTextWriter
+	live := out
+	if c.Parser == ParserGoJSON {
+		text = results.NewGoTestTextWriter(out)
+		live = text
+	}
+	err := sandbox.Exec(ctx, c, io.MultiWriter(&output, live))
+	if text != nil {
+		text.Flush()
+	}
+
+	result := &Result{Passed: err == nil}
+	var parseErr error
+	result.Outcomes, result.Output, parseErr = c.parse(output.Bytes())
+
+	if err == nil {
+		return result, parseErr
+	}
+	if errors.Is(err, ErrTimeout) {
+		return result, err
+	}
+	exitCode := -1
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		exitCode = exitError.ExitCode()
+	}
+	if exitCode > 0 && failedTests(result.Outcomes) {
+		// The tests ran but failed
+		return result, nil
+	}
+	return result, fmt.Errorf("execution failed (exit code %d): %v", exitCode, err)
+}
+
+// parse reads the outcomes from the output or report of the command, and
+// returns the output as it is shown
+func (c *Command) parse(output []byte) ([]results.Outcome, string, error) {
+	switch c.Parser {
+	case ParserGoJSON:
+		return results.ParseGoTestJSON(bytes.NewReader(output))
+	case ParserTAP:
+		return results.ParseTAP(string(output)), string(output), nil
+	case ParserJUnit:
+		report, err := os.Open(c.Report)
+		if err != nil {
+			return nil, string(output), fmt.Errorf("failed to read test report: %w", err)
+		}
+		defer report.Close()
+		outcomes, err := results.ParseJUnit(report)
+		return outcomes, string(output), err
+	}
+	return results.ParseOutput(string(output)), string(output), nil
+}
+
+func failedTests(outcomes []results.Outcome) bool {
+	for _, outcome := range outcomes {
+		if outcome.Status == database.TestFailed || outcome.Status == database.TestError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Package runner builds and runs the command that executes the tests of a
+// test file. Built-in runners cover go test, Jest and pytest; projects add
+// their own in .tracevibe.yaml or in the database, which are tried first.
+package runner
+
+import (
+	"time"
+)
+
+// Result parsers: how the outcome of each test is read from a test command
+const (
+	ParserOutput = "output"  // go test -v, jest --verbose and pytest -v output
+	ParserGoJSON = "go-json" // go test -json event stream
+	ParserJUnit  = "junit"   // JUnit XML report written to Command.Report
+	ParserTAP    = "tap"     // Test Anything Protocol output
+)
+
+// Spec is what a runner is asked to run: the tests of one test file
+type Spec struct {
+	BaseDir string   // project checkout; "" for the current directory
+	File    string   // test file, relative to BaseDir
+	Tests   []string // names of the tests to run; empty runs the whole file
+}
+
+// Command is a test command ready to run
+type Command struct {
+	Args    []string
+	Dir     string        // working directory
+	Env     []string      // KEY=value pairs added to the inherited environment
+	Timeout time.Duration // 0 for no timeout
+	Parser  string        // one of the Parser constants
+	Report  string        // JUnit report the command writes, for ParserJUnit
+}
+
+// TestRunner builds the command for the test files it handles
+type TestRunner interface {
+	// Name identifies the runner in configuration and output
+	Name() string
+	// Matches reports whether the runner handles a test file, given
+	// relative to the project checkout
+	Matches(file string) bool
+	// Command returns the command that runs spec
+	Command(spec Spec) (*Command, error)
+}
+
+// Registry picks the runner of a test file: the first registered runner that
+// matches it
+type Registry struct {
+	runners []TestRunner
+}
+
+// NewRegistry returns a registry of the given runners, in order
+func NewRegistry(runners ...TestRunner) *Registry {
+	return &Registry{runners: runners}
+}
+
+// Default returns a registry of the built-in runners
+func Default() *Registry {
+	return NewRegistry(Builtin()...)
+}
+
+// Register adds a runner, tried after those already registered
+func (r *Registry) Register(runner TestRunner) {
+	r.runners = append(r.runners, runner)
+}
+
+// Runners returns the registered runners in the order they are tried
+func (r *Registry) Runners() []TestRunner {
+	return append([]TestRunner(nil), r.runners...)
+}
+
+// Find returns the runner of a test file, or nil when none handles it
+func (r *Registry) Find(file string) TestRunner {
+	for _, runner := range r.runners {
+		if runner.Matches(file) {
+			return runner
+		}
+	}
+	return nil
+}
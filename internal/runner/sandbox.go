@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is returned for commands stopped because their time ran out
+var ErrTimeout = errors.New("timed out")
+
+// DefaultEnv lists the environment variables test commands get by default:
+// what shells, toolchains and package managers need, and nothing like
+// credentials or the server's own settings
+var DefaultEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TZ", "TMPDIR", "TEMP", "TMP",
+	"LANG", "LC_*", "CI", "SYSTEMROOT", "COMSPEC", "PATHEXT", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+	"GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOTOOLCHAIN", "GOPROXY", "GOPRIVATE",
+	"GONOPROXY", "GONOSUMDB", "GOSUMDB", "GOOS", "GOARCH", "CGO_*",
+	"NODE_PATH", "NODE_OPTIONS", "NVM_DIR", "npm_config_*",
+	"PYTHONPATH", "PYTHONHOME", "VIRTUAL_ENV", "CONDA_PREFIX",
+	"JAVA_HOME", "MAVEN_HOME", "M2_HOME", "GRADLE_USER_HOME",
+	"CARGO_HOME", "RUSTUP_HOME",
+}
+
+// DefaultOutputLimit is how much output of a command is kept when the sandbox
+// sets no limit of its own
+const DefaultOutputLimit int64 = 16 << 20
+
+// killGrace is how long a killed command's output pipes may stay open, e.g.
+// held by a child that escaped the process group
+const killGrace = 5 * time.Second
+
+// Sandbox limits what test commands can do. The zero value passes on no
+// environment and sets no time limit.
+type Sandbox struct {
+	Timeout     time.Duration // for commands without a timeout of their own; 0 for none
+	Env         []string      // names of the variables passed on; "PREFIX*" matches a prefix and "*" everything
+	OutputLimit int64         // bytes of output kept; 0 for DefaultOutputLimit
+	TempHome    bool          // run with a fresh HOME, GOCACHE and TMPDIR, removed afterwards
+}
+
+// Exec runs a command in the sandbox and writes its output to out as it is
+// produced. The command runs in its own process group, which is killed when
+// ctx is done or the timeout expires; the latter returns ErrTimeout. Output
+// past the limit is dropped. A nil sandbox passes on the whole environment and
+// only limits the output.
+func (s *Sandbox) Exec(ctx context.Context, c *Command, out io.Writer) error {
+	if s == nil {
+		s = &Sandbox{Env: []string{"*"}}
+	}
+	if len(c.Args) == 0 {
+		return errors.New("empty command")
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = s.Timeout
+	}
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	env := s.environ(os.Environ())
+	if s.TempHome {
+		home, err := os.MkdirTemp("", "tracevibe-test-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary home: %w", err)
+		}
+		defer os.RemoveAll(home)
+		tmp := filepath.Join(home, "tmp")
+		if err := os.Mkdir(tmp, 0o700); err != nil {
+			return fmt.Errorf("failed to create temporary home: %w", err)
+		}
+		env = append(env, "HOME="+home, "USERPROFILE="+home, "TMPDIR="+tmp,
+			"GOCACHE="+filepath.Join(home, "gocache"), "XDG_CACHE_HOME="+filepath.Join(home, "cache"))
+		if modCache := goModCache(); modCache != "" {
+			// Downloaded modules are read-only and would outlive the home
+			// anyway; keep sharing them
+			env = append(env, "GOMODCACHE="+modCache)
+		}
+	}
+	env = append(env, c.Env...)
+
+	cmd := exec.CommandContext(runCtx, c.Args[0], c.Args[1:]...)
+	cmd.Dir = c.Dir
+	cmd.Env = env
+	cmd.WaitDelay = killGrace
+	setProcessGroup(cmd)
+
+	limit := s.OutputLimit
+	if limit <= 0 {
+		limit = DefaultOutputLimit
+	}
+	out = &limitedWriter{w: out, remaining: limit, limit: limit}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err := cmd.Run()
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+	return err
+}
+
+// environ keeps the variables of env the sandbox passes on
+func (s *Sandbox) environ(env []string) []string {
+	var kept []string
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, pattern := range s.Env {
+			if pattern == name || strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+				kept = append(kept, entry)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// goModCache is where the go command keeps downloaded modules for the
+// server's own environment
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	if len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// limitedWriter passes on the first limit bytes written to it and drops the
+// rest, noting that it did
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+	limit     int64
+	truncated bool
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if l.truncated {
+		return n, nil
+	}
+	if int64(n) > l.remaining {
+		p, l.truncated = p[:l.remaining], true
+	}
+	l.remaining -= int64(len(p))
+	if _, err := l.w.Write(p); err != nil {
+		return 0, err
+	}
+	if l.truncated {
+		fmt.Fprintf(l.w, "\n… output truncated after %d bytes\n", l.limit)
+	}
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxEnviron(t *testing.T) {
+	env := []string{"PATH=/bin", "HOME=/home/me", "LC_ALL=C", "SECRET_TOKEN=x", "GOFLAGS=-v", "LANGUAGE=en"}
+	tests := []struct {
+		allow []string
+		want  []string
+	}{
+		{nil, nil},
+		{[]string{"*"}, env},
+		{[]string{"PATH", "LC_*"}, []string{"PATH=/bin", "LC_ALL=C"}},
+		{[]string{"LANG"}, nil},
+		{DefaultEnv, []string{"PATH=/bin", "HOME=/home/me", "LC_ALL=C", "GOFLAGS=-v"}},
+	}
+	for _, tt := range tests {
+		s := &Sandbox{Env: tt.allow}
+		if got := s.environ(env); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("environ with %q = %q, want %q", tt.allow, got, tt.want)
+		}
+	}
+}
+
+func TestLimitedWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int64
+		writes []string
+		want   string
+	}{
+		{"under", 10, []string{"abc", "def"}, "abcdef"},
+		{"exactly", 6, []string{"abc", "def"}, "abcdef"},
+		{"over", 4, []string{"abc", "def"}, "abcd\n… output truncated after 4 bytes\n"},
+		{"after truncation", 2, []string{"abc", "def"}, "ab\n… output truncated after 2 bytes\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &limitedWriter{w: &buf, remaining: tt.limit, limit: tt.limit}
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	tap := "ok 1 - adds\nnot ok 2 - subtracts\n"
+	tests := []struct {
+		name       string
+		script     string
+		parser     string
+		wantPassed bool
+		wantErr    string
+	}{
+		{"passed", "echo 'ok 1 - adds'", ParserTAP, true, ""},
+		{"failed tests", "printf '" + tap + "'; exit 1", ParserTAP, false, ""},
+		{"failure without tests", "echo 'build failed: FAIL'; exit 1", ParserTAP, false, "execution failed (exit code 1)"},
+		{"failure with passing tests", "echo 'ok 1 - adds'; exit 1", ParserTAP, false, "execution failed (exit code 1)"},
+		{"crash", "echo 'FAIL'; exit 2", ParserOutput, false, "execution failed (exit code 2)"},
+		{"timeout", "sleep 5", ParserOutput, false, "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Command{Args: []string{"sh", "-c", tt.script}, Parser: tt.parser, Timeout: 500 * time.Millisecond}
+			var out bytes.Buffer
+			result, err := c.Run(context.Background(), &Sandbox{Env: []string{"PATH"}}, &out)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if result.Passed != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v", result.Passed, tt.wantPassed)
+			}
+			if tt.name == "timeout" && !errors.Is(err, ErrTimeout) {
+				t.Errorf("error %v is not ErrTimeout", err)
+			}
+		})
+	}
+}
+
+func TestSandboxDefaultOutputLimit(t *testing.T) {
+	if _, err := exec.LookPath("head"); err != nil {
+		t.Skip("no head")
+	}
+	c := &Command{Args: []string{"head", "-c", "17000000", "/dev/zero"}}
+	var out bytes.Buffer
+	if err := (&Sandbox{Env: []string{"PATH"}}).Exec(context.Background(), c, &out); err != nil {
+		t.Fatal(err)
+	}
+	if int64(out.Len()) > DefaultOutputLimit+100 {
+		t.Errorf("kept %d bytes, want at most %d", out.Len(), DefaultOutputLimit)
+	}
+}
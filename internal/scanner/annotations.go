@@ -0,0 +1,391 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// requirementKey is the shape of requirement keys: dash-separated segments
+// starting with a letter, at least one of them numeric, e.g. "SCOPE-1-US-2",
+// "TS-001" or "PROJ-42"
+const requirementKey = `[A-Za-z][A-Za-z0-9_]*(?:[-.][A-Za-z0-9_]+)*-[0-9][A-Za-z0-9_]*(?:[-.][A-Za-z0-9_]+)*`
+
+// annotationPattern matches RTM references such as "RTM: [SCOPE-1-US-2-TS-1]",
+// "RTM: SCOPE-1" or "RTM: [TS-001, TS-002]"
+var annotationPattern = regexp.MustCompile(`RTM:\s*\[?\s*(` + requirementKey + `(?:\s*,\s*` + requirementKey + `)*)\b\s*\]?`)
+
+// Annotation is a single requirement reference found in a source file
+type Annotation struct {
+	RequirementKey string `json:"requirement_key"`
+	FilePath       string `json:"file_path"`
+	Line           int    `json:"line"`
+	Function       string `json:"function,omitempty"`
+	StartLine      int    `json:"start_line,omitempty"`
+	EndLine        int    `json:"end_line,omitempty"`
+	Layer          string `json:"layer"`
+}
+
+// LineRange returns the function span as "start-end", or the annotation line if
+// the annotation is not attached to a function
+func (a Annotation) LineRange() string {
+	if a.StartLine > 0 && a.EndLine > 0 {
+		return fmt.Sprintf("%d-%d", a.StartLine, a.EndLine)
+	}
+	return fmt.Sprintf("%d", a.Line)
+}
+
+// language describes how comments and functions look in a source language
+type language struct {
+	name          string
+	layer         string
+	lineComments  []string
+	blockStart    string
+	blockEnd      string
+	functionStart *regexp.Regexp
+	// blockStyle selects how the end of a function is found: "braces", "indent" or "statement"
+	blockStyle string
+}
+
+var (
+	jsLanguage = &language{
+		name:         "javascript",
+		layer:        "frontend",
+		lineComments: []string{"//"},
+		blockStart:   "/*",
+		blockEnd:     "*/",
+		functionStart: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)|` +
+			`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)|` +
+			`^\s*(?:public\s+|private\s+|protected\s+|static\s+|async\s+)*([A-Za-z_$][\w$]*)\s*\([^)]*\)\s*(?::[^{]+)?\{\s*$|` +
+			`^\s*(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][\w$]*)`),
+		blockStyle: "braces",
+	}
+
+	pythonLanguage = &language{
+		name:          "python",
+		layer:         "backend",
+		lineComments:  []string{"#"},
+		functionStart: regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s+([A-Za-z_]\w*)`),
+		blockStyle:    "indent",
+	}
+
+	sqlLanguage = &language{
+		name:         "sql",
+		layer:        "database",
+		lineComments: []string{"--"},
+		blockStart:   "/*",
+		blockEnd:     "*/",
+		functionStart: regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:unique\s+)?(?:temp(?:orary)?\s+)?` +
+			`(?:function|procedure|table|view|trigger|index|materialized\s+view)\s+(?:if\s+not\s+exists\s+)?([\w."]+)`),
+		blockStyle: "statement",
+	}
+)
+
+// languageForFile returns the language of a source file, or nil if the file is
+// not scanned. Go files are handled separately through go/parser.
+func languageForFile(path string) *language {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return jsLanguage
+	case ".py":
+		return pythonLanguage
+	case ".sql":
+		return sqlLanguage
+	}
+	return nil
+}
+
+// functionSpan is a named block of code with 1-based inclusive line numbers
+type functionSpan struct {
+	name  string
+	start int
+	end   int
+}
+
+// scanLines finds RTM annotations in a non-Go source file and attaches each one
+// to the function that encloses it or directly follows it
+func scanLines(lang *language, relPath string, content []byte) []Annotation {
+	lines := splitLines(content)
+	spans := findSpans(lang, lines)
+
+	var annotations []Annotation
+	inBlock := false
+	for i, line := range lines {
+		comment, stillInBlock := commentText(lang, line, inBlock)
+		inBlock = stillInBlock
+		if comment == "" {
+			continue
+		}
+
+		for _, key := range parseAnnotationKeys(comment) {
+			a := Annotation{
+				RequirementKey: key,
+				FilePath:       relPath,
+				Line:           i + 1,
+				Layer:          lang.layer,
+			}
+			if span := attachSpan(lang, lines, spans, i+1); span != nil {
+				a.Function = span.name
+				a.StartLine = span.start
+				a.EndLine = span.end
+			}
+			annotations = append(annotations, a)
+		}
+	}
+
+	return annotations
+}
+
+// parseAnnotationKeys extracts every requirement key referenced in a comment
+func parseAnnotationKeys(comment string) []string {
+	var keys []string
+	for _, match := range annotationPattern.FindAllStringSubmatch(comment, -1) {
+		for _, key := range strings.Split(match[1], ",") {
+			key = strings.TrimSpace(key)
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// commentText returns the comment portion of a line, tracking whether a block
+// comment continues onto the next line. Comment markers inside string
+// literals, as in "http://", are not comments.
+func commentText(lang *language, line string, inBlock bool) (string, bool) {
+	if inBlock {
+		if idx := strings.Index(line, lang.blockEnd); idx >= 0 {
+			return line[:idx], false
+		}
+		return line, true
+	}
+
+	lineIdx, lineMarker := -1, ""
+	for _, marker := range lang.lineComments {
+		if idx := indexOutsideStrings(line, marker); idx >= 0 && (lineIdx < 0 || idx < lineIdx) {
+			lineIdx, lineMarker = idx, marker
+		}
+	}
+
+	if lang.blockStart != "" {
+		if idx := indexOutsideStrings(line, lang.blockStart); idx >= 0 && (lineIdx < 0 || idx < lineIdx) {
+			rest := line[idx+len(lang.blockStart):]
+			if end := strings.Index(rest, lang.blockEnd); end >= 0 {
+				return rest[:end], false
+			}
+			return rest, true
+		}
+	}
+
+	if lineIdx >= 0 {
+		return line[lineIdx+len(lineMarker):], false
+	}
+	return "", false
+}
+
+// indexOutsideStrings returns the index of the first marker in line that is
+// not inside a quoted string, or -1
+func indexOutsideStrings(line, marker string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case strings.HasPrefix(line[i:], marker):
+			return i
+		}
+	}
+	return -1
+}
+
+// attachSpan picks the function an annotation on the given line belongs to: the
+// next function if only comments and blank lines sit between the annotation and
+// the declaration, otherwise the innermost function containing it
+func attachSpan(lang *language, lines []string, spans []functionSpan, line int) *functionSpan {
+	if next := followingSpan(lang, lines, spans, line); next != nil {
+		return next
+	}
+
+	var enclosing *functionSpan
+	for i := range spans {
+		span := &spans[i]
+		if span.start <= line && line <= span.end {
+			if enclosing == nil || span.start >= enclosing.start {
+				enclosing = span
+			}
+		}
+	}
+	return enclosing
+}
+
+// followingSpan returns the first function declared after line when nothing but
+// comments separates the two
+func followingSpan(lang *language, lines []string, spans []functionSpan, line int) *functionSpan {
+	for i := range spans {
+		span := &spans[i]
+		if span.start <= line {
+			continue
+		}
+		for l := line; l < span.start-1; l++ {
+			trimmed := strings.TrimSpace(lines[l])
+			if trimmed != "" && !isCommentLine(lang, trimmed) {
+				return nil
+			}
+		}
+		return span
+	}
+	return nil
+}
+
+func isCommentLine(lang *language, trimmed string) bool {
+	for _, marker := range lang.lineComments {
+		if strings.HasPrefix(trimmed, marker) {
+			return true
+		}
+	}
+	if lang.blockStart != "" {
+		return strings.HasPrefix(trimmed, lang.blockStart) || strings.HasPrefix(trimmed, "*") ||
+			strings.HasSuffix(trimmed, lang.blockEnd)
+	}
+	// Python decorators sit between comments and the def they decorate
+	return lang == pythonLanguage && strings.HasPrefix(trimmed, "@")
+}
+
+// findSpans locates function-like blocks using the language's block style
+func findSpans(lang *language, lines []string) []functionSpan {
+	var spans []functionSpan
+	for i, line := range lines {
+		match := lang.functionStart.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name := ""
+		for _, group := range match[1:] {
+			if group != "" {
+				name = group
+				break
+			}
+		}
+		if name == "" || isKeyword(name) {
+			continue
+		}
+
+		var end int
+		switch lang.blockStyle {
+		case "braces":
+			end = braceBlockEnd(lines, i)
+		case "indent":
+			end = indentBlockEnd(lines, i)
+		case "statement":
+			end = statementEnd(lines, i)
+		}
+		spans = append(spans, functionSpan{name: strings.Trim(name, `"`), start: i + 1, end: end})
+	}
+	return spans
+}
+
+// isKeyword filters control-flow statements that the method pattern would
+// otherwise mistake for declarations, e.g. "if (x) {"
+func isKeyword(name string) bool {
+	switch name {
+	case "if", "for", "while", "switch", "catch", "function", "return", "else", "do", "with":
+		return true
+	}
+	return false
+}
+
+// braceBlockEnd returns the line on which the brace opened at or after start closes
+func braceBlockEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		for _, r := range lines[i] {
+			switch r {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return i + 1
+		}
+		// Single-expression arrow functions without braces end on their own line
+		if !opened && i > start && strings.HasSuffix(strings.TrimSpace(lines[i]), ";") {
+			return i + 1
+		}
+	}
+	return start + 1
+}
+
+// indentBlockEnd returns the last line indented deeper than the def at start
+func indentBlockEnd(lines []string, start int) int {
+	baseIndent := indentation(lines[start])
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentation(lines[i]) <= baseIndent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// statementEnd returns the line holding the terminating semicolon of a SQL
+// statement, skipping over dollar-quoted function bodies
+func statementEnd(lines []string, start int) int {
+	inDollar := false
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if idx := strings.Index(line, "--"); idx >= 0 {
+			line = line[:idx]
+		}
+		if strings.Count(line, "$$")%2 == 1 {
+			inDollar = !inDollar
+		}
+		if !inDollar && strings.HasSuffix(strings.TrimSpace(line), ";") {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+func indentation(line string) int {
+	count := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			count++
+		case '\t':
+			count += 4
+		default:
+			return count
+		}
+	}
+	return count
+}
+
+func splitLines(content []byte) []string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
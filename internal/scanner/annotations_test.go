@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAnnotationKeys(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    []string
+	}{
+		{"// RTM: SCOPE-1-US-1-TS-1", []string{"SCOPE-1-US-1-TS-1"}},
+		{"/* RTM: [SCOPE-1-US-1-TS-1] */", []string{"SCOPE-1-US-1-TS-1"}},
+		{"# RTM: [TS-001, TS-002]", []string{"TS-001", "TS-002"}},
+		{"-- RTM: SCOPE-1-US-1-TS-1, SCOPE-1-US-1-TS-2", []string{"SCOPE-1-US-1-TS-1", "SCOPE-1-US-1-TS-2"}},
+		{"RTM:PROJ-42", []string{"PROJ-42"}},
+		{"RTM: SCOPE-1.2", []string{"SCOPE-1.2"}},
+		{"RTM: see the docs", nil},
+		{"RTM: TODO", nil},
+		{"RTM: 123", nil},
+		{"no annotation here SCOPE-1", nil},
+	}
+	for _, tt := range tests {
+		if got := parseAnnotationKeys(tt.comment); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAnnotationKeys(%q) = %q, want %q", tt.comment, got, tt.want)
+		}
+	}
+}
+
+func TestCommentText(t *testing.T) {
+	tests := []struct {
+		name        string
+		lang        *language
+		line        string
+		inBlock     bool
+		want        string
+		wantInBlock bool
+	}{
+		{"line comment", jsLanguage, "foo() // RTM: TS-1", false, " RTM: TS-1", false},
+		{"url in string", jsLanguage, `fetch("http://example.com/RTM: TS-1")`, false, "", false},
+		{"url in string then comment", jsLanguage, `fetch('http://x') // RTM: TS-1`, false, " RTM: TS-1", false},
+		{"escaped quote", jsLanguage, `s = "a\"//b" // c`, false, " c", false},
+		{"template literal", jsLanguage, "s = `//` // c", false, " c", false},
+		{"block comment", jsLanguage, "x /* RTM: TS-1 */ y", false, " RTM: TS-1 ", false},
+		{"block before line", jsLanguage, "/* a // b */", false, " a // b ", false},
+		{"block opens", jsLanguage, "/* RTM: TS-1", false, " RTM: TS-1", true},
+		{"block continues", jsLanguage, " * RTM: TS-1", true, " * RTM: TS-1", true},
+		{"block closes", jsLanguage, " RTM: TS-1 */ code", true, " RTM: TS-1 ", false},
+		{"python", pythonLanguage, `url = "http://x#frag"  # RTM: TS-1`, false, " RTM: TS-1", false},
+		{"sql", sqlLanguage, `SELECT '--' -- RTM: TS-1`, false, " RTM: TS-1", false},
+		{"no comment", jsLanguage, "const a = 1", false, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inBlock := commentText(tt.lang, tt.line, tt.inBlock)
+			if got != tt.want || inBlock != tt.wantInBlock {
+				t.Errorf("commentText(%q) = %q, %v; want %q, %v", tt.line, got, inBlock, tt.want, tt.wantInBlock)
+			}
+		})
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	tests := []struct {
+		name string
+		lang *language
+		src  string
+		want []Annotation
+	}{
+		{
+			name: "annotation above function",
+			lang: jsLanguage,
+			src: `// RTM: SCOPE-1-US-1
+export function login(user) {
+  return user
+}
+`,
+			want: []Annotation{{RequirementKey: "SCOPE-1-US-1", FilePath: "f", Line: 1, Function: "login", StartLine: 2, EndLine: 4, Layer: "frontend"}},
+		},
+		{
+			name: "annotation inside function",
+			lang: pythonLanguage,
+			src: `def pay(order):
+    # RTM: SCOPE-2-US-1
+    return order
+
+x = 1
+`,
+			want: []Annotation{{RequirementKey: "SCOPE-2-US-1", FilePath: "f", Line: 2, Function: "pay", StartLine: 1, EndLine: 3, Layer: "backend"}},
+		},
+		{
+			name: "statement",
+			lang: sqlLanguage,
+			src: `-- RTM: [TS-001, TS-002]
+CREATE TABLE users (
+  id INTEGER
+);
+`,
+			want: []Annotation{
+				{RequirementKey: "TS-001", FilePath: "f", Line: 1, Function: "users", StartLine: 2, EndLine: 4, Layer: "database"},
+				{RequirementKey: "TS-002", FilePath: "f", Line: 1, Function: "users", StartLine: 2, EndLine: 4, Layer: "database"},
+			},
+		},
+		{
+			name: "string literal is not a comment",
+			lang: jsLanguage,
+			src:  `const docs = "http://example.com // RTM: SCOPE-1"` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scanLines(tt.lang, "f", []byte(tt.src))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanLines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanGoFile(t *testing.T) {
+	src := `package p
+
+// Login checks credentials.
+// RTM: SCOPE-1-US-1
+func Login() {
+	url := "http://example.com // RTM: SCOPE-9"
+	_ = url
+}
+
+func Logout() {
+	// RTM: SCOPE-1-US-2
+}
+`
+	got, err := scanGoFile("p.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{RequirementKey: "SCOPE-1-US-1", FilePath: "p.go", Line: 4, Function: "Login", StartLine: 5, EndLine: 8, Layer: "backend"},
+		{RequirementKey: "SCOPE-1-US-2", FilePath: "p.go", Line: 11, Function: "Logout", StartLine: 10, EndLine: 12, Layer: "backend"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanGoFile() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// scanGoFile finds RTM annotations in a Go source file. Comments are resolved
+// with go/parser so an annotation is attached to the function whose doc comment
+// or body contains it. Methods are recorded by their bare name, matching how
+// functions are listed in RTM files.
+func scanGoFile(relPath string, content []byte) ([]Annotation, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, relPath, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations []Annotation
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			keys := parseAnnotationKeys(comment.Text)
+			if len(keys) == 0 {
+				continue
+			}
+
+			line := fset.Position(comment.Slash).Line
+			fn := enclosingFunc(file, group, comment.Slash)
+			for _, key := range keys {
+				a := Annotation{
+					RequirementKey: key,
+					FilePath:       relPath,
+					Line:           line,
+					Layer:          "backend",
+				}
+				if fn != nil {
+					a.Function = fn.Name.Name
+					a.StartLine = fset.Position(fn.Pos()).Line
+					a.EndLine = fset.Position(fn.End()).Line
+				}
+				annotations = append(annotations, a)
+			}
+		}
+	}
+
+	return annotations, nil
+}
+
+// enclosingFunc returns the function declaration documented by the comment
+// group or containing the given position
+func enclosingFunc(file *ast.File, group *ast.CommentGroup, pos token.Pos) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if fn.Doc == group || (fn.Pos() <= pos && pos < fn.End()) {
+			return fn
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package scanner
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// TestLink ties a Go test (or subtest) to a requirement key
+type TestLink struct {
+	RequirementKey string `json:"requirement_key"`
+	FilePath       string `json:"file_path"`
+	TestName       string `json:"test_name"`
+	Line           int    `json:"line"`
+}
+
+// goTestFile holds what was learnt from parsing one _test.go file
+type goTestFile struct {
+	relPath string
+	tests   map[string]bool // top-level test functions and subtests found in the file
+	links   []TestLink
+}
+
+// findGoTestFiles parses every _test.go file under root and collects the tests
+// that reference a requirement, either through an RTM annotation in the test
+// function's doc comment or body, or through a t.Run subtest name that contains
+// one of knownKeys.
+func findGoTestFiles(root string, knownKeys []string) ([]goTestFile, []string, error) {
+	var files []goTestFile
+	var parseErrors []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := parseGoTestFile(filepath.ToSlash(relPath), content, knownKeys)
+		if err != nil {
+			parseErrors = append(parseErrors, err.Error())
+			return nil
+		}
+		files = append(files, *file)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, parseErrors, nil
+}
+
+func parseGoTestFile(relPath string, content []byte, knownKeys []string) (*goTestFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, relPath, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &goTestFile{relPath: relPath, tests: make(map[string]bool)}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil || !isTestFunc(fn) {
+			continue
+		}
+		testName := fn.Name.Name
+		result.tests[testName] = true
+
+		// Annotations in the doc comment or anywhere inside the function body
+		seen := make(map[string]bool)
+		for _, group := range file.Comments {
+			if group != fn.Doc && (group.Pos() < fn.Pos() || group.End() > fn.End()) {
+				continue
+			}
+			for _, key := range parseAnnotationKeys(group.Text()) {
+				if !seen[key] {
+					seen[key] = true
+					result.links = append(result.links, TestLink{
+						RequirementKey: key,
+						FilePath:       relPath,
+						TestName:       testName,
+						Line:           fset.Position(fn.Pos()).Line,
+					})
+				}
+			}
+		}
+
+		// Subtests named after requirement keys
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Run" {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			name, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+
+			subtestName := testName + "/" + rewriteSubtestName(name)
+			result.tests[subtestName] = true
+			for _, key := range keysInText(name, knownKeys) {
+				result.links = append(result.links, TestLink{
+					RequirementKey: key,
+					FilePath:       relPath,
+					TestName:       subtestName,
+					Line:           fset.Position(call.Pos()).Line,
+				})
+			}
+			return true
+		})
+	}
+
+	return result, nil
+}
+
+// isTestFunc reports whether fn has the shape func TestXxx(t *testing.T)
+func isTestFunc(fn *ast.FuncDecl) bool {
+	name := fn.Name.Name
+	if !strings.HasPrefix(name, "Test") || name == "TestMain" {
+		return false
+	}
+	if len(name) > 4 {
+		r, _ := utf8.DecodeRuneInString(name[4:])
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+
+	params := fn.Type.Params.List
+	if len(params) != 1 {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "T"
+}
+
+// rewriteSubtestName mirrors how the testing package names subtests: spaces
+// become underscores and non-printable characters are escaped
+func rewriteSubtestName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b.WriteString(s[1 : len(s)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// keysInText returns the known requirement keys that appear in text as whole
+// tokens, so "SCOPE-1" does not match inside "SCOPE-1-US-2"
+func keysInText(text string, knownKeys []string) []string {
+	var found []string
+	for _, key := range knownKeys {
+		for start := 0; start < len(text); {
+			idx := strings.Index(text[start:], key)
+			if idx < 0 {
+				break
+			}
+			idx += start
+			end := idx + len(key)
+			if (idx == 0 || !isKeyChar(text[idx-1])) && (end == len(text) || !isKeyChar(text[end])) {
+				found = append(found, key)
+				break
+			}
+			start = idx + 1
+		}
+	}
+	return found
+}
+
+func isKeyChar(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// linkGoTests records the discovered test links in test_files, test_cases and
+// requirement_test_coverage
+func linkGoTests(tx database.Tx, projectID string, files []goTestFile, requirementIDs map[string]string, result *Result) error {
+	result.TestFilesScanned = len(files)
+	for _, file := range files {
+		for _, link := range file.links {
+			requirementID, ok := requirementIDs[link.RequirementKey]
+			if !ok {
+				addUnknownKey(result, link.RequirementKey)
+				continue
+			}
+
+			testFileID, err := ensureGoTestFile(tx, projectID, file.relPath)
+			if err != nil {
+				return fmt.Errorf("failed to record test file %s: %w", file.relPath, err)
+			}
+
+			var testCaseID string
+			err = tx.QueryRow("SELECT id FROM test_cases WHERE test_file_id = ? AND test_name = ?",
+				testFileID, link.TestName).Scan(&testCaseID)
+			if err != nil {
+				query := `INSERT INTO test_cases (test_file_id, test_name, test_type)
+						  VALUES (?, ?, 'unit')
+						  RETURNING id`
+				if err := tx.QueryRow(query, testFileID, link.TestName).Scan(&testCaseID); err != nil {
+					return fmt.Errorf("failed to record test case %s: %w", link.TestName, err)
+				}
+			}
+
+			res, err := tx.Exec(`INSERT OR IGNORE INTO requirement_test_coverage (requirement_id, test_case_id, coverage_type, source)
+				VALUES (?, ?, 'requirement', 'scan')`, requirementID, testCaseID)
+			if err != nil {
+				return fmt.Errorf("failed to link %s to %s: %w", link.TestName, link.RequirementKey, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				result.TestLinksCreated++
+			}
+			result.TestLinks = append(result.TestLinks, link)
+		}
+	}
+
+	return nil
+}
+
+// findMissingGoTests reports Go test cases linked in the database whose
+// function (or file) no longer exists in the scanned repository
+func findMissingGoTests(db *database.DB, projectID string, files []goTestFile, result *Result) error {
+	parsed := make(map[string]map[string]bool)
+	for _, file := range files {
+		parsed[file.relPath] = file.tests
+	}
+
+	rows, err := db.Query(`
+		SELECT tf.file_path, tc.test_name
+		FROM test_cases tc
+		JOIN test_files tf ON tc.test_file_id = tf.id
+		WHERE tf.project_id = ? AND tf.file_path LIKE '%\_test.go' ESCAPE '\'
+		ORDER BY tf.file_path, tc.test_name`, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load linked Go tests: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filePath, testName string
+		if err := rows.Scan(&filePath, &testName); err != nil {
+			return err
+		}
+		tests, ok := parsed[filePath]
+		if !ok {
+			result.MissingTests = append(result.MissingTests, fmt.Sprintf("%s: file not found", filePath))
+			continue
+		}
+		if !tests[testName] {
+			result.MissingTests = append(result.MissingTests, fmt.Sprintf("%s: %s", filePath, testName))
+		}
+	}
+
+	return rows.Err()
+}
+
+func ensureGoTestFile(tx database.Tx, projectID, filePath string) (string, error) {
+	var testFileID string
+	err := tx.QueryRow("SELECT id FROM test_files WHERE project_id = ? AND file_path = ?",
+		projectID, filePath).Scan(&testFileID)
+	if err == nil {
+		return testFileID, nil
+	}
+
+	query := `INSERT INTO test_files (project_id, file_path, test_type, layer, framework)
+			  VALUES (?, ?, 'unit', 'backend', 'Go testing')
+			  RETURNING id`
+	err = tx.QueryRow(query, projectID, filePath).Scan(&testFileID)
+	return testFileID, err
+}
@@ -0,0 +1,321 @@
+package scanner
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+	"github.com/peshwar9/tracevibe/internal/models"
+)
+
+// skippedDirs are never descended into while walking a repository
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	".next":        true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+type Scanner struct {
+	db *database.DB
+}
+
+func New(db *database.DB) *Scanner {
+	return &Scanner{db: db}
+}
+
+// Result summarises a repository scan
+type Result struct {
+	FilesScanned           int          `json:"files_scanned"`
+	Annotations            []Annotation `json:"annotations"`
+	ImplementationsCreated int          `json:"implementations_created"`
+	ImplementationsUpdated int          `json:"implementations_updated"`
+	TestFilesScanned       int          `json:"test_files_scanned"`
+	TestLinks              []TestLink   `json:"test_links"`
+	TestLinksCreated       int          `json:"test_links_created"`
+	MissingTests           []string     `json:"missing_tests,omitempty"`
+	UnknownKeys            []string     `json:"unknown_keys,omitempty"`
+	ParseErrors            []string     `json:"parse_errors,omitempty"`
+}
+
+func addUnknownKey(result *Result, key string) {
+	if !contains(result.UnknownKeys, key) {
+		result.UnknownKeys = append(result.UnknownKeys, key)
+	}
+}
+
+// FindAnnotations walks root and returns every RTM annotation in Go, JS/TS,
+// Python and SQL source files. Test files are skipped; they describe coverage,
+// not implementation.
+func FindAnnotations(root string) ([]Annotation, int, []string, error) {
+	var annotations []Annotation
+	var parseErrors []string
+	filesScanned := 0
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsTestFile(path) {
+			return nil
+		}
+
+		isGo := strings.HasSuffix(path, ".go")
+		lang := languageForFile(path)
+		if !isGo && lang == nil {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		filesScanned++
+
+		if isGo {
+			found, err := scanGoFile(relPath, content)
+			if err != nil {
+				parseErrors = append(parseErrors, err.Error())
+				return nil
+			}
+			annotations = append(annotations, found...)
+			return nil
+		}
+
+		annotations = append(annotations, scanLines(lang, relPath, content)...)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	return annotations, filesScanned, parseErrors, nil
+}
+
+// IsTestFile reports whether a path follows the test file naming conventions of
+// the supported languages
+func IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(base, "_test.go"):
+		return true
+	case strings.Contains(base, ".test.") || strings.Contains(base, ".spec."):
+		return true
+	case strings.HasSuffix(base, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")):
+		return true
+	}
+	return false
+}
+
+// ScanProject scans root for RTM annotations and upserts the matching rows in
+// the implementations table of the given project. Go test files are parsed as
+// well, and tests that reference a requirement are linked to it.
+func (s *Scanner) ScanProject(root, projectKey string) (*Result, error) {
+	project, err := s.db.GetProjectByKey(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project not found: %s", projectKey)
+	}
+	if root == "" {
+		root = "."
+		if project.SourceRoot != nil && *project.SourceRoot != "" {
+			root = *project.SourceRoot
+		}
+	}
+
+	requirementIDs, err := s.requirementIDs(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	knownKeys := make([]string, 0, len(requirementIDs))
+	for key := range requirementIDs {
+		knownKeys = append(knownKeys, key)
+	}
+	sort.Strings(knownKeys)
+
+	annotations, filesScanned, parseErrors, err := FindAnnotations(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	testFiles, testParseErrors, err := findGoTestFiles(root, knownKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan Go tests in %s: %w", root, err)
+	}
+
+	result := &Result{
+		FilesScanned: filesScanned,
+		Annotations:  annotations,
+		ParseErrors:  append(parseErrors, testParseErrors...),
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, group := range groupByFile(annotations) {
+		key := group[0].RequirementKey
+		requirementID, ok := requirementIDs[key]
+		if !ok {
+			addUnknownKey(result, key)
+			continue
+		}
+
+		created, err := upsertImplementation(tx, requirementID, group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record implementation of %s in %s: %w", key, group[0].FilePath, err)
+		}
+		if created {
+			result.ImplementationsCreated++
+		} else {
+			result.ImplementationsUpdated++
+		}
+	}
+
+	if err := linkGoTests(tx, project.ID, testFiles, requirementIDs, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scan results: %w", err)
+	}
+
+	if err := findMissingGoTests(s.db, project.ID, testFiles, result); err != nil {
+		return nil, err
+	}
+	sort.Strings(result.UnknownKeys)
+
+	return result, nil
+}
+
+// requirementIDs maps every requirement key of a project to its row ID
+func (s *Scanner) requirementIDs(projectID string) (map[string]string, error) {
+	rows, err := s.db.Query("SELECT requirement_key, id FROM requirements WHERE project_id = ?", projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requirements: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var key, id string
+		if err := rows.Scan(&key, &id); err != nil {
+			return nil, err
+		}
+		ids[key] = id
+	}
+	return ids, rows.Err()
+}
+
+// groupByFile buckets annotations by (requirement key, file path) in a stable order
+func groupByFile(annotations []Annotation) [][]Annotation {
+	index := make(map[string]int)
+	var groups [][]Annotation
+	for _, a := range annotations {
+		k := a.RequirementKey + "\x00" + a.FilePath
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], a)
+	}
+	return groups
+}
+
+// upsertImplementation records the functions and line ranges of one file for a
+// requirement. Existing rows keep their layer, source and any functions listed
+// in the imported RTM; line ranges are replaced by what the scan found. New
+// rows are marked as scanned, so re-importing the RTM file keeps them.
+func upsertImplementation(tx database.Tx, requirementID string, group []Annotation) (bool, error) {
+	var functions, lineRanges []string
+	seenFunc := make(map[string]bool)
+	seenRange := make(map[string]bool)
+	for _, a := range group {
+		if a.Function != "" && !seenFunc[a.Function] {
+			seenFunc[a.Function] = true
+			functions = append(functions, a.Function)
+		}
+		if r := a.LineRange(); !seenRange[r] {
+			seenRange[r] = true
+			lineRanges = append(lineRanges, r)
+		}
+	}
+
+	lineRangesJSON, err := models.MarshalStringSliceJSON(lineRanges)
+	if err != nil {
+		return false, err
+	}
+
+	var existingID, existingFunctions string
+	err = tx.QueryRow(`SELECT id, COALESCE(functions, '[]') FROM implementations
+		WHERE requirement_id = ? AND file_path = ? LIMIT 1`,
+		requirementID, group[0].FilePath).Scan(&existingID, &existingFunctions)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	if err != nil {
+		functionsJSON, err := models.MarshalStringSliceJSON(functions)
+		if err != nil {
+			return false, err
+		}
+		query := `INSERT INTO implementations (requirement_id, layer, file_path, functions, line_ranges, source)
+				  VALUES (?, ?, ?, ?, ?, 'scan')`
+		_, err = tx.Exec(query, requirementID, group[0].Layer, group[0].FilePath, functionsJSON, lineRangesJSON)
+		return true, err
+	}
+
+	merged, _ := models.UnmarshalStringSliceJSON(existingFunctions)
+	for _, fn := range functions {
+		if !contains(merged, fn) {
+			merged = append(merged, fn)
+		}
+	}
+	functionsJSON, err := models.MarshalStringSliceJSON(merged)
+	if err != nil {
+		return false, err
+	}
+
+	query := `UPDATE implementations SET functions = ?, line_ranges = ?, updated_at = datetime('now')
+			  WHERE id = ?`
+	_, err = tx.Exec(query, functionsJSON, lineRangesJSON, existingID)
+	return false, err
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
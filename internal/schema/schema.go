@@ -0,0 +1,233 @@
+// Package schema holds the versioned JSON Schema of the RTM import format and
+// a validator for the subset of JSON Schema it uses.
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version is the RTM schema version written by exports and expected by the importer
+const Version = "1.0"
+
+//go:embed rtm.schema.json
+var rtmSchema []byte
+
+// RTM returns the JSON Schema document for RTM files
+func RTM() []byte {
+	return rtmSchema
+}
+
+// Error is a single schema violation, located by its JSON path
+type Error struct {
+	Path    string
+	Message string
+}
+
+type node struct {
+	Ref                  string           `json:"$ref"`
+	Type                 string           `json:"type"`
+	Required             []string         `json:"required"`
+	Properties           map[string]*node `json:"properties"`
+	Items                *node            `json:"items"`
+	Enum                 []string         `json:"enum"`
+	MinLength            *int             `json:"minLength"`
+	Pattern              string           `json:"pattern"`
+	AnyOf                []*node          `json:"anyOf"`
+	Definitions          map[string]*node `json:"definitions"`
+	AdditionalProperties *bool            `json:"additionalProperties"`
+}
+
+var (
+	compileOnce sync.Once
+	compiled    *node
+	compileErr  error
+)
+
+func root() (*node, error) {
+	compileOnce.Do(func() {
+		var n node
+		if err := json.Unmarshal(rtmSchema, &n); err != nil {
+			compileErr = fmt.Errorf("failed to parse embedded RTM schema: %w", err)
+			return
+		}
+		compiled = &n
+	})
+	return compiled, compileErr
+}
+
+// Validate checks a decoded document (maps, slices and scalars as produced by
+// encoding/json) against the RTM schema and returns every violation found
+func Validate(doc interface{}) ([]Error, error) {
+	r, err := root()
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{root: r}
+	v.validate(r, doc, "")
+	return v.errors, nil
+}
+
+type validator struct {
+	root   *node
+	errors []Error
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) resolve(n *node) *node {
+	for n.Ref != "" {
+		name := strings.TrimPrefix(n.Ref, "#/definitions/")
+		def, ok := v.root.Definitions[name]
+		if !ok {
+			return &node{}
+		}
+		n = def
+	}
+	return n
+}
+
+func (v *validator) validate(n *node, value interface{}, path string) {
+	n = v.resolve(n)
+
+	if n.Type != "" && !hasType(value, n.Type) {
+		v.add(path, "expected %s, got %s", n.Type, typeName(value))
+		return
+	}
+
+	if len(n.AnyOf) > 0 {
+		var alternatives []string
+		matched := false
+		for _, option := range n.AnyOf {
+			sub := &validator{root: v.root}
+			sub.validate(option, value, path)
+			if len(sub.errors) == 0 {
+				matched = true
+				break
+			}
+			alternatives = append(alternatives, describe(sub.errors[0], path))
+		}
+		if !matched {
+			v.add(path, "must satisfy one of: %s", strings.Join(alternatives, "; "))
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, field := range n.Required {
+			if _, ok := val[field]; !ok {
+				v.add(join(path, field), "missing mandatory field")
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := n.Properties[key]
+			if !ok {
+				if n.AdditionalProperties != nil && !*n.AdditionalProperties {
+					v.add(join(path, key), "unknown field")
+				}
+				continue
+			}
+			v.validate(prop, val[key], join(path, key))
+		}
+	case []interface{}:
+		if n.Items != nil {
+			for i, item := range val {
+				v.validate(n.Items, item, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case string:
+		if n.MinLength != nil && len(val) < *n.MinLength {
+			if *n.MinLength == 1 {
+				v.add(path, "must not be empty")
+			} else {
+				v.add(path, "must be at least %d characters", *n.MinLength)
+			}
+		}
+		if n.Pattern != "" {
+			if ok, _ := regexp.MatchString(n.Pattern, val); !ok {
+				v.add(path, "%q does not match pattern %s", val, n.Pattern)
+			}
+		}
+		if len(n.Enum) > 0 && !containsString(n.Enum, val) {
+			v.add(path, "%q is not one of %s", val, strings.Join(n.Enum, ", "))
+		}
+	}
+}
+
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describe(err Error, base string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(err.Path, base), ".")
+	if rel == "" {
+		return err.Message
+	}
+	return rel + " " + err.Message
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
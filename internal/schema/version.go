@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// upgrades[n] rewrites a document of major version n into major version n+1.
+// Register a function here whenever a breaking change bumps the major version.
+var upgrades = map[int]func(doc map[string]interface{}) error{}
+
+// DocumentVersion returns the schema_version declared in a decoded document's
+// metadata. Files written before the field existed are treated as 1.0.
+func DocumentVersion(doc map[string]interface{}) string {
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+		if version, ok := metadata["schema_version"].(string); ok && version != "" {
+			return version
+		}
+	}
+	return "1.0"
+}
+
+// Upgrade brings a decoded document up to the current schema Version. It
+// reports whether the document was changed, and fails for documents written
+// by a newer TraceVibe than this one.
+func Upgrade(doc map[string]interface{}) (bool, error) {
+	version := DocumentVersion(doc)
+	major, err := majorVersion(version)
+	if err != nil {
+		return false, err
+	}
+	current, _ := majorVersion(Version)
+
+	if major > current {
+		return false, fmt.Errorf("schema_version %s is newer than this tracevibe supports (%s); upgrade tracevibe to import this file", version, Version)
+	}
+
+	changed := false
+	for ; major < current; major++ {
+		upgrade, ok := upgrades[major]
+		if !ok {
+			return false, fmt.Errorf("no upgrade path from schema_version %d.x to %s", major, Version)
+		}
+		if err := upgrade(doc); err != nil {
+			return false, fmt.Errorf("failed to upgrade document from schema_version %d.x: %w", major, err)
+		}
+		changed = true
+	}
+
+	if changed {
+		metadata, ok := doc["metadata"].(map[string]interface{})
+		if !ok {
+			metadata = map[string]interface{}{}
+			doc["metadata"] = metadata
+		}
+		metadata["schema_version"] = Version
+	}
+	return changed, nil
+}
+
+func majorVersion(version string) (int, error) {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema_version %q (expected MAJOR.MINOR)", version)
+	}
+	return n, nil
+}
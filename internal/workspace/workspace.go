@@ -0,0 +1,110 @@
+// Package workspace locates the files of a project in its local checkout: the
+// project's source root and the directories of its components within it.
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+// Workspace is the local checkout of a project
+type Workspace struct {
+	Root       string // checkout directory; "" for the current directory
+	components map[string]database.ComponentPath
+}
+
+// Load returns the checkout of a project: its own source root, or else
+// fallback, e.g. a server-wide base path. Component paths outside the checkout
+// are ignored.
+func Load(db *database.DB, project *database.Project, fallback string) (*Workspace, error) {
+	root := fallback
+	if project.SourceRoot != nil && *project.SourceRoot != "" {
+		root = *project.SourceRoot
+	}
+	components, err := db.ListComponentPaths(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{Root: root, components: components}
+	for key, component := range components {
+		component.Path, component.BasePath = w.local(component.Path), w.local(component.BasePath)
+		components[key] = component
+	}
+	return w, nil
+}
+
+// Dir is the checkout directory
+func (w *Workspace) Dir() string {
+	if w.Root == "" {
+		return "."
+	}
+	return w.Root
+}
+
+// ComponentDir returns the directory of a component, or the checkout
+// directory when its path is unknown
+func (w *Workspace) ComponentDir(componentKey string) string {
+	return w.join(w.components[componentKey].Path)
+}
+
+// Resolve returns where a file of a component is, relative to the checkout
+// directory: under the component's base path when it has one; otherwise at
+// the root, or under the component's directory when it is not at the root.
+// Files outside the checkout resolve to "".
+func (w *Workspace) Resolve(componentKey, file string) string {
+	if filepath.IsAbs(file) {
+		return w.local(file)
+	}
+	component := w.components[componentKey]
+	resolved := file
+	switch {
+	case component.BasePath != "":
+		resolved = filepath.Join(component.BasePath, file)
+	case component.Path != "" && !exists(w.join(file)) && exists(w.join(filepath.Join(component.Path, file))):
+		resolved = filepath.Join(component.Path, file)
+	}
+	return w.local(resolved)
+}
+
+// Path returns the location of a file of a component on disk, or "" when it
+// is outside the checkout
+func (w *Workspace) Path(componentKey, file string) string {
+	resolved := w.Resolve(componentKey, file)
+	if resolved == "" {
+		return ""
+	}
+	return w.join(resolved)
+}
+
+// local returns a path relative to the checkout directory, or "" when it is
+// outside it
+func (w *Workspace) local(path string) string {
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) {
+		root, err := filepath.Abs(w.Dir())
+		if err != nil {
+			return ""
+		}
+		if path, err = filepath.Rel(root, path); err != nil {
+			return ""
+		}
+	}
+	if !filepath.IsLocal(path) {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+// join resolves a path relative to the checkout directory
+func (w *Workspace) join(path string) string {
+	return filepath.Join(w.Dir(), path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
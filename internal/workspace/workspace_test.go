@@ -0,0 +1,79 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peshwar9/tracevibe/internal/database"
+)
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"svc/calc_test.go", "web/app.test.ts", "main_test.go"} {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := &Workspace{Root: root, components: map[string]database.ComponentPath{
+		"SVC": {Path: "svc"},
+		"WEB": {Path: "web", BasePath: "web"},
+	}}
+	tests := []struct {
+		component string
+		file      string
+		want      string
+	}{
+		{"SVC", "calc_test.go", "svc/calc_test.go"},
+		{"SVC", "main_test.go", "main_test.go"},
+		{"SVC", "svc/calc_test.go", "svc/calc_test.go"},
+		{"SVC", "missing_test.go", "missing_test.go"},
+		{"WEB", "app.test.ts", "web/app.test.ts"},
+		{"WEB", "../main_test.go", "main_test.go"},
+		{"OTHER", "main_test.go", "main_test.go"},
+		{"OTHER", filepath.Join(root, "svc/calc_test.go"), "svc/calc_test.go"},
+		{"OTHER", "../outside_test.go", ""},
+		{"OTHER", "/etc/passwd", ""},
+		{"WEB", "../../outside_test.go", ""},
+	}
+	for _, tt := range tests {
+		if got := w.Resolve(tt.component, tt.file); got != filepath.FromSlash(tt.want) {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.component, tt.file, got, tt.want)
+		}
+	}
+
+	if got, want := w.Path("SVC", "calc_test.go"), filepath.Join(root, "svc", "calc_test.go"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+	if got := w.Path("SVC", "../x_test.go"); got != "" {
+		t.Errorf("Path() outside the root = %q, want \"\"", got)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	w := &Workspace{Root: "/src/project"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"svc", "svc"},
+		{"svc/../web/", "web"},
+		{".", "."},
+		{"/src/project/svc", "svc"},
+		{"/src/project", "."},
+		{"/src/other", ""},
+		{"..", ""},
+		{"svc/../../x", ""},
+	}
+	for _, tt := range tests {
+		if got := w.local(tt.path); got != filepath.FromSlash(tt.want) {
+			t.Errorf("local(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
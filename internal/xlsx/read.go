@@ -0,0 +1,232 @@
+// Package xlsx reads and writes the parts of Office Open XML workbooks that
+// TraceVibe needs: plain cell values on named sheets, without styles, formulas
+// or dates.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Row is one non-empty row of a sheet. Number is the 1-based row number shown
+// in spreadsheet applications.
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+type workbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// textXML is a rich or plain string: either a single <t> or runs of <r><t>
+type textXML struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t textXML) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type sharedStringsXML struct {
+	Items []textXML `xml:"si"`
+}
+
+type sheetXML struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string  `xml:"r,attr"`
+			Type   string  `xml:"t,attr"`
+			Value  string  `xml:"v"`
+			Inline textXML `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadSheet returns the non-empty rows of the named sheet, or of the first
+// sheet when name is empty
+func ReadSheet(filePath, name string) ([]Row, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX file: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook workbookXML
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+	rID := ""
+	for _, sheet := range workbook.Sheets {
+		if name == "" || strings.EqualFold(sheet.Name, name) {
+			rID = sheet.RID
+			break
+		}
+	}
+	if rID == "" {
+		names := make([]string, len(workbook.Sheets))
+		for i, sheet := range workbook.Sheets {
+			names[i] = sheet.Name
+		}
+		return nil, fmt.Errorf("sheet %q not found (workbook has %s)", name, strings.Join(names, ", "))
+	}
+
+	var rels relationshipsXML
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rID {
+			sheetPath = rel.Target
+			if strings.HasPrefix(sheetPath, "/") {
+				sheetPath = strings.TrimPrefix(sheetPath, "/")
+			} else {
+				sheetPath = path.Join("xl", sheetPath)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("sheet relationship %s not found", rID)
+	}
+
+	// Workbooks with only numbers or inline strings have no shared strings part
+	var shared sharedStringsXML
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet sheetXML
+	if err := decodePart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for i, r := range sheet.Rows {
+		row := Row{Number: r.R}
+		if row.Number == 0 {
+			row.Number = i + 1
+		}
+		for j, c := range r.Cells {
+			col := j
+			if c.Ref != "" {
+				if col, _, err = parseRef(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+
+			value := c.Value
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to missing shared string %q", c.Ref, c.Value)
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			case "b":
+				value = map[string]string{"0": "FALSE", "1": "TRUE"}[c.Value]
+			}
+
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, "")
+			}
+			row.Cells[col] = value
+		}
+		if !emptyRow(row.Cells) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid XLSX file: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// maxColumns is the number of columns a sheet can have; the last is XFD
+const maxColumns = 16384
+
+// parseRef splits a cell reference such as "AB12" into a 0-based column and
+// the row number. Columns beyond XFD are rejected, so a crafted reference
+// cannot make a row absurdly wide.
+func parseRef(ref string) (int, int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		if col > maxColumns {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if i == 0 || err != nil {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, row, nil
+}
+
+func emptyRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteReadSheet(t *testing.T) {
+	wide := make([]string, 30)
+	for i := range wide {
+		wide[i] = columnName(i)
+	}
+	tests := []struct {
+		name  string
+		sheet string
+		rows  [][]string
+		want  []Row
+	}{
+		{
+			name:  "backlog",
+			sheet: "Backlog",
+			rows: [][]string{
+				{"Key", "Title", "Acceptance Criteria"},
+				{"REQ-1", "Sign in & out", "valid <user>\n  signs in"},
+				{"REQ-2", "", "trailing space "},
+			},
+			want: []Row{
+				{Number: 1, Cells: []string{"Key", "Title", "Acceptance Criteria"}},
+				{Number: 2, Cells: []string{"REQ-1", "Sign in & out", "valid <user>\n  signs in"}},
+				{Number: 3, Cells: []string{"REQ-2", "", "trailing space "}},
+			},
+		},
+		{
+			name:  "empty rows are skipped",
+			sheet: "Q&A \"sheet\"",
+			rows:  [][]string{{"a"}, {}, {"", " "}, {"", "b"}},
+			want: []Row{
+				{Number: 1, Cells: []string{"a"}},
+				{Number: 4, Cells: []string{"", "b"}},
+			},
+		},
+		{
+			name:  "wide",
+			sheet: "Wide",
+			rows:  [][]string{wide},
+			want:  []Row{{Number: 1, Cells: wide}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSheet(t, tt.sheet, tt.rows)
+			for _, name := range []string{"", tt.sheet, strings.ToUpper(tt.sheet)} {
+				got, err := ReadSheet(path, name)
+				if err != nil {
+					t.Fatalf("ReadSheet(%q): %v", name, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ReadSheet(%q) =\n%q\nwant\n%q", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSheetErrors(t *testing.T) {
+	path := writeSheet(t, "Backlog", [][]string{{"Key"}})
+	if _, err := ReadSheet(path, "Other"); err == nil || !strings.Contains(err.Error(), `sheet "Other" not found (workbook has Backlog)`) {
+		t.Errorf("missing sheet: error = %v", err)
+	}
+
+	notZip := filepath.Join(t.TempDir(), "backlog.xlsx")
+	if err := os.WriteFile(notZip, []byte("Key,Title\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSheet(notZip, ""); err == nil || !strings.Contains(err.Error(), "failed to open XLSX file") {
+		t.Errorf("not a workbook: error = %v", err)
+	}
+
+	crafted := filepath.Join(t.TempDir(), "crafted.xlsx")
+	f, err := os.Create(crafted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"xl/workbook.xml":            fmt.Sprintf(workbookXMLFormat, "Backlog"),
+		"xl/_rels/workbook.xml.rels": workbookRelsXML,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="ZZZZZZZZZZZZZZZ1" t="inlineStr"><is><t>x</t></is></c></row></sheetData></worksheet>`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := ReadSheet(crafted, ""); err == nil || !strings.Contains(err.Error(), `invalid cell reference "ZZZZZZZZZZZZZZZ1"`) {
+		t.Errorf("column beyond XFD: error = %v", err)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		col     int
+		row     int
+		wantErr bool
+	}{
+		{ref: "A1", col: 0, row: 1},
+		{ref: "Z10", col: 25, row: 10},
+		{ref: "AA2", col: 26, row: 2},
+		{ref: "ab12", col: 27, row: 12},
+		{ref: "XFD1048576", col: 16383, row: 1048576},
+		{ref: "12", wantErr: true},
+		{ref: "A", wantErr: true},
+		{ref: "A1B", wantErr: true},
+		{ref: "XFE1", wantErr: true},
+		{ref: "ZZZZZZ1", wantErr: true},
+		{ref: "ZZZZZZZZZZZZZZZ1", wantErr: true},
+	}
+	for _, tt := range tests {
+		col, row, err := parseRef(tt.ref)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRef(%q) = %d, %d; want an error", tt.ref, col, row)
+			}
+			continue
+		}
+		if err != nil || col != tt.col || row != tt.row {
+			t.Errorf("parseRef(%q) = %d, %d, %v; want %d, %d", tt.ref, col, row, err, tt.col, tt.row)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(col); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", col, got, want)
+		}
+		if got, _, _ := parseRef(want + "1"); got != col {
+			t.Errorf("parseRef(%q) column = %d, want %d", want+"1", got, col)
+		}
+	}
+}
+
+func writeSheet(t *testing.T, name string, rows [][]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sheet.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := WriteSheet(f, name, rows); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}